@@ -7,15 +7,25 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/cosmos-link/gen-code/internal/api"
+	"github.com/cosmos-link/gen-code/internal/cassette"
 	"github.com/cosmos-link/gen-code/internal/config"
+	"github.com/cosmos-link/gen-code/internal/deps"
 	"github.com/cosmos-link/gen-code/internal/generator"
 	"github.com/cosmos-link/gen-code/internal/github"
 	"github.com/cosmos-link/gen-code/internal/llm"
+	"github.com/cosmos-link/gen-code/internal/prompts"
+	"github.com/cosmos-link/gen-code/internal/sandbox"
+	"github.com/cosmos-link/gen-code/internal/scaffold"
+	"github.com/cosmos-link/gen-code/internal/secrets"
 	"github.com/cosmos-link/gen-code/internal/task"
+	"github.com/cosmos-link/gen-code/internal/templates"
+	"github.com/cosmos-link/gen-code/internal/usage"
+	"github.com/cosmos-link/gen-code/internal/validate"
 )
 
 func main() {
@@ -32,35 +42,367 @@ func main() {
 		log.Fatalf("Failed to create temp directory: %v", err)
 	}
 
-	// Create LLM client based on default model
-	var llmClient llm.Client
-	switch cfg.LLM.DefaultModel {
-	case "deepseek":
-		if cfg.LLM.DeepSeekAPIKey == "" {
-			log.Fatal("DEEPSEEK_API_KEY is required when using deepseek model")
+	// Load OpenAI-compatible providers such as local Ollama or vLLM servers
+	compatibleProviders := make(map[string]llm.CompatibleConfig)
+	if cfg.LLM.CompatibleProvidersFile != "" {
+		configs, err := llm.LoadCompatibleProviders(cfg.LLM.CompatibleProvidersFile)
+		if err != nil {
+			log.Fatalf("Failed to load OpenAI-compatible providers: %v", err)
 		}
-		llmClient = llm.NewDeepSeekClient(cfg.LLM.DeepSeekAPIKey, cfg.LLM.DeepSeekBaseURL)
-		log.Println("Using DeepSeek as LLM provider")
-	case "openai":
-		if cfg.LLM.OpenAIAPIKey == "" {
-			log.Fatal("OPENAI_API_KEY is required when using openai model")
+		for _, c := range configs {
+			compatibleProviders[c.Name] = c
 		}
-		llmClient = llm.NewOpenAIClient(cfg.LLM.OpenAIAPIKey, cfg.LLM.OpenAIBaseURL)
-		log.Println("Using OpenAI as LLM provider")
-	default:
-		log.Fatalf("Unknown model: %s", cfg.LLM.DefaultModel)
+		log.Printf("Loaded %d OpenAI-compatible providers", len(configs))
 	}
 
-	// Create GitHub client
-	githubClient := github.NewClient(cfg.GitHub.Token, cfg.GitHub.Owner)
-	log.Println("GitHub client initialized")
+	// Configured credentials, which must not appear in generated code or
+	// recorded cassettes
+	credentials := map[string]string{
+		"GITHUB_TOKEN":               cfg.GitHub.Token,
+		"DEEPSEEK_API_KEY":           cfg.LLM.DeepSeekAPIKey,
+		"OPENAI_API_KEY":             cfg.LLM.OpenAIAPIKey,
+		"ANTHROPIC_API_KEY":          cfg.LLM.AnthropicAPIKey,
+		"GIT_SIGNING_KEY_PASSPHRASE": cfg.Git.SigningKeyPassphrase,
+	}
+	for name, c := range compatibleProviders {
+		credentials[name+" api_key"] = c.APIKey
+	}
+
+	// Record or replay LLM and GitHub API exchanges
+	var (
+		httpClient *http.Client
+		onToken    func(token string)
+	)
+	if cfg.Cassette.Path != "" {
+		mode, err := cassette.ParseMode(cfg.Cassette.Mode)
+		if err != nil {
+			log.Fatalf("Invalid cassette configuration: %v", err)
+		}
+		recorder, err := cassette.New(cassette.Config{
+			Path:    cfg.Cassette.Path,
+			Mode:    mode,
+			Scanner: secrets.NewScanner(secrets.Config{Literals: credentials}),
+		})
+		if err != nil {
+			log.Fatalf("Failed to open HTTP cassette: %v", err)
+		}
+		httpClient = recorder.Client()
+		github.InstallGitTransport(httpClient)
+		onToken = recorder.AddSecret
+		for name, c := range compatibleProviders {
+			c.HTTPClient = httpClient
+			compatibleProviders[name] = c
+		}
+		log.Printf("HTTP cassette %s opened in %s mode", cfg.Cassette.Path, mode)
+	}
+
+	// Create LLM clients for the fallback chain, or the default model alone
+	providerNames := cfg.LLM.Providers
+	if len(providerNames) == 0 {
+		providerNames = []string{cfg.LLM.DefaultModel}
+	}
+	var providers []llm.Provider
+	for _, name := range providerNames {
+		switch name {
+		case "deepseek":
+			if cfg.LLM.DeepSeekAPIKey == "" {
+				log.Fatal("DEEPSEEK_API_KEY is required when using deepseek model")
+			}
+			providers = append(providers, llm.Provider{Name: name, Client: llm.NewDeepSeekClient(cfg.LLM.DeepSeekAPIKey, cfg.LLM.DeepSeekBaseURL, modelConfig(cfg.LLM.DeepSeek), httpClient)})
+		case "openai":
+			if cfg.LLM.OpenAIAPIKey == "" {
+				log.Fatal("OPENAI_API_KEY is required when using openai model")
+			}
+			providers = append(providers, llm.Provider{Name: name, Client: llm.NewOpenAIClient(cfg.LLM.OpenAIAPIKey, cfg.LLM.OpenAIBaseURL, modelConfig(cfg.LLM.OpenAI), httpClient)})
+		case "anthropic":
+			if cfg.LLM.AnthropicAPIKey == "" {
+				log.Fatal("ANTHROPIC_API_KEY is required when using anthropic model")
+			}
+			providers = append(providers, llm.Provider{Name: name, Client: llm.NewAnthropicClient(llm.AnthropicConfig{
+				APIKey:      cfg.LLM.AnthropicAPIKey,
+				BaseURL:     cfg.LLM.AnthropicBaseURL,
+				ModelConfig: modelConfig(cfg.LLM.Anthropic),
+				HTTPClient:  httpClient,
+			})})
+		default:
+			compatible, ok := compatibleProviders[name]
+			if !ok {
+				log.Fatalf("Unknown model: %s", name)
+			}
+			providers = append(providers, llm.Provider{Name: name, Client: llm.NewCompatibleClient(compatible)})
+		}
+	}
+	llmClient := providers[0].Client
+	if len(providers) > 1 {
+		llmClient = llm.NewFallbackClient(providers, llm.FallbackConfig{
+			AttemptTimeout:   time.Duration(cfg.LLM.AttemptTimeout) * time.Second,
+			BreakerThreshold: cfg.LLM.BreakerThreshold,
+			BreakerCooldown:  time.Duration(cfg.LLM.BreakerCooldown) * time.Second,
+		})
+	}
+	log.Printf("Using LLM providers: %s", strings.Join(providerNames, ", "))
+
+	// Create GitHub client factory
+	var tokenStore github.TokenStore
+	if cfg.GitHub.CredentialsFile != "" {
+		store, err := github.LoadTokenStore(cfg.GitHub.CredentialsFile)
+		if err != nil {
+			log.Fatalf("Failed to load GitHub credentials: %v", err)
+		}
+		tokenStore = store
+		log.Println("Loaded stored GitHub credentials")
+	}
+	var githubApp *github.App
+	if cfg.GitHub.AppID != 0 {
+		githubApp, err = github.NewApp(github.AppConfig{
+			AppID:          cfg.GitHub.AppID,
+			PrivateKeyPath: cfg.GitHub.AppPrivateKeyFile,
+			APIURL:         cfg.GitHub.APIURL,
+			HTTPClient:     httpClient,
+			OnToken:        onToken,
+		})
+		if err != nil {
+			log.Fatalf("Failed to initialize GitHub App: %v", err)
+		}
+		log.Printf("GitHub App %d authentication enabled", cfg.GitHub.AppID)
+	}
+
+	// Load API clients and the stored credentials and installations each
+	// may use; anonymous callers get only what the server exposes
+	var apiClients []api.Client
+	if cfg.Server.ClientsFile != "" {
+		apiClients, err = api.LoadClients(cfg.Server.ClientsFile)
+		if err != nil {
+			log.Fatalf("Failed to load API clients: %v", err)
+		}
+		log.Printf("API key authentication enabled for %d clients", len(apiClients))
+	}
+	grants := map[string]github.Grant{
+		"": {
+			CredentialRefs:  cfg.GitHub.ExposedCredentials,
+			InstallationIDs: cfg.GitHub.ExposedInstallations,
+		},
+	}
+	for _, client := range apiClients {
+		grants[client.Name] = github.Grant{
+			CredentialRefs:  client.GitHubCredentials,
+			InstallationIDs: client.GitHubInstallations,
+		}
+	}
+
+	githubClients := github.NewClientFactory(github.FactoryConfig{
+		DefaultToken:          cfg.GitHub.Token,
+		DefaultInstallationID: cfg.GitHub.AppInstallationID,
+		Owner:                 cfg.GitHub.Owner,
+		APIURL:                cfg.GitHub.APIURL,
+		Store:                 tokenStore,
+		App:                   githubApp,
+		HTTPClient:            httpClient,
+		OnToken:               onToken,
+		Grants:                grants,
+	})
+	log.Println("GitHub client factory initialized")
 
 	// Create task manager
 	taskManager := task.NewManager(cfg.Task.MaxConcurrentTasks)
 	log.Printf("Task manager initialized with %d concurrent tasks", cfg.Task.MaxConcurrentTasks)
 
+	// Configure commit identity and signing
+	commitOpts := github.CommitOptions{
+		Author:    github.Identity{Name: cfg.Git.AuthorName, Email: cfg.Git.AuthorEmail},
+		Committer: github.Identity{Name: cfg.Git.CommitterName, Email: cfg.Git.CommitterEmail},
+	}
+	if cfg.Git.SigningFormat != "" {
+		commitOpts.Signer, err = github.NewSigner(cfg.Git.SigningFormat, cfg.Git.SigningKeyFile, cfg.Git.SigningKeyPassphrase)
+		if err != nil {
+			log.Fatalf("Failed to load commit signing key: %v", err)
+		}
+		log.Printf("Signing commits with %s key", cfg.Git.SigningFormat)
+	}
+
+	// Configure secret scanning, seeded with our own credentials
+	var secretScanner *secrets.Scanner
+	secretScanMode, err := secrets.ParseMode(cfg.Secrets.Mode)
+	if err != nil {
+		log.Fatalf("Invalid secret scan configuration: %v", err)
+	}
+	if cfg.Secrets.Enabled {
+		var customRules []secrets.Rule
+		if cfg.Secrets.RulesFile != "" {
+			customRules, err = secrets.LoadRules(cfg.Secrets.RulesFile)
+			if err != nil {
+				log.Fatalf("Failed to load secret scan rules: %v", err)
+			}
+		}
+		secretScanner = secrets.NewScanner(secrets.Config{
+			CustomRules:      customRules,
+			Literals:         credentials,
+			EntropyThreshold: cfg.Secrets.EntropyThreshold,
+		})
+		log.Printf("Secret scanning enabled in %s mode with %d custom rules", secretScanMode, len(customRules))
+	}
+
+	// Configure validation of generated code
+	var validators *validate.Registry
+	if cfg.Validation.Enabled {
+		validationTimeout := time.Duration(cfg.Validation.Timeout) * time.Second
+		scriptCfg := validate.ScriptConfig{
+			PythonBinary: cfg.Validation.PythonBinary,
+			NodeBinary:   cfg.Validation.NodeBinary,
+			TSCBinary:    cfg.Validation.TSCBinary,
+			Timeout:      validationTimeout,
+		}
+
+		validators = validate.NewRegistry()
+		validators.Register(validate.NewGoValidator(validate.GoConfig{
+			GoBinary: cfg.Validation.GoBinary,
+			Offline:  cfg.Validation.GoOffline,
+			ModCache: cfg.Validation.GoModCache,
+			Timeout:  validationTimeout,
+		}), validate.TypeGo)
+		validators.Register(validate.NewPythonValidator(scriptCfg), validate.TypePython)
+		validators.Register(validate.NewJavaScriptValidator(scriptCfg), validate.TypeJavaScript)
+		validators.Register(validate.NewTypeScriptValidator(scriptCfg), validate.TypeTypeScript)
+		validators.Register(validate.NewJSONValidator(), validate.TypeJSON)
+		validators.Register(validate.NewYAMLValidator(), validate.TypeYAML)
+		log.Printf("Validation enabled with %d validators", validators.Len())
+	}
+
+	// Configure the sandbox for generated tests
+	testCfg := generator.TestConfig{
+		Limits: sandbox.Limits{
+			CPUSeconds:     cfg.Sandbox.CPUSeconds,
+			MemoryMB:       cfg.Sandbox.MemoryMB,
+			Timeout:        time.Duration(cfg.Sandbox.Timeout) * time.Second,
+			MaxOutputBytes: cfg.Sandbox.MaxOutputBytes,
+		},
+		Images: map[string]string{
+			llm.LanguageGo:         cfg.Sandbox.ImageGo,
+			llm.LanguagePython:     cfg.Sandbox.ImagePython,
+			llm.LanguageJavaScript: cfg.Sandbox.ImageNode,
+			llm.LanguageTypeScript: cfg.Sandbox.ImageNode,
+		},
+		GoModCache: cfg.Validation.GoModCache,
+	}
+	switch cfg.Sandbox.Runner {
+	case "namespace":
+		testCfg.Runner = sandbox.NewNamespaceRunner(cfg.Sandbox.BwrapPath, cfg.Sandbox.ToolchainDirs)
+	case "container":
+		testCfg.Runner = sandbox.NewContainerRunner(cfg.Sandbox.Runtime)
+	}
+	if testCfg.Runner != nil {
+		log.Printf("Running generated tests with %s sandbox", testCfg.Runner.Name())
+	}
+
+	// Load project templates
+	templateRegistry, err := templates.LoadRegistry(cfg.Task.TemplatesDir)
+	if err != nil {
+		log.Fatalf("Failed to load templates: %v", err)
+	}
+	log.Printf("Loaded %d project templates from %s", len(templateRegistry.List()), cfg.Task.TemplatesDir)
+
+	// Configure repository scaffolding
+	var scaffolder *scaffold.Scaffolder
+	if cfg.Scaffold.Enabled {
+		owner := cfg.Scaffold.LicenseOwner
+		if owner == "" {
+			owner = cfg.GitHub.Owner
+		}
+		scaffolder, err = scaffold.New(scaffold.Config{
+			License:    cfg.Scaffold.License,
+			Owner:      owner,
+			CI:         cfg.Scaffold.CI,
+			Dockerfile: cfg.Scaffold.Dockerfile,
+			Overwrite:  cfg.Scaffold.Overwrite,
+		})
+		if err != nil {
+			log.Fatalf("Failed to configure scaffolding: %v", err)
+		}
+		log.Printf("Scaffolding enabled with %s license", cfg.Scaffold.License)
+	}
+
+	// Configure dependency manifest reconciliation
+	var reconciler *deps.Reconciler
+	if cfg.Deps.Enabled {
+		var allowlist *deps.Allowlist
+		if cfg.Deps.AllowlistFile != "" {
+			allowlist, err = deps.LoadAllowlist(cfg.Deps.AllowlistFile)
+			if err != nil {
+				log.Fatalf("Failed to load dependency allowlist: %v", err)
+			}
+		}
+		modCache := cfg.Deps.GoModCache
+		if modCache == "" {
+			modCache = cfg.Validation.GoModCache
+		}
+		reconciler = deps.NewReconciler(deps.Config{
+			Allowlist:    allowlist,
+			GoModCache:   modCache,
+			RemoveUnused: cfg.Deps.RemoveUnused,
+		})
+		log.Println("Dependency reconciliation enabled")
+	}
+
+	// Load the LLM price table for usage accounting
+	prices, err := usage.LoadPrices(cfg.LLM.PricesFile)
+	if err != nil {
+		log.Fatalf("Failed to load LLM prices: %v", err)
+	}
+	usageLedger := usage.NewLedger(prices)
+	if cfg.Task.TokenLimit > 0 {
+		log.Printf("Tasks are limited to %d LLM tokens", cfg.Task.TokenLimit)
+	}
+
+	// Load LLM system prompts
+	promptLibrary, err := prompts.Load(cfg.Prompts.Dir, cfg.Prompts.Reload)
+	if err != nil {
+		log.Fatalf("Failed to load prompts: %v", err)
+	}
+	if cfg.Prompts.Set != "" && !promptLibrary.Has(cfg.Prompts.Set) {
+		log.Fatalf("PROMPT_SET %q is not one of the prompt sets: %s", cfg.Prompts.Set, strings.Join(promptLibrary.Sets(), ", "))
+	}
+	log.Printf("Loaded prompt sets: %s", strings.Join(promptLibrary.Sets(), ", "))
+
+	// Configure the LLM response cache
+	var llmCache *llm.Cache
+	if cfg.LLM.CacheEnabled {
+		llmCache, err = llm.NewCache(llm.CacheConfig{
+			Dir:      cfg.LLM.CacheDir,
+			TTL:      time.Duration(cfg.LLM.CacheTTL) * time.Second,
+			MaxBytes: cfg.LLM.CacheMaxBytes,
+		})
+		if err != nil {
+			log.Fatalf("Failed to create LLM cache: %v", err)
+		}
+		log.Printf("Caching LLM responses in %s", cfg.LLM.CacheDir)
+	}
+
 	// Create generator
-	gen := generator.NewGenerator(llmClient, githubClient, taskManager, cfg.Task.TempDir)
+	gen := generator.NewGenerator(llmClient, githubClients, taskManager, generator.Options{
+		TempDir:           cfg.Task.TempDir,
+		Commit:            commitOpts,
+		SplitCommits:      cfg.Git.SplitCommits,
+		LLMCommitMessages: cfg.Git.LLMCommitMessages,
+		FilePolicy: generator.FilePolicy{
+			MaxFileSize: cfg.Task.MaxFileSize,
+			MaxFiles:    cfg.Task.MaxFiles,
+		},
+		SecretScanner:   secretScanner,
+		SecretScanMode:  secretScanMode,
+		Validators:      validators,
+		FixRounds:       cfg.Validation.FixRounds,
+		FixPolicy:       cfg.Validation.FixPolicy,
+		Tests:           testCfg,
+		Templates:       templateRegistry,
+		Scaffolder:      scaffolder,
+		Reconciler:      reconciler,
+		ApprovalTimeout: time.Duration(cfg.Approval.Timeout) * time.Second,
+		Usage:           usageLedger,
+		Prompts:         promptLibrary,
+		PromptSet:       cfg.Prompts.Set,
+		PromptMaxFiles:  cfg.Prompts.MaxFiles,
+		Cache:           llmCache,
+	})
 	log.Println("Code generator initialized")
 
 	// Create SSE manager
@@ -68,7 +410,7 @@ func main() {
 	log.Println("SSE manager initialized")
 
 	// Create handler
-	handler := api.NewHandler(gen, taskManager, sseManager, cfg)
+	handler := api.NewHandler(gen, taskManager, sseManager, cfg, apiClients)
 
 	// Setup router
 	router := api.SetupRouter(handler)
@@ -111,3 +453,14 @@ func main() {
 
 	log.Println("Server stopped")
 }
+
+// modelConfig converts a provider's configured model and sampling
+// parameters for the LLM client
+func modelConfig(c config.ModelConfig) llm.ModelConfig {
+	models := llm.ModelConfig{Model: c.Model, MaxTokens: c.MaxTokens}
+	if c.Temperature != nil {
+		temperature := float32(*c.Temperature)
+		models.Temperature = &temperature
+	}
+	return models
+}
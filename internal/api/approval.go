@@ -0,0 +1,77 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/cosmos-link/gen-code/internal/llm"
+	"github.com/cosmos-link/gen-code/internal/task"
+	"github.com/gin-gonic/gin"
+)
+
+// ApprovalFile is a file edited by the reviewer
+type ApprovalFile struct {
+	Path    string `json:"path" binding:"required"`
+	Content string `json:"content"`
+}
+
+// ApproveRequest represents an approve request. The body is optional.
+type ApproveRequest struct {
+	Reviewer string         `json:"reviewer"`
+	Files    []ApprovalFile `json:"files"` // Edited or added files to push instead of the generated ones
+}
+
+// RejectRequest represents a reject request. The body is optional.
+type RejectRequest struct {
+	Reviewer string `json:"reviewer"`
+	Reason   string `json:"reason"`
+}
+
+// HandleApprove resumes a task awaiting approval
+func (h *Handler) HandleApprove(c *gin.Context) {
+	var req ApproveRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	approval := task.Approval{Approved: true, Reviewer: req.Reviewer}
+	for _, file := range req.Files {
+		approval.Files = append(approval.Files, llm.FileInfo{Path: file.Path, Content: file.Content})
+	}
+
+	h.resolveApproval(c, approval)
+}
+
+// HandleReject rejects a task awaiting approval
+func (h *Handler) HandleReject(c *gin.Context) {
+	var req RejectRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.resolveApproval(c, task.Approval{Reviewer: req.Reviewer, Reason: req.Reason})
+}
+
+// resolveApproval delivers a decision and writes the response
+func (h *Handler) resolveApproval(c *gin.Context, approval task.Approval) {
+	taskID := c.Param("task_id")
+
+	err := h.taskMgr.ResolveApproval(taskID, approval)
+	if errors.Is(err, task.ErrNotAwaitingApproval) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		return
+	}
+
+	status := "approved"
+	if !approval.Approved {
+		status = "rejected"
+	}
+	c.JSON(http.StatusOK, gin.H{"task_id": taskID, "decision": status})
+}
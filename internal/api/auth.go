@@ -0,0 +1,91 @@
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Client is an API caller and the GitHub identities it may use
+type Client struct {
+	Name                string   `json:"name"`
+	APIKey              string   `json:"api_key"`
+	GitHubCredentials   []string `json:"github_credentials,omitempty"`   // Refs in GITHUB_CREDENTIALS_FILE
+	GitHubInstallations []int64  `json:"github_installations,omitempty"` // GitHub App installation IDs
+}
+
+// callerKey is the gin context key holding the authenticated client's name
+const callerKey = "caller"
+
+// LoadClients reads a JSON array of Client
+func LoadClients(path string) ([]Client, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read clients file: %w", err)
+	}
+
+	var clients []Client
+	if err := json.Unmarshal(data, &clients); err != nil {
+		return nil, fmt.Errorf("failed to parse clients file: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	for _, c := range clients {
+		switch {
+		case c.Name == "":
+			return nil, fmt.Errorf("client without a name in %s", path)
+		case c.APIKey == "":
+			return nil, fmt.Errorf("client %s needs an api_key", c.Name)
+		case seen[c.Name]:
+			return nil, fmt.Errorf("duplicate client %s", c.Name)
+		}
+		seen[c.Name] = true
+	}
+
+	return clients, nil
+}
+
+// authenticate requires a known API key in the Authorization header when
+// clients are configured and records the caller's name. Without clients
+// every request is anonymous.
+func (h *Handler) authenticate(c *gin.Context) {
+	if len(h.clients) == 0 {
+		c.Next()
+		return
+	}
+
+	key, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if ok {
+		for _, client := range h.clients {
+			if subtle.ConstantTimeCompare([]byte(key), []byte(client.APIKey)) == 1 {
+				c.Set(callerKey, client.Name)
+				c.Next()
+				return
+			}
+		}
+	}
+
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "a valid API key is required"})
+}
+
+// authorizeTask lets only the client that created a task reach it. Tasks of
+// other clients are reported as missing so their IDs are not confirmed.
+func (h *Handler) authorizeTask(c *gin.Context) {
+	t, err := h.taskMgr.GetTask(c.Param("task_id"))
+	if err != nil || t.Caller != caller(c) {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		return
+	}
+	c.Next()
+}
+
+// caller returns the name of the authenticated client, or "" for anonymous
+// requests
+func caller(c *gin.Context) string {
+	return c.GetString(callerKey)
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cosmos-link/gen-code/internal/task"
+	"github.com/gin-gonic/gin"
+)
+
+func TestTaskRoutesScopedToCreator(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	manager := task.NewManager(1)
+	tk := manager.CreateTask("prompt", "repo", "", "", task.Options{Caller: "alice"})
+	router := SetupRouter(&Handler{taskMgr: manager, clients: []Client{
+		{Name: "alice", APIKey: "alice-key"},
+		{Name: "bob", APIKey: "bob-key"},
+	}})
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		key        string
+		wantStatus int
+	}{
+		{"creator reads the task", http.MethodGet, "/task/" + tk.ID, "alice-key", http.StatusOK},
+		{"other client reads the task", http.MethodGet, "/task/" + tk.ID, "bob-key", http.StatusNotFound},
+		{"other client lists files", http.MethodGet, "/task/" + tk.ID + "/files", "bob-key", http.StatusNotFound},
+		{"other client downloads a file", http.MethodGet, "/task/" + tk.ID + "/files/main.go", "bob-key", http.StatusNotFound},
+		{"other client downloads an archive", http.MethodGet, "/task/" + tk.ID + "/archive.zip", "bob-key", http.StatusNotFound},
+		{"other client reads the diff", http.MethodGet, "/task/" + tk.ID + "/diff", "bob-key", http.StatusNotFound},
+		{"other client approves", http.MethodPost, "/task/" + tk.ID + "/approve", "bob-key", http.StatusNotFound},
+		{"other client rejects", http.MethodPost, "/task/" + tk.ID + "/reject", "bob-key", http.StatusNotFound},
+		{"other client refines", http.MethodPost, "/task/" + tk.ID + "/refine", "bob-key", http.StatusNotFound},
+		{"other client follows status", http.MethodGet, "/status/" + tk.ID, "bob-key", http.StatusNotFound},
+		{"unknown task", http.MethodGet, "/task/missing", "alice-key", http.StatusNotFound},
+		{"no key", http.MethodGet, "/task/" + tk.ID, "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/api/v1"+tt.path, strings.NewReader(`{"prompt": "add tests"}`))
+			if tt.key != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.key)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+		})
+	}
+}
+
+func TestTaskRoutesWithoutClients(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	manager := task.NewManager(1)
+	tk := manager.CreateTask("prompt", "repo", "", "", task.Options{})
+	router := SetupRouter(&Handler{taskMgr: manager})
+
+	// Without configured clients every request is anonymous and may read
+	// anonymous tasks
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/task/"+tk.ID, nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+}
@@ -0,0 +1,247 @@
+package api
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cosmos-link/gen-code/internal/llm"
+	"github.com/cosmos-link/gen-code/internal/task"
+	"github.com/gin-gonic/gin"
+)
+
+// FileEntry describes a generated file in a listing
+type FileEntry struct {
+	Path     string `json:"path"`
+	Size     int    `json:"size"`
+	Type     string `json:"type,omitempty"`
+	Category string `json:"category,omitempty"`
+}
+
+// FileListResponse represents a generated file listing
+type FileListResponse struct {
+	TaskID      string      `json:"task_id"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Files       []FileEntry `json:"files"`
+}
+
+// HandleListFiles lists the files generated for a task
+func (h *Handler) HandleListFiles(c *gin.Context) {
+	taskID := c.Param("task_id")
+
+	project, ok := h.taskProject(c, taskID)
+	if !ok {
+		return
+	}
+
+	entries := make([]FileEntry, 0, len(project.Files))
+	for _, file := range project.Files {
+		entries = append(entries, FileEntry{
+			Path:     file.Path,
+			Size:     len(file.Content),
+			Type:     file.Type,
+			Category: file.Category,
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+
+	c.JSON(http.StatusOK, FileListResponse{
+		TaskID:      taskID,
+		Name:        project.Name,
+		Description: project.Description,
+		Files:       entries,
+	})
+}
+
+// HandleGetFile returns the content of one generated file
+func (h *Handler) HandleGetFile(c *gin.Context) {
+	taskID := c.Param("task_id")
+	filePath := strings.TrimPrefix(c.Param("path"), "/")
+
+	project, ok := h.taskProject(c, taskID)
+	if !ok {
+		return
+	}
+
+	for _, file := range project.Files {
+		if file.Path == filePath {
+			// Model output is untrusted: never let a browser render it as
+			// HTML or SVG on the API origin
+			c.Header("X-Content-Type-Options", "nosniff")
+			c.Header("Content-Security-Policy", "sandbox")
+			c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(file.Content))
+			return
+		}
+	}
+
+	c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+}
+
+// HandleArchiveZip downloads the generated project as a zip archive
+func (h *Handler) HandleArchiveZip(c *gin.Context) {
+	t, project, ok := h.taskWithProject(c)
+	if !ok {
+		return
+	}
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.zip"`, t.RepoName))
+	c.Status(http.StatusOK)
+
+	zw := zip.NewWriter(c.Writer)
+	modified := t.UpdatedAt
+	for _, file := range project.Files {
+		w, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     path.Join(t.RepoName, file.Path),
+			Method:   zip.Deflate,
+			Modified: modified,
+		})
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		if _, err := w.Write([]byte(file.Content)); err != nil {
+			c.Error(err)
+			return
+		}
+	}
+	if err := zw.Close(); err != nil {
+		c.Error(err)
+	}
+}
+
+// HandleArchiveTarGz downloads the generated project as a gzipped tarball
+func (h *Handler) HandleArchiveTarGz(c *gin.Context) {
+	t, project, ok := h.taskWithProject(c)
+	if !ok {
+		return
+	}
+
+	c.Header("Content-Type", "application/gzip")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.tar.gz"`, t.RepoName))
+	c.Status(http.StatusOK)
+
+	gw := gzip.NewWriter(c.Writer)
+	tw := tar.NewWriter(gw)
+	for _, file := range project.Files {
+		hdr := &tar.Header{
+			Name:    path.Join(t.RepoName, file.Path),
+			Mode:    0644,
+			Size:    int64(len(file.Content)),
+			ModTime: t.UpdatedAt.Truncate(time.Second),
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			c.Error(err)
+			return
+		}
+		if _, err := tw.Write([]byte(file.Content)); err != nil {
+			c.Error(err)
+			return
+		}
+	}
+	if err := tw.Close(); err != nil {
+		c.Error(err)
+		return
+	}
+	if err := gw.Close(); err != nil {
+		c.Error(err)
+	}
+}
+
+// FileDiff is the diff of one file changed by a refinement
+type FileDiff struct {
+	Path    string `json:"path"`
+	Action  string `json:"action"`
+	Added   int    `json:"added"`
+	Removed int    `json:"removed"`
+	Patch   string `json:"patch"`
+}
+
+// DiffResponse represents the changes a refinement made to its parent's
+// project
+type DiffResponse struct {
+	TaskID       string     `json:"task_id"`
+	ParentTaskID string     `json:"parent_task_id,omitempty"`
+	Added        int        `json:"added"`
+	Removed      int        `json:"removed"`
+	Files        []FileDiff `json:"files"`
+}
+
+// HandleDiff returns the unified diff of a refinement, as JSON or, with
+// ?format=patch, as a plain patch that git apply accepts
+func (h *Handler) HandleDiff(c *gin.Context) {
+	t, err := h.taskMgr.GetTask(c.Param("task_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		return
+	}
+	if len(t.FileChanges) == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Task has no changes to diff yet"})
+		return
+	}
+
+	resp := DiffResponse{TaskID: t.ID, ParentTaskID: t.ParentID}
+	var patch strings.Builder
+	for _, change := range t.FileChanges {
+		resp.Added += change.Added
+		resp.Removed += change.Removed
+		resp.Files = append(resp.Files, FileDiff{
+			Path:    change.Path,
+			Action:  change.Action,
+			Added:   change.Added,
+			Removed: change.Removed,
+			Patch:   change.Patch,
+		})
+		patch.WriteString(change.Patch)
+	}
+
+	if c.Query("format") == "patch" {
+		c.Header("X-Content-Type-Options", "nosniff")
+		c.Data(http.StatusOK, "text/x-diff; charset=utf-8", []byte(patch.String()))
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// taskWithProject loads the task named in the request and its project,
+// writing an error response if either is unavailable
+func (h *Handler) taskWithProject(c *gin.Context) (*task.Task, *llm.GeneratedProject, bool) {
+	taskID := c.Param("task_id")
+
+	t, err := h.taskMgr.GetTask(taskID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		return nil, nil, false
+	}
+
+	project, ok := h.taskProject(c, taskID)
+	if !ok {
+		return nil, nil, false
+	}
+	return t, project, true
+}
+
+// taskProject returns the project stored for a task, writing an error
+// response if there is none
+func (h *Handler) taskProject(c *gin.Context, taskID string) (*llm.GeneratedProject, bool) {
+	project, err := h.taskMgr.GetTaskProject(taskID)
+	if errors.Is(err, task.ErrNoProject) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Task has no generated files yet"})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		return nil, false
+	}
+	return project, true
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cosmos-link/gen-code/internal/llm"
+	"github.com/cosmos-link/gen-code/internal/task"
+	"github.com/gin-gonic/gin"
+)
+
+func TestHandleGetFileServesPlainText(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	manager := task.NewManager(1)
+	tk := manager.CreateTask("prompt", "repo", "", "", task.Options{})
+	manager.SetTaskProject(tk.ID, &llm.GeneratedProject{Files: []llm.FileInfo{
+		{Path: "index.html", Content: "<script>alert(1)</script>"},
+		{Path: "logo.svg", Content: `<svg onload="alert(1)"/>`},
+		{Path: "main.go", Content: "package main"},
+	}})
+	router := SetupRouter(&Handler{taskMgr: manager})
+
+	tests := []struct {
+		path       string
+		wantStatus int
+	}{
+		{"index.html", http.StatusOK},
+		{"logo.svg", http.StatusOK},
+		{"main.go", http.StatusOK},
+		{"missing.go", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/task/"+tk.ID+"/files/"+tt.path, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if got := w.Header().Get("Content-Type"); got != "text/plain; charset=utf-8" {
+				t.Errorf("Content-Type = %q, want plain text", got)
+			}
+			if got := w.Header().Get("X-Content-Type-Options"); got != "nosniff" {
+				t.Errorf("X-Content-Type-Options = %q, want nosniff", got)
+			}
+		})
+	}
+}
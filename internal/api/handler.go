@@ -3,9 +3,12 @@ package api
 import (
 	"fmt"
 	"net/http"
+	"slices"
+	"strings"
 
 	"github.com/cosmos-link/gen-code/internal/config"
 	"github.com/cosmos-link/gen-code/internal/generator"
+	"github.com/cosmos-link/gen-code/internal/scaffold"
 	"github.com/cosmos-link/gen-code/internal/task"
 	"github.com/gin-gonic/gin"
 )
@@ -16,15 +19,18 @@ type Handler struct {
 	taskMgr    *task.Manager
 	sseManager *SSEManager
 	cfg        *config.Config
+	clients    []Client
 }
 
-// NewHandler creates a new handler
-func NewHandler(gen *generator.Generator, taskMgr *task.Manager, sseManager *SSEManager, cfg *config.Config) *Handler {
+// NewHandler creates a new handler. With clients, every API request must
+// carry one of their keys.
+func NewHandler(gen *generator.Generator, taskMgr *task.Manager, sseManager *SSEManager, cfg *config.Config, clients []Client) *Handler {
 	return &Handler{
 		generator:  gen,
 		taskMgr:    taskMgr,
 		sseManager: sseManager,
 		cfg:        cfg,
+		clients:    clients,
 	}
 }
 
@@ -34,6 +40,63 @@ type GenerateRequest struct {
 	RepoName  string `json:"repo_name" binding:"required"`
 	Model     string `json:"model"`
 	GitHubOrg string `json:"github_org"`
+	Private   bool   `json:"private"`
+	DryRun    bool   `json:"dry_run"`
+
+	// Pause for human approval before creating the repository
+	RequireApproval bool `json:"require_approval"`
+
+	// Optional LLM token limit, at most the server's TASK_TOKEN_LIMIT
+	TokenLimit int `json:"token_limit"`
+
+	// Skip cached LLM responses
+	NoCache bool `json:"no_cache"`
+
+	// Optional model variant and sampling overrides
+	Sampling
+
+	// Optional LLM prompt set, target language and coding conventions
+	PromptSettings
+
+	// Optional GitHub identity
+	GitHubAuth
+
+	// Optional starter template and its variables
+	Template     string            `json:"template"`
+	TemplateVars map[string]string `json:"template_vars"`
+
+	// Optional license for the scaffolded LICENSE file, or "none"
+	License string `json:"license"`
+
+	// Optional commit identity overrides
+	CommitAuthor      *task.CommitIdentity  `json:"commit_author"`
+	CommitCommitter   *task.CommitIdentity  `json:"commit_committer"`
+	CoAuthors         []task.CommitIdentity `json:"co_authors"`
+	CoAuthorRequester bool                  `json:"co_author_requester"`
+}
+
+// GitHubAuth holds an optional per-request GitHub identity; at most one
+// field may be set
+type GitHubAuth struct {
+	GitHubToken          string `json:"github_token"`
+	GitHubCredential     string `json:"github_credential"`
+	GitHubInstallationID int64  `json:"github_installation_id"`
+}
+
+// Sampling holds optional per-request model and sampling overrides, within
+// the bounds the server configures
+type Sampling struct {
+	ModelVariant string   `json:"model_variant"` // e.g. deepseek-reasoner
+	Temperature  *float32 `json:"temperature"`
+	MaxTokens    int      `json:"max_tokens"`
+}
+
+// PromptSettings holds optional per-request choices for the LLM system
+// prompts; empty values fall back to the template's and the server's
+type PromptSettings struct {
+	PromptSet   string `json:"prompt_set"`  // e.g. en or zh
+	Language    string `json:"language"`    // e.g. Go
+	Conventions string `json:"conventions"` // e.g. "use table-driven tests"
 }
 
 // GenerateResponse represents a generate response
@@ -57,13 +120,84 @@ func (h *Handler) HandleGenerate(c *gin.Context) {
 	}
 
 	// Validate model
-	if req.Model != "deepseek" && req.Model != "openai" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid model, must be 'deepseek' or 'openai'"})
+	if !h.knownModel(req.Model) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid model, must be 'deepseek', 'openai', 'anthropic' or a provider listed in LLM_PROVIDERS"})
+		return
+	}
+
+	// Validate GitHub credentials
+	creds, err := req.githubCredentials(caller(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.generator.AuthorizeGitHub(creds); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Validate commit identities
+	if err := req.validateIdentities(h.cfg.Git.SigningFormat != ""); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Validate template
+	if req.Template != "" {
+		if err := h.generator.ValidateTemplate(req.Template, req.TemplateVars); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	// Validate token limit
+	tokenLimit, err := h.tokenLimit(req.TokenLimit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Validate model variant and sampling overrides
+	if err := h.validateSampling(req.Model, &req.Sampling); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Validate prompt set
+	if err := h.validatePrompts(&req.PromptSettings); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Validate license
+	if req.License != "" && !scaffold.HasLicense(req.License) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unknown license %q, must be one of: %s", req.License, strings.Join(scaffold.Licenses(), ", "))})
 		return
 	}
 
 	// Create task
-	t := h.taskMgr.CreateTask(req.Prompt, req.RepoName, req.Model, req.GitHubOrg)
+	t := h.taskMgr.CreateTask(req.Prompt, req.RepoName, req.Model, req.GitHubOrg, task.Options{
+		Private:           req.Private,
+		DryRun:            req.DryRun,
+		RequireApproval:   req.RequireApproval || h.cfg.Approval.Required,
+		GitHub:            creds,
+		Caller:            caller(c),
+		ModelVariant:      req.ModelVariant,
+		Temperature:       req.Temperature,
+		MaxTokens:         req.MaxTokens,
+		PromptSet:         req.PromptSet,
+		Language:          req.Language,
+		Conventions:       req.Conventions,
+		Template:          req.Template,
+		TemplateVars:      req.TemplateVars,
+		License:           req.License,
+		TokenLimit:        tokenLimit,
+		NoCache:           req.NoCache,
+		CommitAuthor:      req.CommitAuthor,
+		CommitCommitter:   req.CommitCommitter,
+		CoAuthors:         req.CoAuthors,
+		CoAuthorRequester: req.CoAuthorRequester,
+	})
 
 	// Subscribe SSE manager to task updates
 	h.taskMgr.SubscribeToTask(t.ID, func(task *task.Task) {
@@ -81,6 +215,119 @@ func (h *Handler) HandleGenerate(c *gin.Context) {
 	})
 }
 
+// githubCredentials returns the per-task GitHub identity supplied by
+// caller, or nil to use the service default
+func (r *GitHubAuth) githubCredentials(caller string) (*task.GitHubCredentials, error) {
+	set := 0
+	for _, ok := range []bool{r.GitHubToken != "", r.GitHubCredential != "", r.GitHubInstallationID != 0} {
+		if ok {
+			set++
+		}
+	}
+
+	switch {
+	case set == 0:
+		return nil, nil
+	case set > 1:
+		return nil, ValidationError("only one of github_token, github_credential or github_installation_id may be set")
+	}
+
+	return &task.GitHubCredentials{
+		Token:          r.GitHubToken,
+		CredentialRef:  r.GitHubCredential,
+		InstallationID: r.GitHubInstallationID,
+		Caller:         caller,
+	}, nil
+}
+
+// knownModel reports whether model names a built-in provider or one the
+// server is configured with
+func (h *Handler) knownModel(model string) bool {
+	if model == "deepseek" || model == "openai" || model == "anthropic" || model == h.cfg.LLM.DefaultModel {
+		return true
+	}
+	for _, provider := range h.cfg.LLM.Providers {
+		if model == provider {
+			return true
+		}
+	}
+	return false
+}
+
+// tokenLimit returns the token limit for a new task: the requested limit
+// if it is within the server's, otherwise the server's
+func (h *Handler) tokenLimit(requested int) (int, error) {
+	limit := h.cfg.Task.TokenLimit
+	switch {
+	case requested < 0:
+		return 0, ValidationError("token_limit must not be negative")
+	case requested == 0:
+		return limit, nil
+	case limit > 0 && requested > limit:
+		return 0, ValidationError(fmt.Sprintf("token_limit must not exceed the server limit of %d", limit))
+	}
+	return requested, nil
+}
+
+// validateSampling checks that a model variant is offered by the model's
+// provider and that sampling overrides are within the server's bounds
+func (h *Handler) validateSampling(model string, s *Sampling) error {
+	if s.ModelVariant != "" {
+		models, ok := h.cfg.LLM.Models(model)
+		if !ok {
+			return ValidationError(fmt.Sprintf("model_variant is not supported for model %q", model))
+		}
+		if s.ModelVariant != models.Model && !slices.Contains(models.Variants, s.ModelVariant) {
+			return ValidationError(fmt.Sprintf("unknown model_variant %q for %s, must be one of: %s", s.ModelVariant, model, strings.Join(append([]string{models.Model}, models.Variants...), ", ")))
+		}
+	}
+
+	if s.Temperature != nil && (*s.Temperature < 0 || float64(*s.Temperature) > h.cfg.LLM.MaxTemperature) {
+		return ValidationError(fmt.Sprintf("temperature must be between 0 and %g", h.cfg.LLM.MaxTemperature))
+	}
+
+	switch {
+	case s.MaxTokens < 0:
+		return ValidationError("max_tokens must not be negative")
+	case h.cfg.LLM.MaxTokensLimit > 0 && s.MaxTokens > h.cfg.LLM.MaxTokensLimit:
+		return ValidationError(fmt.Sprintf("max_tokens must not exceed the server limit of %d", h.cfg.LLM.MaxTokensLimit))
+	}
+
+	return nil
+}
+
+// validatePrompts checks that a requested prompt set exists
+func (h *Handler) validatePrompts(p *PromptSettings) error {
+	if p.PromptSet != "" && !h.generator.HasPromptSet(p.PromptSet) {
+		return ValidationError(fmt.Sprintf("unknown prompt_set %q, must be one of: %s", p.PromptSet, strings.Join(h.generator.PromptSets(), ", ")))
+	}
+	return nil
+}
+
+// validateIdentities checks that every supplied commit identity is complete.
+// While commits are signed with the server's key the committer is the key's
+// owner, so a committer override would attribute that signature to someone else
+func (r *GenerateRequest) validateIdentities(signing bool) error {
+	if signing && r.CommitCommitter != nil {
+		return ValidationError("commit_committer cannot be set while commits are signed with the server's key")
+	}
+
+	identities := append([]task.CommitIdentity(nil), r.CoAuthors...)
+	if r.CommitAuthor != nil {
+		identities = append(identities, *r.CommitAuthor)
+	}
+	if r.CommitCommitter != nil {
+		identities = append(identities, *r.CommitCommitter)
+	}
+
+	for _, identity := range identities {
+		if identity.Name == "" || identity.Email == "" || !strings.Contains(identity.Email, "@") {
+			return ValidationError(fmt.Sprintf("commit identity %q <%s> needs a name and a valid email", identity.Name, identity.Email))
+		}
+	}
+	return nil
+}
+
 // HandleGetTask handles the get task request
 func (h *Handler) HandleGetTask(c *gin.Context) {
 	taskID := c.Param("task_id")
@@ -94,6 +341,11 @@ func (h *Handler) HandleGetTask(c *gin.Context) {
 	c.JSON(http.StatusOK, t)
 }
 
+// HandleListTemplates lists the available project templates
+func (h *Handler) HandleListTemplates(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"templates": h.generator.Templates()})
+}
+
 // HandleStatus handles the SSE status endpoint
 func (h *Handler) HandleStatus(c *gin.Context) {
 	HandleSSE(c, h.sseManager, h.taskMgr)
@@ -115,7 +367,7 @@ func SetupRouter(handler *Handler) *gin.Engine {
 	r.Use(func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -126,11 +378,25 @@ func SetupRouter(handler *Handler) *gin.Engine {
 	})
 
 	// API routes
-	api := r.Group("/api/v1")
+	api := r.Group("/api/v1", handler.authenticate)
 	{
 		api.POST("/generate", handler.HandleGenerate)
-		api.GET("/task/:task_id", handler.HandleGetTask)
-		api.GET("/status/:task_id", handler.HandleStatus)
+		api.GET("/status/:task_id", handler.authorizeTask, handler.HandleStatus)
+		api.GET("/templates", handler.HandleListTemplates)
+		api.GET("/usage/keys", handler.HandleUsageByKey)
+		api.GET("/usage/days", handler.HandleUsageByDay)
+
+		// Task routes are limited to the client that created the task
+		tasks := api.Group("/task/:task_id", handler.authorizeTask)
+		tasks.GET("", handler.HandleGetTask)
+		tasks.GET("/files", handler.HandleListFiles)
+		tasks.GET("/files/*path", handler.HandleGetFile)
+		tasks.GET("/archive.zip", handler.HandleArchiveZip)
+		tasks.GET("/archive.tar.gz", handler.HandleArchiveTarGz)
+		tasks.GET("/diff", handler.HandleDiff)
+		tasks.POST("/approve", handler.HandleApprove)
+		tasks.POST("/reject", handler.HandleReject)
+		tasks.POST("/refine", handler.HandleRefine)
 	}
 
 	// Health check
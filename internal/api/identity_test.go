@@ -0,0 +1,39 @@
+package api
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cosmos-link/gen-code/internal/task"
+)
+
+func TestValidateIdentities(t *testing.T) {
+	alice := &task.CommitIdentity{Name: "Alice", Email: "alice@example.com"}
+	tests := []struct {
+		name    string
+		req     GenerateRequest
+		signing bool
+		wantErr string
+	}{
+		{"no overrides", GenerateRequest{}, false, ""},
+		{"author and committer", GenerateRequest{CommitAuthor: alice, CommitCommitter: alice}, false, ""},
+		{"author while signing", GenerateRequest{CommitAuthor: alice, CoAuthors: []task.CommitIdentity{*alice}}, true, ""},
+		{"committer while signing", GenerateRequest{CommitCommitter: alice}, true, "cannot be set while commits are signed"},
+		{"missing email", GenerateRequest{CommitAuthor: &task.CommitIdentity{Name: "Alice"}}, false, "needs a name and a valid email"},
+		{"invalid co-author", GenerateRequest{CoAuthors: []task.CommitIdentity{{Name: "Bob", Email: "bob"}}}, false, "needs a name and a valid email"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.validateIdentities(tt.signing)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("err = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/cosmos-link/gen-code/internal/task"
+	"github.com/gin-gonic/gin"
+)
+
+// RefineRequest represents a follow-up prompt on a completed task
+type RefineRequest struct {
+	Prompt string `json:"prompt" binding:"required"`
+	Mode   string `json:"mode"` // commit (default) or pull_request
+
+	// Pause for human approval before pushing
+	RequireApproval bool `json:"require_approval"`
+
+	// Optional LLM token limit, at most the server's TASK_TOKEN_LIMIT
+	TokenLimit int `json:"token_limit"`
+
+	// Skip cached LLM responses
+	NoCache bool `json:"no_cache"`
+
+	// Optional model variant and sampling overrides; each defaults to the
+	// parent task's
+	Sampling
+
+	// Optional LLM prompt set, target language and coding conventions;
+	// each defaults to the parent task's
+	PromptSettings
+
+	// Optional GitHub identity; defaults to the parent task's credential
+	// reference or installation
+	GitHubAuth
+}
+
+// RefineResponse represents a refine response
+type RefineResponse struct {
+	TaskID       string `json:"task_id"`
+	ParentTaskID string `json:"parent_task_id"`
+	Status       string `json:"status"`
+	Message      string `json:"message"`
+}
+
+// HandleRefine starts a refinement task that changes a completed task's
+// project and pushes the result to the same repository
+func (h *Handler) HandleRefine(c *gin.Context) {
+	var req RefineRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	parent, err := h.taskMgr.GetTask(c.Param("task_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		return
+	}
+	if parent.Status != task.StatusCompleted {
+		c.JSON(http.StatusConflict, gin.H{"error": "Task must be completed before it can be refined"})
+		return
+	}
+
+	// Validate mode
+	switch req.Mode {
+	case "":
+		req.Mode = task.RefineModeCommit
+	case task.RefineModeCommit, task.RefineModePullRequest:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid mode, must be '%s' or '%s'", task.RefineModeCommit, task.RefineModePullRequest)})
+		return
+	}
+
+	// Validate token limit
+	tokenLimit, err := h.tokenLimit(req.TokenLimit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Validate model variant and sampling overrides
+	if req.ModelVariant == "" {
+		req.ModelVariant = parent.ModelVariant
+	}
+	if req.Temperature == nil {
+		req.Temperature = parent.Temperature
+	}
+	if req.MaxTokens == 0 {
+		req.MaxTokens = parent.MaxTokens
+	}
+	if err := h.validateSampling(parent.Model, &req.Sampling); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Validate prompt set
+	if req.PromptSet == "" {
+		req.PromptSet = parent.PromptSet
+	}
+	if req.Language == "" {
+		req.Language = parent.Language
+	}
+	if req.Conventions == "" {
+		req.Conventions = parent.Conventions
+	}
+	if err := h.validatePrompts(&req.PromptSettings); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Validate GitHub credentials
+	creds, err := req.githubCredentials(caller(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if creds == nil && parent.GitHub != nil && !parent.DryRun {
+		// Raw tokens are dropped when a task finishes; references are kept
+		if parent.GitHub.CredentialRef == "" && parent.GitHub.InstallationID == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "github_token is required: the parent task's token is not retained"})
+			return
+		}
+		// The refining caller must itself be allowed to use the parent's
+		// credential ref or installation
+		creds = &task.GitHubCredentials{
+			CredentialRef:  parent.GitHub.CredentialRef,
+			InstallationID: parent.GitHub.InstallationID,
+			Caller:         caller(c),
+		}
+	}
+	if err := h.generator.AuthorizeGitHub(creds); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Create the child task with the parent's repository settings
+	t := h.taskMgr.CreateTask(req.Prompt, parent.RepoName, parent.Model, parent.GitHubOrg, task.Options{
+		Private:           parent.Private,
+		DryRun:            parent.DryRun,
+		RequireApproval:   req.RequireApproval || h.cfg.Approval.Required,
+		GitHub:            creds,
+		Caller:            caller(c),
+		ModelVariant:      req.ModelVariant,
+		Temperature:       req.Temperature,
+		MaxTokens:         req.MaxTokens,
+		PromptSet:         req.PromptSet,
+		Language:          req.Language,
+		Conventions:       req.Conventions,
+		License:           parent.License,
+		ParentID:          parent.ID,
+		RefineMode:        req.Mode,
+		TokenLimit:        tokenLimit,
+		NoCache:           req.NoCache,
+		CommitAuthor:      parent.CommitAuthor,
+		CommitCommitter:   parent.CommitCommitter,
+		CoAuthors:         parent.CoAuthors,
+		CoAuthorRequester: parent.CoAuthorRequester,
+	})
+
+	// Subscribe SSE manager to task updates
+	h.taskMgr.SubscribeToTask(t.ID, func(task *task.Task) {
+		h.sseManager.Broadcast(task)
+	})
+
+	// Start refinement asynchronously
+	go h.generator.ProcessRefinement(t.ID)
+
+	c.JSON(http.StatusOK, RefineResponse{
+		TaskID:       t.ID,
+		ParentTaskID: parent.ID,
+		Status:       string(t.Status),
+		Message:      t.Message,
+	})
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
@@ -125,7 +126,7 @@ func HandleSSE(c *gin.Context, sseManager *SSEManager, taskManager *task.Manager
 	defer log.Printf("SSE client disconnected for task %s", taskID)
 
 	// Send initial status
-	sendSSEMessage(c.Writer, "status", t)
+	sendTaskUpdate(c.Writer, t)
 	flusher.Flush()
 
 	// If task is already terminal, close connection
@@ -146,7 +147,7 @@ func HandleSSE(c *gin.Context, sseManager *SSEManager, taskManager *task.Manager
 
 		case task := <-client.Channel:
 			log.Printf("SSE sending status update for task %s: %s", taskID, task.Status)
-			sendSSEMessage(c.Writer, "status", task)
+			sendTaskUpdate(c.Writer, task)
 			flusher.Flush()
 			
 			// Close connection if task is terminal
@@ -178,3 +179,39 @@ func sendSSEMessage(w io.Writer, event string, task *task.Task) {
 	
 	fmt.Fprintf(w, "}\n\n")
 }
+
+// sendTaskUpdate sends a status event, followed by an awaiting_approval
+// event when the task is paused for review and a diff event when a
+// refinement's changes are applied or final
+func sendTaskUpdate(w io.Writer, t *task.Task) {
+	sendSSEMessage(w, "status", t)
+	if t.Status == task.StatusAwaitingApproval {
+		sendApprovalMessage(w, t)
+	}
+	if len(t.FileChanges) > 0 && (t.Status == task.StatusMergingFiles || t.IsTerminal()) {
+		sendDiffMessage(w, t)
+	}
+}
+
+// sendDiffMessage reports the files a refinement changed with their added
+// and removed line counts
+func sendDiffMessage(w io.Writer, t *task.Task) {
+	data, _ := json.Marshal(gin.H{
+		"task_id": t.ID,
+		"status":  t.Status,
+		"files":   t.FileChanges,
+	})
+	fmt.Fprintf(w, "event: diff\ndata: %s\n\n", data)
+}
+
+// sendApprovalMessage announces that a task is paused for approval
+func sendApprovalMessage(w io.Writer, t *task.Task) {
+	data, _ := json.Marshal(gin.H{
+		"task_id":           t.ID,
+		"status":            t.Status,
+		"message":           t.Message,
+		"file_count":        t.FileCount,
+		"approval_deadline": t.ApprovalDeadline,
+	})
+	fmt.Fprintf(w, "event: awaiting_approval\ndata: %s\n\n", data)
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/cosmos-link/gen-code/internal/usage"
+	"github.com/gin-gonic/gin"
+)
+
+// UsageResponse represents aggregated LLM usage
+type UsageResponse struct {
+	From      *time.Time      `json:"from,omitempty"`
+	To        *time.Time      `json:"to,omitempty"`
+	Summaries []usage.Summary `json:"summaries"`
+}
+
+// HandleUsageByKey aggregates LLM usage by provider and API key
+func (h *Handler) HandleUsageByKey(c *gin.Context) {
+	h.handleUsage(c, h.generator.UsageByKey)
+}
+
+// HandleUsageByDay aggregates LLM usage by UTC day
+func (h *Handler) HandleUsageByDay(c *gin.Context) {
+	h.handleUsage(c, h.generator.UsageByDay)
+}
+
+// handleUsage parses the optional from and to query parameters, given as
+// dates (to is inclusive) or RFC 3339 times, and writes the aggregation
+func (h *Handler) handleUsage(c *gin.Context, aggregate func(from, to time.Time) []usage.Summary) {
+	from, err := parseUsageTime(c.Query("from"), false)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from: " + err.Error()})
+		return
+	}
+	to, err := parseUsageTime(c.Query("to"), true)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to: " + err.Error()})
+		return
+	}
+
+	resp := UsageResponse{Summaries: aggregate(from, to)}
+	if !from.IsZero() {
+		resp.From = &from
+	}
+	if !to.IsZero() {
+		resp.To = &to
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// parseUsageTime parses a date or RFC 3339 time. An end date covers the
+// whole day. An empty value returns the zero time.
+func parseUsageTime(value string, end bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if day, err := time.Parse(time.DateOnly, value); err == nil {
+		if end {
+			day = day.AddDate(0, 0, 1)
+		}
+		return day, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
@@ -0,0 +1,323 @@
+// Package cassette records HTTP exchanges with LLM providers and the GitHub
+// API to files and replays them offline, so that code generation can be
+// exercised in tests without network access or credentials.
+package cassette
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"unicode/utf8"
+
+	"github.com/cosmos-link/gen-code/internal/secrets"
+)
+
+// Mode selects whether a Recorder records or replays exchanges
+type Mode string
+
+const (
+	ModeRecord Mode = "record" // Forward requests and save the exchanges
+	ModeReplay Mode = "replay" // Answer requests from the cassette without network access
+)
+
+// ParseMode converts a mode name from configuration
+func ParseMode(name string) (Mode, error) {
+	switch Mode(name) {
+	case ModeRecord, ModeReplay:
+		return Mode(name), nil
+	}
+	return "", fmt.Errorf("unknown cassette mode %q, must be 'record' or 'replay'", name)
+}
+
+// sensitiveHeaders are replaced by secrets.Redacted in recorded exchanges
+var sensitiveHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"X-Api-Key",
+	"Api-Key",
+	"Cookie",
+	"Set-Cookie",
+}
+
+// Config holds recorder settings
+type Config struct {
+	Path string // Cassette file, one per recording session
+	Mode Mode
+
+	// Scanner finds the tokens scrubbed from recorded URLs and bodies, such
+	// as our own API keys; defaults to the built-in secret rules
+	Scanner *secrets.Scanner
+
+	// Transport makes the real requests when recording; defaults to
+	// http.DefaultTransport
+	Transport http.RoundTripper
+}
+
+// Interaction is a recorded request and its response
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request is the recorded form of an HTTP request
+type Request struct {
+	Method       string      `json:"method"`
+	URL          string      `json:"url"`
+	Header       http.Header `json:"header,omitempty"`
+	Body         string      `json:"body,omitempty"`
+	BodyEncoding string      `json:"body_encoding,omitempty"` // "base64" for binary bodies such as Git packs
+}
+
+// Response is the recorded form of an HTTP response
+type Response struct {
+	StatusCode   int         `json:"status_code"`
+	Header       http.Header `json:"header,omitempty"`
+	Body         string      `json:"body,omitempty"`
+	BodyEncoding string      `json:"body_encoding,omitempty"` // "base64" for binary bodies such as Git packs
+}
+
+// base64Encoding marks a body stored in base64
+const base64Encoding = "base64"
+
+// encodeBody returns the stored form of a body and its encoding. Bodies that
+// are not UTF-8 are stored in base64, since JSON strings would mangle them.
+func encodeBody(body string) (string, string) {
+	if utf8.ValidString(body) {
+		return body, ""
+	}
+	return base64.StdEncoding.EncodeToString([]byte(body)), base64Encoding
+}
+
+// decodeBody reverses encodeBody
+func decodeBody(body, encoding string) (string, error) {
+	switch encoding {
+	case "":
+		return body, nil
+	case base64Encoding:
+		data, err := base64.StdEncoding.DecodeString(body)
+		return string(data), err
+	}
+	return "", fmt.Errorf("unknown body encoding %q", encoding)
+}
+
+// Recorder is an http.RoundTripper that records exchanges to a cassette or
+// replays them from it. Recorded exchanges never contain credentials:
+// auth headers and tokens in URLs and bodies are redacted.
+type Recorder struct {
+	cfg     Config
+	scanner atomic.Pointer[secrets.Scanner] // cfg.Scanner with the secrets added since
+
+	secretsMu sync.Mutex
+	secrets   map[string]bool
+
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// New creates a recorder. In replay mode the cassette must exist; in record
+// mode it is replaced.
+func New(cfg Config) (*Recorder, error) {
+	if cfg.Scanner == nil {
+		cfg.Scanner = secrets.NewScanner(secrets.Config{})
+	}
+	if cfg.Transport == nil {
+		cfg.Transport = http.DefaultTransport
+	}
+
+	r := &Recorder{cfg: cfg, secrets: make(map[string]bool)}
+	r.scanner.Store(cfg.Scanner)
+	switch cfg.Mode {
+	case ModeRecord:
+		if err := os.MkdirAll(filepath.Dir(cfg.Path), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create cassette directory: %w", err)
+		}
+	case ModeReplay:
+		data, err := os.ReadFile(cfg.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cassette: %w", err)
+		}
+		if err := json.Unmarshal(data, &r.interactions); err != nil {
+			return nil, fmt.Errorf("failed to parse cassette: %w", err)
+		}
+		r.used = make([]bool, len(r.interactions))
+	default:
+		return nil, fmt.Errorf("unknown cassette mode %q", cfg.Mode)
+	}
+
+	return r, nil
+}
+
+// AddSecret scrubs value from exchanges recorded from now on, for tokens
+// that are only known at runtime such as per-request GitHub tokens and
+// minted installation tokens
+func (r *Recorder) AddSecret(value string) {
+	r.secretsMu.Lock()
+	defer r.secretsMu.Unlock()
+
+	if value == "" || r.secrets[value] {
+		return
+	}
+	r.secrets[value] = true
+	name := fmt.Sprintf("runtime secret %d", len(r.secrets))
+	r.scanner.Store(r.scanner.Load().WithLiterals(map[string]string{name: value}))
+}
+
+// Client returns an HTTP client that sends its requests through r
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// RoundTrip records or replays a request
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Read the body from a clone, since a RoundTripper must not modify the
+	// caller's request
+	req = req.Clone(req.Context())
+	body, err := readBody(&req.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	body = r.scrub(body)
+
+	if r.cfg.Mode == ModeReplay {
+		return r.replay(req, req.Method, r.scrub(req.URL.String()), body)
+	}
+
+	recorded := Request{
+		Method: req.Method,
+		URL:    r.scrub(req.URL.String()),
+		Header: r.scrubHeader(req.Header),
+	}
+	recorded.Body, recorded.BodyEncoding = encodeBody(body)
+
+	resp, err := r.cfg.Transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := readBody(&resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	recordedResp := Response{
+		StatusCode: resp.StatusCode,
+		Header:     r.scrubHeader(resp.Header),
+	}
+	recordedResp.Body, recordedResp.BodyEncoding = encodeBody(r.scrub(respBody))
+
+	if err := r.record(Interaction{Request: recorded, Response: recordedResp}); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// replay answers a request with the first unused interaction recorded for
+// the same method, URL and body, all scrubbed
+func (r *Recorder) replay(req *http.Request, method, url, body string) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, interaction := range r.interactions {
+		if r.used[i] {
+			continue
+		}
+		if interaction.Request.Method != method || interaction.Request.URL != url {
+			continue
+		}
+		if recordedBody, err := decodeBody(interaction.Request.Body, interaction.Request.BodyEncoding); err != nil || recordedBody != body {
+			continue
+		}
+
+		resp := interaction.Response
+		respBody, err := decodeBody(resp.Body, resp.BodyEncoding)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode recorded response body: %w", err)
+		}
+		r.used[i] = true
+
+		header := resp.Header.Clone()
+		if header == nil {
+			header = make(http.Header)
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", resp.StatusCode, http.StatusText(resp.StatusCode)),
+			StatusCode:    resp.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(bytes.NewReader([]byte(respBody))),
+			ContentLength: int64(len(respBody)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("cassette %s has no recorded response for %s %s", r.cfg.Path, method, url)
+}
+
+// record appends an interaction and saves the cassette, so that exchanges
+// recorded before a crash are kept
+func (r *Recorder) record(interaction Interaction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.interactions = append(r.interactions, interaction)
+
+	data, err := json.MarshalIndent(r.interactions, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode cassette: %w", err)
+	}
+	if err := os.WriteFile(r.cfg.Path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return nil
+}
+
+// scrub redacts the tokens the scanner finds in s
+func (r *Recorder) scrub(s string) string {
+	return secrets.Redact(s, r.scanner.Load().Scan("", s))
+}
+
+// scrubHeader copies a header, redacting credentials
+func (r *Recorder) scrubHeader(header http.Header) http.Header {
+	if len(header) == 0 {
+		return nil
+	}
+
+	scrubbed := make(http.Header, len(header))
+	for name, values := range header {
+		for _, value := range values {
+			scrubbed.Add(name, r.scrub(value))
+		}
+	}
+	for _, name := range sensitiveHeaders {
+		if scrubbed.Get(name) != "" {
+			scrubbed.Set(name, secrets.Redacted)
+		}
+	}
+	return scrubbed
+}
+
+// readBody reads a request or response body and replaces it with a copy so
+// that it can still be read by its consumer
+func readBody(body *io.ReadCloser) (string, error) {
+	if *body == nil || *body == http.NoBody {
+		return "", nil
+	}
+
+	data, err := io.ReadAll(*body)
+	(*body).Close()
+	if err != nil {
+		return "", err
+	}
+	*body = io.NopCloser(bytes.NewReader(data))
+	return string(data), nil
+}
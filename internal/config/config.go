@@ -4,37 +4,116 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
 
 // Config holds all configuration for the application
 type Config struct {
-	Server  ServerConfig
-	GitHub  GitHubConfig
-	LLM     LLMConfig
-	Task    TaskConfig
+	Server     ServerConfig
+	GitHub     GitHubConfig
+	Git        GitConfig
+	LLM        LLMConfig
+	Task       TaskConfig
+	Secrets    SecretsConfig
+	Validation ValidationConfig
+	Sandbox    SandboxConfig
+	Scaffold   ScaffoldConfig
+	Deps       DepsConfig
+	Approval   ApprovalConfig
+	Prompts    PromptsConfig
+	Cassette   CassetteConfig
 }
 
 // ServerConfig holds server-related configuration
 type ServerConfig struct {
 	Port string
 	Host string
+
+	// Optional JSON array of API clients with their keys and the GitHub
+	// identities each may use; when set, every API request needs a key
+	ClientsFile string
 }
 
 // GitHubConfig holds GitHub-related configuration
 type GitHubConfig struct {
-	Token string
-	Owner string
+	Token           string
+	Owner           string
+	CredentialsFile string
+	APIURL          string
+
+	// GitHub App authentication
+	AppID             int64
+	AppPrivateKeyFile string
+	AppInstallationID int64
+
+	// Stored credential refs and App installations that anonymous requests
+	// may select; authenticated clients use the grants in their entry
+	ExposedCredentials   []string
+	ExposedInstallations []int64
+}
+
+// GitConfig holds commit identity and signing configuration
+type GitConfig struct {
+	AuthorName     string
+	AuthorEmail    string
+	CommitterName  string
+	CommitterEmail string
+
+	SigningFormat        string // "gpg", "ssh" or empty for unsigned commits
+	SigningKeyFile       string
+	SigningKeyPassphrase string
+
+	SplitCommits      bool // One commit per file category instead of a single commit
+	LLMCommitMessages bool // Let the LLM write commit messages
 }
 
 // LLMConfig holds LLM-related configuration
 type LLMConfig struct {
-	DeepSeekAPIKey  string
-	DeepSeekBaseURL string
-	OpenAIAPIKey    string
-	OpenAIBaseURL   string
-	DefaultModel    string
+	DeepSeekAPIKey   string
+	DeepSeekBaseURL  string
+	OpenAIAPIKey     string
+	OpenAIBaseURL    string
+	AnthropicAPIKey  string
+	AnthropicBaseURL string
+	DefaultModel     string
+	PricesFile       string // Optional JSON price table, USD per million tokens by model
+
+	// Per-provider models and sampling parameters
+	DeepSeek  ModelConfig
+	OpenAI    ModelConfig
+	Anthropic ModelConfig
+
+	// Bounds on the sampling parameters a request may set
+	MaxTemperature float64
+	MaxTokensLimit int
+
+	// Optional JSON array of OpenAI-compatible providers (Ollama, vLLM,
+	// llama.cpp), usable by name in DEFAULT_MODEL and LLM_PROVIDERS
+	CompatibleProvidersFile string
+
+	// Fallback chain: providers tried in order when a call fails with a
+	// retryable error; empty uses DefaultModel alone
+	Providers        []string
+	AttemptTimeout   int // Per-provider attempt limit in seconds, 0 for none
+	BreakerThreshold int // Consecutive failures that take a provider out of the chain, 0 to disable
+	BreakerCooldown  int // Seconds a failing provider stays out of the chain
+
+	// On-disk cache of LLM responses, keyed by provider, model, sampling
+	// parameters and prompt
+	CacheEnabled  bool
+	CacheDir      string
+	CacheTTL      int   // Seconds a response is served, 0 for no expiry
+	CacheMaxBytes int64 // Cache size above which old responses are removed, 0 for no limit
+}
+
+// ModelConfig holds a provider's model and sampling configuration
+type ModelConfig struct {
+	Model       string
+	Variants    []string // Other models a request may select with model_variant
+	Temperature *float64 // Replaces the per-operation defaults when set
+	MaxTokens   int      // Replaces the per-operation defaults when set, 0 to keep them
 }
 
 // TaskConfig holds task-related configuration
@@ -42,6 +121,89 @@ type TaskConfig struct {
 	MaxConcurrentTasks int
 	TaskTimeout        int
 	TempDir            string
+	MaxFileSize        int // Largest generated file accepted, in bytes
+	MaxFiles           int // Most generated files accepted per project
+	TemplatesDir       string
+	TokenLimit         int // LLM tokens a task may use before it is aborted, 0 for no limit
+}
+
+// SecretsConfig holds secret scanning configuration
+type SecretsConfig struct {
+	Enabled          bool
+	Mode             string // block, redact or warn
+	RulesFile        string // Optional JSON file of custom rules
+	EntropyThreshold float64
+}
+
+// ValidationConfig holds generated-code validation configuration
+type ValidationConfig struct {
+	Enabled    bool
+	GoBinary   string
+	GoOffline  bool   // Run the Go toolchain with GOPROXY=off
+	GoModCache string // Optional GOMODCACHE for offline dependency resolution
+	Timeout    int    // Per-command timeout in seconds
+
+	// Syntax checkers for other languages; each is skipped if not installed
+	PythonBinary string
+	NodeBinary   string
+	TSCBinary    string
+
+	FixRounds int    // LLM fix-up rounds when validation fails
+	FixPolicy string // "block" or "push" when errors remain after fix-up
+}
+
+// SandboxConfig holds configuration for running generated tests
+type SandboxConfig struct {
+	Runner         string // "namespace", "container" or "none"
+	BwrapPath      string
+	ToolchainDirs  []string // Extra host paths visible read-only to the namespace runner
+	Runtime        string   // Container runtime binary, e.g. docker or podman
+	CPUSeconds     int
+	MemoryMB       int
+	Timeout        int // Wall-clock limit per test command in seconds
+	MaxOutputBytes int
+	ImageGo        string
+	ImagePython    string
+	ImageNode      string
+}
+
+// ScaffoldConfig holds configuration for generated repository boilerplate
+type ScaffoldConfig struct {
+	Enabled      bool
+	License      string // SPDX identifier, or "none"
+	LicenseOwner string // Copyright owner; defaults to the GitHub owner
+	CI           bool
+	Dockerfile   bool
+	Overwrite    bool // Replace files the LLM already produced
+}
+
+// DepsConfig holds dependency manifest reconciliation configuration
+type DepsConfig struct {
+	Enabled       bool
+	AllowlistFile string // JSON: {"go": {"module": "version"}, "python": {"dist": "specifier"}}
+	GoModCache    string // Module cache used to resolve versions; defaults to VALIDATION_GOMODCACHE
+	RemoveUnused  bool
+}
+
+// ApprovalConfig holds configuration for the human approval gate
+type ApprovalConfig struct {
+	Required bool // Require approval for every task
+	Timeout  int  // Seconds to wait before auto-rejecting
+}
+
+// PromptsConfig holds LLM system prompt configuration
+type PromptsConfig struct {
+	Dir      string // Optional directory of prompt sets overriding the embedded ones
+	Set      string // Prompt set for every provider; empty uses each provider's own
+	MaxFiles int    // File limit stated in project prompts
+	Reload   bool   // Re-read Dir on every call, for editing prompts in development
+}
+
+// CassetteConfig holds configuration for recording and replaying LLM and
+// GitHub API exchanges
+type CassetteConfig struct {
+	Path string // Cassette file; empty sends requests to the network as usual
+	Mode string // "record" or "replay"
 }
 
 // Load loads configuration from environment variables
@@ -53,22 +215,148 @@ func Load() (*Config, error) {
 		Server: ServerConfig{
 			Port: getEnv("SERVER_PORT", "8080"),
 			Host: getEnv("SERVER_HOST", "0.0.0.0"),
+
+			ClientsFile: getEnv("API_CLIENTS_FILE", ""),
 		},
 		GitHub: GitHubConfig{
-			Token: getEnv("GITHUB_TOKEN", ""),
-			Owner: getEnv("GITHUB_OWNER", ""),
+			Token:           getEnv("GITHUB_TOKEN", ""),
+			Owner:           getEnv("GITHUB_OWNER", ""),
+			CredentialsFile: getEnv("GITHUB_CREDENTIALS_FILE", ""),
+			APIURL:          getEnv("GITHUB_API_URL", "https://api.github.com/"),
+
+			AppID:             getEnvAsInt64("GITHUB_APP_ID", 0),
+			AppPrivateKeyFile: getEnv("GITHUB_APP_PRIVATE_KEY_FILE", ""),
+			AppInstallationID: getEnvAsInt64("GITHUB_APP_INSTALLATION_ID", 0),
+
+			ExposedCredentials:   getEnvAsList("GITHUB_EXPOSED_CREDENTIALS", nil),
+			ExposedInstallations: getEnvAsInt64List("GITHUB_EXPOSED_INSTALLATIONS"),
+		},
+		Git: GitConfig{
+			AuthorName:     getEnv("GIT_AUTHOR_NAME", "Gen Code Bot"),
+			AuthorEmail:    getEnv("GIT_AUTHOR_EMAIL", "bot@gencode.dev"),
+			CommitterName:  getEnv("GIT_COMMITTER_NAME", ""),
+			CommitterEmail: getEnv("GIT_COMMITTER_EMAIL", ""),
+
+			SigningFormat:        getEnv("GIT_SIGNING_FORMAT", ""),
+			SigningKeyFile:       getEnv("GIT_SIGNING_KEY_FILE", ""),
+			SigningKeyPassphrase: getEnv("GIT_SIGNING_KEY_PASSPHRASE", ""),
+
+			SplitCommits:      getEnvAsBool("GIT_SPLIT_COMMITS", true),
+			LLMCommitMessages: getEnvAsBool("GIT_LLM_COMMIT_MESSAGES", false),
 		},
 		LLM: LLMConfig{
-			DeepSeekAPIKey:  getEnv("DEEPSEEK_API_KEY", ""),
-			DeepSeekBaseURL: getEnv("DEEPSEEK_BASE_URL", "https://api.deepseek.com"),
-			OpenAIAPIKey:    getEnv("OPENAI_API_KEY", ""),
-			OpenAIBaseURL:   getEnv("OPENAI_BASE_URL", "https://api.openai.com/v1"),
-			DefaultModel:    getEnv("DEFAULT_MODEL", "deepseek"),
+			DeepSeekAPIKey:   getEnv("DEEPSEEK_API_KEY", ""),
+			DeepSeekBaseURL:  getEnv("DEEPSEEK_BASE_URL", "https://api.deepseek.com"),
+			OpenAIAPIKey:     getEnv("OPENAI_API_KEY", ""),
+			OpenAIBaseURL:    getEnv("OPENAI_BASE_URL", "https://api.openai.com/v1"),
+			AnthropicAPIKey:  getEnv("ANTHROPIC_API_KEY", ""),
+			AnthropicBaseURL: getEnv("ANTHROPIC_BASE_URL", "https://api.anthropic.com"),
+			DefaultModel:     getEnv("DEFAULT_MODEL", "deepseek"),
+			PricesFile:       getEnv("LLM_PRICES_FILE", ""),
+
+			DeepSeek: ModelConfig{
+				Model:       getEnv("DEEPSEEK_MODEL", "deepseek-chat"),
+				Variants:    getEnvAsList("DEEPSEEK_MODEL_VARIANTS", []string{"deepseek-reasoner"}),
+				Temperature: getEnvAsOptionalFloat("DEEPSEEK_TEMPERATURE"),
+				MaxTokens:   getEnvAsInt("DEEPSEEK_MAX_TOKENS", 0),
+			},
+			OpenAI: ModelConfig{
+				Model:       getEnv("OPENAI_MODEL", "gpt-4-turbo-preview"),
+				Variants:    getEnvAsList("OPENAI_MODEL_VARIANTS", []string{"gpt-4o", "gpt-4o-mini"}),
+				Temperature: getEnvAsOptionalFloat("OPENAI_TEMPERATURE"),
+				MaxTokens:   getEnvAsInt("OPENAI_MAX_TOKENS", 0),
+			},
+			Anthropic: ModelConfig{
+				Model:       getEnv("ANTHROPIC_MODEL", "claude-sonnet-4-5"),
+				Variants:    getEnvAsList("ANTHROPIC_MODEL_VARIANTS", nil),
+				Temperature: getEnvAsOptionalFloat("ANTHROPIC_TEMPERATURE"),
+				MaxTokens:   getEnvAsInt("ANTHROPIC_MAX_TOKENS", 0),
+			},
+
+			MaxTemperature: getEnvAsFloat("LLM_MAX_TEMPERATURE", 1.0),
+			MaxTokensLimit: getEnvAsInt("LLM_MAX_TOKENS_LIMIT", 16384),
+
+			CompatibleProvidersFile: getEnv("LLM_COMPATIBLE_PROVIDERS_FILE", ""),
+
+			Providers:        getEnvAsList("LLM_PROVIDERS", nil),
+			AttemptTimeout:   getEnvAsInt("LLM_ATTEMPT_TIMEOUT", 300),
+			BreakerThreshold: getEnvAsInt("LLM_BREAKER_THRESHOLD", 3),
+			BreakerCooldown:  getEnvAsInt("LLM_BREAKER_COOLDOWN", 60),
+
+			CacheEnabled:  getEnvAsBool("LLM_CACHE_ENABLED", false),
+			CacheDir:      getEnv("LLM_CACHE_DIR", "./cache/llm"),
+			CacheTTL:      getEnvAsInt("LLM_CACHE_TTL", 86400),
+			CacheMaxBytes: getEnvAsInt64("LLM_CACHE_MAX_BYTES", 100<<20),
 		},
 		Task: TaskConfig{
 			MaxConcurrentTasks: getEnvAsInt("MAX_CONCURRENT_TASKS", 5),
 			TaskTimeout:        getEnvAsInt("TASK_TIMEOUT", 600),
 			TempDir:            getEnv("TEMP_DIR", "./tmp"),
+			MaxFileSize:        getEnvAsInt("MAX_FILE_SIZE", 1<<20),
+			MaxFiles:           getEnvAsInt("MAX_FILES", 100),
+			TemplatesDir:       getEnv("TEMPLATES_DIR", "./templates"),
+			TokenLimit:         getEnvAsInt("TASK_TOKEN_LIMIT", 0),
+		},
+		Secrets: SecretsConfig{
+			Enabled:          getEnvAsBool("SECRET_SCAN_ENABLED", true),
+			Mode:             getEnv("SECRET_SCAN_MODE", "block"),
+			RulesFile:        getEnv("SECRET_SCAN_RULES_FILE", ""),
+			EntropyThreshold: getEnvAsFloat("SECRET_SCAN_ENTROPY_THRESHOLD", 4.5),
+		},
+		Validation: ValidationConfig{
+			Enabled:    getEnvAsBool("VALIDATION_ENABLED", true),
+			GoBinary:   getEnv("VALIDATION_GO_BINARY", "go"),
+			GoOffline:  getEnvAsBool("VALIDATION_GO_OFFLINE", true),
+			GoModCache: getEnv("VALIDATION_GOMODCACHE", ""),
+			Timeout:    getEnvAsInt("VALIDATION_TIMEOUT", 120),
+
+			PythonBinary: getEnv("VALIDATION_PYTHON_BINARY", "python3"),
+			NodeBinary:   getEnv("VALIDATION_NODE_BINARY", "node"),
+			TSCBinary:    getEnv("VALIDATION_TSC_BINARY", "tsc"),
+
+			FixRounds: getEnvAsInt("FIX_MAX_ROUNDS", 2),
+			FixPolicy: getEnv("FIX_POLICY", "block"),
+		},
+		Sandbox: SandboxConfig{
+			Runner:         getEnv("SANDBOX_RUNNER", "namespace"),
+			BwrapPath:      getEnv("SANDBOX_BWRAP_PATH", "bwrap"),
+			ToolchainDirs:  getEnvAsList("SANDBOX_TOOLCHAIN_DIRS", nil),
+			Runtime:        getEnv("SANDBOX_RUNTIME", "docker"),
+			CPUSeconds:     getEnvAsInt("SANDBOX_CPU_SECONDS", 120),
+			MemoryMB:       getEnvAsInt("SANDBOX_MEMORY_MB", 2048),
+			Timeout:        getEnvAsInt("SANDBOX_TIMEOUT", 300),
+			MaxOutputBytes: getEnvAsInt("SANDBOX_MAX_OUTPUT_BYTES", 64<<10),
+			ImageGo:        getEnv("SANDBOX_IMAGE_GO", "golang:1.24"),
+			ImagePython:    getEnv("SANDBOX_IMAGE_PYTHON", "python:3.12-slim"),
+			ImageNode:      getEnv("SANDBOX_IMAGE_NODE", "node:20-slim"),
+		},
+		Scaffold: ScaffoldConfig{
+			Enabled:      getEnvAsBool("SCAFFOLD_ENABLED", true),
+			License:      getEnv("SCAFFOLD_LICENSE", "MIT"),
+			LicenseOwner: getEnv("SCAFFOLD_LICENSE_OWNER", ""),
+			CI:           getEnvAsBool("SCAFFOLD_CI", true),
+			Dockerfile:   getEnvAsBool("SCAFFOLD_DOCKERFILE", false),
+			Overwrite:    getEnvAsBool("SCAFFOLD_OVERWRITE", false),
+		},
+		Deps: DepsConfig{
+			Enabled:       getEnvAsBool("DEPS_ENABLED", true),
+			AllowlistFile: getEnv("DEPS_ALLOWLIST_FILE", ""),
+			GoModCache:    getEnv("DEPS_GOMODCACHE", ""),
+			RemoveUnused:  getEnvAsBool("DEPS_REMOVE_UNUSED", true),
+		},
+		Approval: ApprovalConfig{
+			Required: getEnvAsBool("APPROVAL_REQUIRED", false),
+			Timeout:  getEnvAsInt("APPROVAL_TIMEOUT", 86400),
+		},
+		Prompts: PromptsConfig{
+			Dir:      getEnv("PROMPTS_DIR", ""),
+			Set:      getEnv("PROMPT_SET", ""),
+			MaxFiles: getEnvAsInt("PROMPT_MAX_FILES", 5),
+			Reload:   getEnvAsBool("PROMPTS_RELOAD", false),
+		},
+		Cassette: CassetteConfig{
+			Path: getEnv("HTTP_CASSETTE", ""),
+			Mode: getEnv("HTTP_CASSETTE_MODE", "replay"),
 		},
 	}
 
@@ -80,15 +368,114 @@ func Load() (*Config, error) {
 	return cfg, nil
 }
 
+// Models returns the model configuration of a built-in provider
+func (c *LLMConfig) Models(provider string) (ModelConfig, bool) {
+	switch provider {
+	case "deepseek":
+		return c.DeepSeek, true
+	case "openai":
+		return c.OpenAI, true
+	case "anthropic":
+		return c.Anthropic, true
+	}
+	return ModelConfig{}, false
+}
+
 // Validate checks if required configuration fields are set
 func (c *Config) Validate() error {
-	if c.GitHub.Token == "" {
-		return fmt.Errorf("GITHUB_TOKEN is required")
+	// A shared token, stored per-user credentials or a GitHub App must be available
+	if c.GitHub.Token == "" && c.GitHub.CredentialsFile == "" && c.GitHub.AppID == 0 {
+		return fmt.Errorf("GITHUB_TOKEN, GITHUB_CREDENTIALS_FILE or GITHUB_APP_ID is required")
+	}
+
+	if c.GitHub.AppID != 0 && c.GitHub.AppPrivateKeyFile == "" {
+		return fmt.Errorf("GITHUB_APP_PRIVATE_KEY_FILE is required when GITHUB_APP_ID is set")
+	}
+
+	switch c.Git.SigningFormat {
+	case "":
+	case "gpg", "ssh":
+		if c.Git.SigningKeyFile == "" {
+			return fmt.Errorf("GIT_SIGNING_KEY_FILE is required when GIT_SIGNING_FORMAT is set")
+		}
+	default:
+		return fmt.Errorf("GIT_SIGNING_FORMAT must be 'gpg' or 'ssh'")
+	}
+
+	switch c.Secrets.Mode {
+	case "block", "redact", "warn":
+	default:
+		return fmt.Errorf("SECRET_SCAN_MODE must be 'block', 'redact' or 'warn'")
+	}
+
+	if c.Validation.FixPolicy != "block" && c.Validation.FixPolicy != "push" {
+		return fmt.Errorf("FIX_POLICY must be 'block' or 'push'")
+	}
+
+	switch c.Sandbox.Runner {
+	case "namespace", "container", "none":
+	default:
+		return fmt.Errorf("SANDBOX_RUNNER must be 'namespace', 'container' or 'none'")
+	}
+
+	if c.Task.TokenLimit < 0 {
+		return fmt.Errorf("TASK_TOKEN_LIMIT must not be negative")
+	}
+
+	// At least one LLM API key or OpenAI-compatible provider must be set
+	if c.LLM.DeepSeekAPIKey == "" && c.LLM.OpenAIAPIKey == "" && c.LLM.AnthropicAPIKey == "" && c.LLM.CompatibleProvidersFile == "" {
+		return fmt.Errorf("at least one LLM API key (DEEPSEEK_API_KEY, OPENAI_API_KEY or ANTHROPIC_API_KEY) or LLM_COMPATIBLE_PROVIDERS_FILE is required")
+	}
+
+	// Every provider in the fallback chain must have a key; other names
+	// refer to OpenAI-compatible providers, which are resolved at startup
+	for _, provider := range c.LLM.Providers {
+		switch provider {
+		case "deepseek":
+			if c.LLM.DeepSeekAPIKey == "" {
+				return fmt.Errorf("DEEPSEEK_API_KEY is required when LLM_PROVIDERS includes deepseek")
+			}
+		case "openai":
+			if c.LLM.OpenAIAPIKey == "" {
+				return fmt.Errorf("OPENAI_API_KEY is required when LLM_PROVIDERS includes openai")
+			}
+		case "anthropic":
+			if c.LLM.AnthropicAPIKey == "" {
+				return fmt.Errorf("ANTHROPIC_API_KEY is required when LLM_PROVIDERS includes anthropic")
+			}
+		default:
+			if c.LLM.CompatibleProvidersFile == "" {
+				return fmt.Errorf("LLM_PROVIDERS contains unknown provider %q", provider)
+			}
+		}
+	}
+
+	if c.LLM.AttemptTimeout < 0 || c.LLM.BreakerThreshold < 0 || c.LLM.BreakerCooldown < 0 {
+		return fmt.Errorf("LLM_ATTEMPT_TIMEOUT, LLM_BREAKER_THRESHOLD and LLM_BREAKER_COOLDOWN must not be negative")
+	}
+
+	if c.LLM.MaxTemperature < 0 || c.LLM.MaxTokensLimit < 0 {
+		return fmt.Errorf("LLM_MAX_TEMPERATURE and LLM_MAX_TOKENS_LIMIT must not be negative")
+	}
+	for name, models := range map[string]ModelConfig{"DEEPSEEK": c.LLM.DeepSeek, "OPENAI": c.LLM.OpenAI, "ANTHROPIC": c.LLM.Anthropic} {
+		if models.Temperature != nil && *models.Temperature < 0 {
+			return fmt.Errorf("%s_TEMPERATURE must not be negative", name)
+		}
+		if models.MaxTokens < 0 {
+			return fmt.Errorf("%s_MAX_TOKENS must not be negative", name)
+		}
+	}
+
+	if c.LLM.CacheTTL < 0 || c.LLM.CacheMaxBytes < 0 {
+		return fmt.Errorf("LLM_CACHE_TTL and LLM_CACHE_MAX_BYTES must not be negative")
 	}
 
-	// At least one LLM API key must be set
-	if c.LLM.DeepSeekAPIKey == "" && c.LLM.OpenAIAPIKey == "" {
-		return fmt.Errorf("at least one LLM API key (DEEPSEEK_API_KEY or OPENAI_API_KEY) is required")
+	if c.Prompts.MaxFiles <= 0 {
+		return fmt.Errorf("PROMPT_MAX_FILES must be positive")
+	}
+
+	if c.Cassette.Path != "" && c.Cassette.Mode != "record" && c.Cassette.Mode != "replay" {
+		return fmt.Errorf("HTTP_CASSETTE_MODE must be 'record' or 'replay'")
 	}
 
 	return nil
@@ -111,3 +498,73 @@ func getEnvAsInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+// getEnvAsInt64 gets an environment variable as int64 or returns a default value
+func getEnvAsInt64(key string, defaultValue int64) int64 {
+	if value := os.Getenv(key); value != "" {
+		if intValue, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return intValue
+		}
+	}
+	return defaultValue
+}
+
+// getEnvAsBool gets an environment variable as bool or returns a default value
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
+// getEnvAsList gets a comma-separated environment variable as a list,
+// dropping empty entries, or returns a default value
+func getEnvAsList(key string, defaultValue []string) []string {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return defaultValue
+	}
+
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// getEnvAsInt64List gets a comma-separated environment variable as int64s,
+// skipping invalid items
+func getEnvAsInt64List(key string) []int64 {
+	var list []int64
+	for _, item := range getEnvAsList(key, nil) {
+		if intValue, err := strconv.ParseInt(item, 10, 64); err == nil {
+			list = append(list, intValue)
+		}
+	}
+	return list
+}
+
+// getEnvAsOptionalFloat gets an environment variable as float64, or nil if
+// it is unset or invalid
+func getEnvAsOptionalFloat(key string) *float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return &floatValue
+		}
+	}
+	return nil
+}
+
+// getEnvAsFloat gets an environment variable as float64 or returns a default value
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
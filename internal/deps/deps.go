@@ -0,0 +1,114 @@
+package deps
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/build"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cosmos-link/gen-code/internal/llm"
+)
+
+// Change actions
+const (
+	ActionAdded   = "added"   // Entry added to the manifest
+	ActionRemoved = "removed" // Unused entry removed from the manifest
+	ActionFlagged = "flagged" // Entry left for review, e.g. a possibly hallucinated package
+)
+
+// Change is a manifest change or finding made while reconciling dependencies
+type Change struct {
+	Manifest string // go.mod or requirements.txt
+	Package  string
+	Version  string
+	Action   string
+	Reason   string
+}
+
+// Allowlist holds known-good packages per ecosystem. Values are the version
+// to use when adding the package: a Go module version, or a pip version
+// specifier such as ">=3.0". An empty value means latest known.
+type Allowlist struct {
+	Go     map[string]string `json:"go"`
+	Python map[string]string `json:"python"`
+}
+
+// LoadAllowlist reads an allowlist from a JSON file
+func LoadAllowlist(path string) (*Allowlist, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read allowlist: %w", err)
+	}
+
+	var allow Allowlist
+	if err := json.Unmarshal(data, &allow); err != nil {
+		return nil, fmt.Errorf("failed to parse allowlist: %w", err)
+	}
+
+	// Python distribution names compare normalized
+	normalized := make(map[string]string, len(allow.Python))
+	for name, spec := range allow.Python {
+		normalized[normalizeDist(name)] = spec
+	}
+	allow.Python = normalized
+
+	return &allow, nil
+}
+
+// Config holds dependency reconciliation settings
+type Config struct {
+	Allowlist    *Allowlist // Optional
+	GoModCache   string     // Module cache used to resolve versions; defaults to GOPATH/pkg/mod
+	RemoveUnused bool       // Drop manifest entries no source file imports
+}
+
+// Reconciler brings generated dependency manifests in line with the imports
+// of the generated sources
+type Reconciler struct {
+	cfg   Config
+	allow Allowlist
+}
+
+// NewReconciler creates a new reconciler
+func NewReconciler(cfg Config) *Reconciler {
+	if cfg.GoModCache == "" {
+		cfg.GoModCache = os.Getenv("GOMODCACHE")
+	}
+	if cfg.GoModCache == "" {
+		cfg.GoModCache = filepath.Join(build.Default.GOPATH, "pkg", "mod")
+	}
+
+	r := &Reconciler{cfg: cfg}
+	if cfg.Allowlist != nil {
+		r.allow = *cfg.Allowlist
+	}
+	return r
+}
+
+// Reconcile updates the go.mod and requirements.txt files of project in
+// place and returns what changed
+func (r *Reconciler) Reconcile(project *llm.GeneratedProject) ([]Change, error) {
+	goChanges, err := r.reconcileGo(project)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reconcile go.mod: %w", err)
+	}
+
+	pyChanges, err := r.reconcilePython(project)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reconcile requirements.txt: %w", err)
+	}
+
+	return append(goChanges, pyChanges...), nil
+}
+
+// findFile returns the index of the file at p, or -1
+func findFile(project *llm.GeneratedProject, p string) int {
+	for i, file := range project.Files {
+		if strings.EqualFold(file.Path, p) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,247 @@
+package deps
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/cosmos-link/gen-code/internal/llm"
+	"golang.org/x/mod/module"
+)
+
+// writeModCache creates download cache entries for the versions of a module
+func writeModCache(t *testing.T, dir, modulePath string, versions ...string) {
+	t.Helper()
+	escaped, err := module.EscapePath(modulePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	versionDir := filepath.Join(dir, "cache", "download", filepath.FromSlash(escaped), "@v")
+	if err := os.MkdirAll(versionDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range versions {
+		if err := os.WriteFile(filepath.Join(versionDir, v+".info"), []byte("{}"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// changeList formats changes as "action package version" for comparison
+func changeList(changes []Change) []string {
+	list := make([]string, 0, len(changes))
+	for _, c := range changes {
+		list = append(list, strings.TrimSpace(c.Action+" "+c.Package+" "+c.Version))
+	}
+	return list
+}
+
+// projectFile returns the content of the file at p, or "" if missing
+func projectFile(project *llm.GeneratedProject, p string) string {
+	if idx := findFile(project, p); idx >= 0 {
+		return project.Files[idx].Content
+	}
+	return ""
+}
+
+func TestReconcileGo(t *testing.T) {
+	cache := t.TempDir()
+	writeModCache(t, cache, "github.com/gin-gonic/gin", "v1.9.1", "v1.10.0", "v1.11.0-rc.1")
+	writeModCache(t, cache, "github.com/BurntSushi/toml", "v1.3.2")
+	writeModCache(t, cache, "github.com/google/uuid", "v1.6.0")
+
+	const header = "module example.com/app\n\ngo 1.22\n"
+
+	tests := []struct {
+		name         string
+		gomod        string
+		source       string // main.go
+		removeUnused bool
+		allow        map[string]string
+		want         string // Expected go.mod, "" when it must not change
+		wantChanges  []string
+	}{
+		{
+			name:        "adds the latest release of a missing module",
+			gomod:       header,
+			source:      `import "github.com/gin-gonic/gin/binding"`,
+			want:        header + "\nrequire github.com/gin-gonic/gin v1.10.0\n",
+			wantChanges: []string{"added github.com/gin-gonic/gin v1.10.0"},
+		},
+		{
+			name:        "resolves upper-case module paths through the escaped cache",
+			gomod:       header,
+			source:      `import "github.com/BurntSushi/toml"`,
+			want:        header + "\nrequire github.com/BurntSushi/toml v1.3.2\n",
+			wantChanges: []string{"added github.com/BurntSushi/toml v1.3.2"},
+		},
+		{
+			name:        "allowlist version wins over the cache",
+			gomod:       header,
+			source:      `import "github.com/gin-gonic/gin"`,
+			allow:       map[string]string{"github.com/gin-gonic/gin": "v1.9.1"},
+			want:        header + "\nrequire github.com/gin-gonic/gin v1.9.1\n",
+			wantChanges: []string{"added github.com/gin-gonic/gin v1.9.1"},
+		},
+		{
+			name:        "flags unknown modules without adding them",
+			gomod:       header,
+			source:      `import "github.com/made/up/v2/pkg"`,
+			wantChanges: []string{"flagged github.com/made/up/v2"},
+		},
+		{
+			name:   "ignores the standard library and the module's own packages",
+			gomod:  header,
+			source: `import ("fmt"; "net/http"; "example.com/app/internal/store")`,
+		},
+		{
+			name:         "removes unused direct requirements and keeps comments",
+			gomod:        header + "\nrequire (\n\t// Router\n\tgithub.com/gin-gonic/gin v1.10.0\n\tgithub.com/google/uuid v1.6.0\n\tgolang.org/x/text v0.14.0 // indirect\n)\n",
+			source:       `import "github.com/gin-gonic/gin"`,
+			removeUnused: true,
+			want:         header + "\n// Router\nrequire github.com/gin-gonic/gin v1.10.0\n\nrequire golang.org/x/text v0.14.0 // indirect\n",
+			wantChanges:  []string{"removed github.com/google/uuid v1.6.0"},
+		},
+		{
+			name:   "keeps unused requirements unless asked",
+			gomod:  header + "\nrequire github.com/google/uuid v1.6.0\n",
+			source: `import "fmt"`,
+		},
+		{
+			name:        "flags required modules missing from the cache",
+			gomod:       header + "\nrequire github.com/made/up v1.0.0\n",
+			source:      `import "github.com/made/up"`,
+			wantChanges: []string{"flagged github.com/made/up v1.0.0"},
+		},
+		{
+			name:   "leaves a go.mod that does not parse to validation",
+			gomod:  "module example.com/app\n\nrequire (\n",
+			source: `import "github.com/gin-gonic/gin"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			project := &llm.GeneratedProject{Files: []llm.FileInfo{
+				{Path: "go.mod", Content: tt.gomod},
+				{Path: "main.go", Content: "package main\n\n" + tt.source + "\n"},
+			}}
+			r := NewReconciler(Config{
+				GoModCache:   cache,
+				RemoveUnused: tt.removeUnused,
+				Allowlist:    &Allowlist{Go: tt.allow},
+			})
+
+			changes, err := r.Reconcile(project)
+			if err != nil {
+				t.Fatalf("Reconcile: %v", err)
+			}
+
+			want := tt.want
+			if want == "" {
+				want = tt.gomod
+			}
+			if got := projectFile(project, "go.mod"); got != want {
+				t.Errorf("go.mod = %q, want %q", got, want)
+			}
+			if got := changeList(changes); !slices.Equal(got, tt.wantChanges) {
+				t.Errorf("changes = %q, want %q", got, tt.wantChanges)
+			}
+		})
+	}
+}
+
+func TestReconcilePython(t *testing.T) {
+	tests := []struct {
+		name         string
+		requirements string // "" for no requirements.txt
+		files        map[string]string
+		removeUnused bool
+		allow        map[string]string
+		want         string // Expected requirements.txt, "" for none
+		wantChanges  []string
+	}{
+		{
+			name:        "adds well-known packages and their distribution names",
+			files:       map[string]string{"app.py": "import requests\nimport yaml\nfrom PIL import Image\n"},
+			want:        "Pillow\nrequests\nPyYAML\n",
+			wantChanges: []string{"added Pillow", "added requests", "added PyYAML"},
+		},
+		{
+			name:         "flags unknown imports without an allowlist instead of adding them",
+			requirements: "flask\n",
+			files:        map[string]string{"app.py": "import flask\nimport flask_magic_auth\n"},
+			want:         "flask\n",
+			wantChanges:  []string{"flagged flask_magic_auth"},
+		},
+		{
+			name:         "allowlist specifiers are used and other packages flagged",
+			requirements: "# web\n",
+			files:        map[string]string{"app.py": "from flask import Flask\nimport requests\n"},
+			allow:        map[string]string{"Flask": ">=3.0"},
+			want:         "# web\nflask>=3.0\n",
+			wantChanges:  []string{"added flask >=3.0", "flagged requests"},
+		},
+		{
+			name:         "names compare normalized",
+			requirements: "Flask_Cors==4.0\n",
+			files:        map[string]string{"app.py": "import flask_cors\n"},
+			want:         "Flask_Cors==4.0\n",
+		},
+		{
+			name:         "removes unused packages but keeps tools and comments",
+			requirements: "# runtime\nrequests==2.31\ndjango>=4\n-r base.txt\npytest\n",
+			files:        map[string]string{"app.py": "import requests\n"},
+			removeUnused: true,
+			want:         "# runtime\nrequests==2.31\n-r base.txt\npytest\n",
+			wantChanges:  []string{"removed django"},
+		},
+		{
+			name:  "ignores the standard library, relative and local imports",
+			files: map[string]string{"app.py": "import os, json\nfrom . import views\nfrom helpers import slugify\n", "helpers.py": "", "src/models/__init__.py": "import models.user\n"},
+		},
+		{
+			name:  "leaves pyproject projects alone",
+			files: map[string]string{"app.py": "import requests\n", "pyproject.toml": "[project]\n"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			project := &llm.GeneratedProject{}
+			if tt.requirements != "" {
+				project.Files = append(project.Files, llm.FileInfo{Path: "requirements.txt", Content: tt.requirements})
+			}
+			for p, content := range tt.files {
+				project.Files = append(project.Files, llm.FileInfo{Path: p, Content: content})
+			}
+
+			// Loaded from a file, which normalizes distribution names
+			var allow *Allowlist
+			if tt.allow != nil {
+				data, _ := json.Marshal(map[string]any{"python": tt.allow})
+				path := filepath.Join(t.TempDir(), "allow.json")
+				if err := os.WriteFile(path, data, 0600); err != nil {
+					t.Fatal(err)
+				}
+				var err error
+				if allow, err = LoadAllowlist(path); err != nil {
+					t.Fatal(err)
+				}
+			}
+			r := NewReconciler(Config{GoModCache: t.TempDir(), RemoveUnused: tt.removeUnused, Allowlist: allow})
+
+			changes, err := r.Reconcile(project)
+			if err != nil {
+				t.Fatalf("Reconcile: %v", err)
+			}
+			if got := projectFile(project, "requirements.txt"); got != tt.want {
+				t.Errorf("requirements.txt =\n%s\nwant\n%s", got, tt.want)
+			}
+			if got := changeList(changes); !slices.Equal(got, tt.wantChanges) {
+				t.Errorf("changes = %q, want %q", got, tt.wantChanges)
+			}
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package deps
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cosmos-link/gen-code/internal/llm"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+// requireFor returns the requirement whose module provides imp
+func requireFor(f *modfile.File, imp string) *modfile.Require {
+	var best *modfile.Require
+	for _, req := range f.Require {
+		if (imp == req.Mod.Path || strings.HasPrefix(imp, req.Mod.Path+"/")) && (best == nil || len(req.Mod.Path) > len(best.Mod.Path)) {
+			best = req
+		}
+	}
+	return best
+}
+
+// reconcileGo adds requirements for imported modules missing from go.mod,
+// removes unused direct requirements and flags unknown modules. go.mod is
+// rewritten with golang.org/x/mod, which keeps comments and puts direct
+// and indirect requirements in separate blocks, as go mod tidy does.
+func (r *Reconciler) reconcileGo(project *llm.GeneratedProject) ([]Change, error) {
+	idx := findFile(project, "go.mod")
+	if idx < 0 {
+		return nil, nil
+	}
+	// A go.mod that does not parse is left for validation to report
+	f, err := modfile.Parse("go.mod", []byte(project.Files[idx].Content), nil)
+	if err != nil || f.Module == nil {
+		return nil, nil
+	}
+	modulePath := f.Module.Mod.Path
+
+	var (
+		changes  []Change
+		requires []*modfile.Require
+	)
+	used := make(map[string]bool)
+	seen := make(map[string]bool)
+	edited := false
+
+	for _, imp := range goImports(project) {
+		if isGoStdlib(imp) || imp == modulePath || strings.HasPrefix(imp, modulePath+"/") {
+			continue
+		}
+		if req := requireFor(f, imp); req != nil {
+			used[req.Mod.Path] = true
+			continue
+		}
+
+		candidate, version, found := r.resolveGoModule(imp)
+		if seen[candidate] {
+			continue
+		}
+		seen[candidate] = true
+
+		if !found || version == "" {
+			changes = append(changes, Change{Manifest: "go.mod", Package: candidate, Action: ActionFlagged,
+				Reason: "imported but not found in the allowlist or module cache; the module may not exist"})
+			continue
+		}
+
+		requires = append(requires, &modfile.Require{Mod: module.Version{Path: candidate, Version: version}})
+		used[candidate] = true
+		edited = true
+		changes = append(changes, Change{Manifest: "go.mod", Package: candidate, Version: version, Action: ActionAdded,
+			Reason: "imported but missing from go.mod"})
+	}
+
+	for _, req := range f.Require {
+		if !req.Indirect && !used[req.Mod.Path] && r.cfg.RemoveUnused {
+			edited = true
+			changes = append(changes, Change{Manifest: "go.mod", Package: req.Mod.Path, Version: req.Mod.Version, Action: ActionRemoved,
+				Reason: "required but not imported"})
+			continue
+		}
+		if !req.Indirect && r.goVerifiable() && !r.goModuleKnown(req.Mod.Path) {
+			changes = append(changes, Change{Manifest: "go.mod", Package: req.Mod.Path, Version: req.Mod.Version, Action: ActionFlagged,
+				Reason: "not found in the allowlist or module cache; the module may not exist"})
+		}
+		requires = append(requires, req)
+	}
+
+	if !edited {
+		return changes, nil
+	}
+
+	f.SetRequireSeparateIndirect(requires)
+	f.SortBlocks()
+	f.Cleanup()
+	data, err := f.Format()
+	if err != nil {
+		return nil, err
+	}
+	project.Files[idx].Content = string(data)
+	return changes, nil
+}
+
+// goImports returns the sorted import paths of the project's Go files.
+// Files that do not parse are skipped; validation reports them.
+func goImports(project *llm.GeneratedProject) []string {
+	fset := token.NewFileSet()
+	found := make(map[string]bool)
+
+	for _, file := range project.Files {
+		if path.Ext(file.Path) != ".go" || strings.Contains("/"+file.Path, "/testdata/") || strings.Contains("/"+file.Path, "/vendor/") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, file.Path, file.Content, parser.ImportsOnly)
+		if err != nil {
+			continue
+		}
+		for _, spec := range f.Imports {
+			if imp, err := strconv.Unquote(spec.Path.Value); err == nil {
+				found[imp] = true
+			}
+		}
+	}
+
+	imports := make([]string, 0, len(found))
+	for imp := range found {
+		imports = append(imports, imp)
+	}
+	sort.Strings(imports)
+	return imports
+}
+
+// isGoStdlib reports whether an import path belongs to the standard
+// library, whose first element never contains a dot
+func isGoStdlib(imp string) bool {
+	first, _, _ := strings.Cut(imp, "/")
+	return !strings.Contains(first, ".")
+}
+
+// resolveGoModule finds the module providing imp and a version for it,
+// trying the allowlist, then the module cache, from the longest prefix
+func (r *Reconciler) resolveGoModule(imp string) (string, string, bool) {
+	parts := strings.Split(imp, "/")
+	for n := len(parts); n >= 2; n-- {
+		candidate := strings.Join(parts[:n], "/")
+
+		version, allowed := r.allow.Go[candidate]
+		cached := latestVersion(r.cachedVersions(candidate))
+		if version == "" {
+			version = cached
+		}
+		if allowed || cached != "" {
+			return candidate, version, true
+		}
+	}
+	return guessModulePath(imp), "", false
+}
+
+// goVerifiable reports whether there is anything to check modules against
+func (r *Reconciler) goVerifiable() bool {
+	if len(r.allow.Go) > 0 {
+		return true
+	}
+	_, err := os.Stat(filepath.Join(r.cfg.GoModCache, "cache", "download"))
+	return err == nil
+}
+
+// goModuleKnown reports whether a module is allowlisted or cached
+func (r *Reconciler) goModuleKnown(modulePath string) bool {
+	if _, ok := r.allow.Go[modulePath]; ok {
+		return true
+	}
+	return len(r.cachedVersions(modulePath)) > 0
+}
+
+// cachedVersions lists the versions of a module in the module cache
+func (r *Reconciler) cachedVersions(modulePath string) []string {
+	escaped, err := module.EscapePath(modulePath)
+	if err != nil {
+		return nil
+	}
+
+	var versions []string
+	infos, _ := filepath.Glob(filepath.Join(r.cfg.GoModCache, "cache", "download", filepath.FromSlash(escaped), "@v", "*.info"))
+	for _, info := range infos {
+		if version, err := module.UnescapeVersion(strings.TrimSuffix(filepath.Base(info), ".info")); err == nil {
+			versions = append(versions, version)
+		}
+	}
+	dirs, _ := filepath.Glob(filepath.Join(r.cfg.GoModCache, filepath.FromSlash(escaped)+"@v*"))
+	for _, dir := range dirs {
+		_, escapedVersion, _ := strings.Cut(filepath.Base(dir), "@")
+		if version, err := module.UnescapeVersion(escapedVersion); err == nil {
+			versions = append(versions, version)
+		}
+	}
+	return versions
+}
+
+// majorVersion matches a /vN major version suffix element
+var majorVersion = regexp.MustCompile(`^v[2-9][0-9]*$`)
+
+// guessModulePath estimates the module path of an import for reporting
+func guessModulePath(imp string) string {
+	parts := strings.Split(imp, "/")
+	n := 2
+	switch parts[0] {
+	case "github.com", "gitlab.com", "bitbucket.org", "golang.org":
+		n = 3
+	}
+	if n < len(parts) && majorVersion.MatchString(parts[n]) {
+		n++
+	}
+	if n > len(parts) {
+		n = len(parts)
+	}
+	return strings.Join(parts[:n], "/")
+}
+
+// latestVersion returns the highest valid semantic version, preferring
+// releases over pre-releases as go get does
+func latestVersion(versions []string) string {
+	best := ""
+	for _, v := range versions {
+		if !semver.IsValid(v) {
+			continue
+		}
+		if best == "" || releaseRank(v) > releaseRank(best) || (releaseRank(v) == releaseRank(best) && semver.Compare(v, best) > 0) {
+			best = v
+		}
+	}
+	return best
+}
+
+// releaseRank ranks releases above pre-releases
+func releaseRank(v string) int {
+	if semver.Prerelease(v) == "" {
+		return 1
+	}
+	return 0
+}
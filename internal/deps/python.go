@@ -0,0 +1,288 @@
+package deps
+
+import (
+	"bufio"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/cosmos-link/gen-code/internal/llm"
+)
+
+// pythonStdlib lists the top-level modules of the Python 3 standard library
+var pythonStdlib = toSet(
+	"__future__", "_thread", "abc", "aifc", "argparse", "array", "ast", "asynchat", "asyncio", "asyncore",
+	"atexit", "audioop", "base64", "bdb", "binascii", "bisect", "builtins", "bz2", "calendar", "cgi",
+	"cgitb", "chunk", "cmath", "cmd", "code", "codecs", "codeop", "collections", "colorsys", "compileall",
+	"concurrent", "configparser", "contextlib", "contextvars", "copy", "copyreg", "cProfile", "crypt", "csv",
+	"ctypes", "curses", "dataclasses", "datetime", "dbm", "decimal", "difflib", "dis", "doctest", "email",
+	"encodings", "ensurepip", "enum", "errno", "faulthandler", "fcntl", "filecmp", "fileinput", "fnmatch",
+	"fractions", "ftplib", "functools", "gc", "getopt", "getpass", "gettext", "glob", "graphlib", "grp",
+	"gzip", "hashlib", "heapq", "hmac", "html", "http", "imaplib", "imghdr", "imp", "importlib", "inspect",
+	"io", "ipaddress", "itertools", "json", "keyword", "lib2to3", "linecache", "locale", "logging", "lzma",
+	"mailbox", "mailcap", "marshal", "math", "mimetypes", "mmap", "modulefinder", "msvcrt", "multiprocessing",
+	"netrc", "nntplib", "numbers", "operator", "optparse", "os", "pathlib", "pdb", "pickle", "pickletools",
+	"pipes", "pkgutil", "platform", "plistlib", "poplib", "posix", "pprint", "profile", "pstats", "pty",
+	"pwd", "py_compile", "pyclbr", "pydoc", "queue", "quopri", "random", "re", "readline", "reprlib",
+	"resource", "rlcompleter", "runpy", "sched", "secrets", "select", "selectors", "shelve", "shlex",
+	"shutil", "signal", "site", "smtplib", "sndhdr", "socket", "socketserver", "sqlite3", "ssl", "stat",
+	"statistics", "string", "stringprep", "struct", "subprocess", "sunau", "symtable", "sys", "sysconfig",
+	"syslog", "tabnanny", "tarfile", "telnetlib", "tempfile", "termios", "textwrap", "threading", "time",
+	"timeit", "tkinter", "token", "tokenize", "tomllib", "trace", "traceback", "tracemalloc", "tty",
+	"turtle", "types", "typing", "unicodedata", "unittest", "urllib", "uu", "uuid", "venv", "warnings",
+	"wave", "weakref", "webbrowser", "winreg", "winsound", "wsgiref", "xdrlib", "xml", "xmlrpc", "zipapp",
+	"zipfile", "zipimport", "zlib", "zoneinfo",
+)
+
+// pythonDistributions maps import names to the PyPI distributions that
+// provide them, where the two differ
+var pythonDistributions = map[string]string{
+	"attr":          "attrs",
+	"bs4":           "beautifulsoup4",
+	"Crypto":        "pycryptodome",
+	"cv2":           "opencv-python",
+	"dateutil":      "python-dateutil",
+	"docx":          "python-docx",
+	"dotenv":        "python-dotenv",
+	"jose":          "python-jose",
+	"jwt":           "PyJWT",
+	"magic":         "python-magic",
+	"multipart":     "python-multipart",
+	"OpenSSL":       "pyOpenSSL",
+	"PIL":           "Pillow",
+	"psycopg2":      "psycopg2-binary",
+	"serial":        "pyserial",
+	"sklearn":       "scikit-learn",
+	"yaml":          "PyYAML",
+	"google":        "protobuf",
+	"pkg_resources": "setuptools",
+}
+
+// pythonWellKnown are popular PyPI distributions added without an
+// allowlist; other imports are flagged for review instead of added, as a
+// hallucinated import would otherwise become a dependency anyone could
+// register on PyPI
+var pythonWellKnown = toSet(
+	"aiohttp", "anthropic", "boto3", "celery", "click", "django", "djangorestframework", "fastapi",
+	"flask", "flask-cors", "flask-sqlalchemy", "gunicorn", "httpx", "jinja2", "marshmallow",
+	"matplotlib", "numpy", "openai", "pandas", "pydantic", "pydantic-settings", "pymongo", "pytest",
+	"redis", "requests", "rich", "scipy", "sqlalchemy", "starlette", "tabulate", "toml", "tqdm",
+	"typer", "uvicorn", "websockets", "werkzeug",
+)
+
+// pythonTools are distributions used as commands or plugins rather than
+// imported, so they are never removed as unused
+var pythonTools = toSet(
+	"black", "coverage", "flake8", "gunicorn", "hypercorn", "isort", "mypy", "pip", "pylint",
+	"pytest", "pytest-asyncio", "pytest-cov", "pytest-mock", "ruff", "setuptools", "uvicorn", "wheel",
+	"psycopg2-binary", "psycopg", "pymysql", "python-multipart", "alembic", "celery",
+)
+
+var (
+	// pythonImport matches "import a, b.c as d"
+	pythonImport = regexp.MustCompile(`^\s*import\s+([\w\s.,]+?)\s*(?:#.*)?$`)
+	// pythonFromImport matches "from a.b import c"
+	pythonFromImport = regexp.MustCompile(`^\s*from\s+([\w.]+)\s+import\b`)
+	// requirementName matches the distribution name at the start of a
+	// requirements.txt line
+	requirementName = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*)`)
+	// distSeparators are the characters PEP 503 folds together
+	distSeparators = regexp.MustCompile(`[-_.]+`)
+)
+
+// reconcilePython adds requirements for third-party imports missing from
+// requirements.txt, removes unused ones and flags unknown distributions.
+// Projects managed by pyproject.toml or setup.py are only checked.
+func (r *Reconciler) reconcilePython(project *llm.GeneratedProject) ([]Change, error) {
+	imports := pythonImports(project)
+
+	idx := findFile(project, "requirements.txt")
+	if idx < 0 && (len(imports) == 0 || findFile(project, "pyproject.toml") >= 0 || findFile(project, "setup.py") >= 0) {
+		return nil, nil
+	}
+
+	var lines []string
+	if idx >= 0 {
+		lines = strings.Split(strings.TrimRight(project.Files[idx].Content, "\n"), "\n")
+	}
+
+	// Distributions already listed, keyed by normalized name
+	listed := make(map[string]string)
+	for _, line := range lines {
+		if name := requirementLineName(line); name != "" {
+			listed[normalizeDist(name)] = name
+		}
+	}
+
+	var changes []Change
+	used := make(map[string]bool)
+	edited := false
+
+	for _, imp := range imports {
+		dist := imp
+		if mapped, ok := pythonDistributions[imp]; ok {
+			dist = mapped
+		}
+		key := normalizeDist(dist)
+		used[key] = true
+		if _, ok := listed[key]; ok {
+			continue
+		}
+
+		if !r.pythonKnown(dist) {
+			changes = append(changes, Change{Manifest: "requirements.txt", Package: dist, Action: ActionFlagged,
+				Reason: "imported as " + imp + " but not a known package; the package may not exist"})
+			continue
+		}
+
+		spec := r.allow.Python[key]
+		lines = append(lines, dist+spec)
+		listed[key] = dist
+		edited = true
+		changes = append(changes, Change{Manifest: "requirements.txt", Package: dist, Version: spec, Action: ActionAdded,
+			Reason: "imported as " + imp + " but missing from requirements.txt"})
+	}
+
+	var kept []string
+	for _, line := range lines {
+		name := requirementLineName(line)
+		if name == "" {
+			kept = append(kept, line)
+			continue
+		}
+
+		key := normalizeDist(name)
+		if !used[key] && !pythonTools[key] && r.cfg.RemoveUnused {
+			edited = true
+			changes = append(changes, Change{Manifest: "requirements.txt", Package: name, Action: ActionRemoved,
+				Reason: "listed but not imported"})
+			continue
+		}
+		if used[key] && len(r.allow.Python) > 0 && !r.pythonKnown(name) {
+			changes = append(changes, Change{Manifest: "requirements.txt", Package: name, Action: ActionFlagged,
+				Reason: "not in the allowlist; the package may not exist"})
+		}
+		kept = append(kept, line)
+	}
+
+	if !edited {
+		return changes, nil
+	}
+
+	content := strings.Join(kept, "\n") + "\n"
+	if idx >= 0 {
+		project.Files[idx].Content = content
+	} else {
+		project.Files = append(project.Files, llm.FileInfo{Path: "requirements.txt", Content: content, Type: "text", Category: llm.CategoryScaffolding})
+	}
+	return changes, nil
+}
+
+// pythonKnown reports whether a distribution may be added: it is in the
+// allowlist, or, without one, a well-known distribution. Distributions of
+// well-known import names are always accepted.
+func (r *Reconciler) pythonKnown(dist string) bool {
+	key := normalizeDist(dist)
+	if _, ok := r.allow.Python[key]; ok {
+		return true
+	}
+	if len(r.allow.Python) == 0 && pythonWellKnown[key] {
+		return true
+	}
+	for _, mapped := range pythonDistributions {
+		if normalizeDist(mapped) == key {
+			return true
+		}
+	}
+	return false
+}
+
+// pythonImports returns the sorted top-level third-party modules imported
+// by the project's Python files
+func pythonImports(project *llm.GeneratedProject) []string {
+	local := pythonLocalModules(project)
+	found := make(map[string]bool)
+
+	for _, file := range project.Files {
+		if path.Ext(file.Path) != ".py" {
+			continue
+		}
+
+		scanner := bufio.NewScanner(strings.NewReader(file.Content))
+		scanner.Buffer(make([]byte, 64*1024), 1<<20)
+		for scanner.Scan() {
+			line := scanner.Text()
+
+			var modules []string
+			if m := pythonFromImport.FindStringSubmatch(line); m != nil {
+				modules = []string{m[1]}
+			} else if m := pythonImport.FindStringSubmatch(line); m != nil {
+				for _, part := range strings.Split(m[1], ",") {
+					if fields := strings.Fields(part); len(fields) > 0 {
+						modules = append(modules, fields[0])
+					}
+				}
+			}
+
+			for _, module := range modules {
+				if strings.HasPrefix(module, ".") {
+					continue // Relative import
+				}
+				top, _, _ := strings.Cut(module, ".")
+				if top != "" && !pythonStdlib[top] && !local[top] {
+					found[top] = true
+				}
+			}
+		}
+	}
+
+	imports := make([]string, 0, len(found))
+	for imp := range found {
+		imports = append(imports, imp)
+	}
+	sort.Strings(imports)
+	return imports
+}
+
+// pythonLocalModules returns the top-level module names the project itself
+// provides, at the root or under src/
+func pythonLocalModules(project *llm.GeneratedProject) map[string]bool {
+	local := make(map[string]bool)
+	for _, file := range project.Files {
+		if path.Ext(file.Path) != ".py" {
+			continue
+		}
+		p := strings.TrimPrefix(file.Path, "src/")
+		top, _, nested := strings.Cut(p, "/")
+		if !nested {
+			top = strings.TrimSuffix(top, ".py")
+		}
+		local[top] = true
+	}
+	return local
+}
+
+// requirementLineName returns the distribution named by a requirements.txt
+// line, or "" for comments, options and blank lines
+func requirementLineName(line string) string {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "-") {
+		return ""
+	}
+	return requirementName.FindString(line)
+}
+
+// normalizeDist normalizes a distribution name per PEP 503
+func normalizeDist(name string) string {
+	return distSeparators.ReplaceAllString(strings.ToLower(name), "-")
+}
+
+// toSet builds a set from its arguments
+func toSet(values ...string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
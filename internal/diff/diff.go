@@ -0,0 +1,307 @@
+// Package diff computes line-based unified diffs between versions of a
+// project's files
+package diff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DefaultContext is the number of unchanged lines shown around each change
+const DefaultContext = 3
+
+// maxEdits bounds the work spent on a single file. Files that differ by
+// more lines are reported as entirely replaced.
+const maxEdits = 1000
+
+// File change actions
+const (
+	Added    = "added"
+	Modified = "modified"
+	Deleted  = "deleted"
+)
+
+// File is the diff of one file
+type File struct {
+	Path    string
+	Action  string // Added, Modified or Deleted
+	Added   int    // Lines added
+	Removed int    // Lines removed
+	Patch   string // Unified diff in git format
+}
+
+// Files diffs two sets of file contents keyed by path and returns the
+// changed files sorted by path
+func Files(before, after map[string]string) []File {
+	var files []File
+	for path, newText := range after {
+		oldText, ok := before[path]
+		switch {
+		case !ok:
+			files = append(files, Unified(path, "", newText, Added))
+		case oldText != newText:
+			files = append(files, Unified(path, oldText, newText, Modified))
+		}
+	}
+	for path, oldText := range before {
+		if _, ok := after[path]; !ok {
+			files = append(files, Unified(path, oldText, "", Deleted))
+		}
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files
+}
+
+// Unified diffs two versions of a file with DefaultContext lines of context
+func Unified(path, oldText, newText, action string) File {
+	edits := diffLines(splitLines(oldText), splitLines(newText))
+
+	file := File{Path: path, Action: action}
+	for _, e := range edits {
+		switch e.kind {
+		case insert:
+			file.Added++
+		case remove:
+			file.Removed++
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "diff --git a/%s b/%s\n", path, path)
+	oldName, newName := "a/"+path, "b/"+path
+	switch action {
+	case Added:
+		b.WriteString("new file mode 100644\n")
+		oldName = "/dev/null"
+	case Deleted:
+		b.WriteString("deleted file mode 100644\n")
+		newName = "/dev/null"
+	}
+	if file.Added+file.Removed > 0 {
+		fmt.Fprintf(&b, "--- %s\n+++ %s\n", oldName, newName)
+		writeHunks(&b, edits, DefaultContext)
+	}
+	file.Patch = b.String()
+
+	return file
+}
+
+// Join concatenates the patches of a set of file diffs
+func Join(files []File) string {
+	var b strings.Builder
+	for _, file := range files {
+		b.WriteString(file.Patch)
+	}
+	return b.String()
+}
+
+// editKind is the kind of a line edit
+type editKind int
+
+const (
+	equal editKind = iota
+	remove
+	insert
+)
+
+// edit is one line of an edit script
+type edit struct {
+	kind editKind
+	line string
+}
+
+// splitLines splits text into lines, each keeping its trailing newline
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines returns a shortest edit script turning a into b, using the
+// Myers algorithm on what remains after trimming common prefix and suffix
+func diffLines(a, b []string) []edit {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var edits []edit
+	for _, line := range a[:prefix] {
+		edits = append(edits, edit{equal, line})
+	}
+	edits = append(edits, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		edits = append(edits, edit{equal, line})
+	}
+	return edits
+}
+
+// myers computes a shortest edit script between a and b, falling back to
+// replacing all of a with all of b beyond maxEdits
+func myers(a, b []string) []edit {
+	n, m := len(a), len(b)
+	limit := n + m
+	if limit > maxEdits {
+		limit = maxEdits
+	}
+
+	offset := limit + 1
+	v := make([]int, 2*limit+3)
+	var trace [][]int
+
+	for d := 0; d <= limit; d++ {
+		// Snapshot the furthest reaching paths of round d-1 for k in [-d, d]
+		snapshot := make([]int, 2*d+1)
+		copy(snapshot, v[offset-d:offset+d+1])
+		trace = append(trace, snapshot)
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				return backtrack(trace, a, b)
+			}
+		}
+	}
+
+	edits := make([]edit, 0, n+m)
+	for _, line := range a {
+		edits = append(edits, edit{remove, line})
+	}
+	for _, line := range b {
+		edits = append(edits, edit{insert, line})
+	}
+	return edits
+}
+
+// backtrack walks the recorded rounds from the end of both inputs back to
+// the start, emitting the edit script in reverse
+func backtrack(trace [][]int, a, b []string) []edit {
+	var edits []edit
+	x, y := len(a), len(b)
+
+	for d := len(trace) - 1; d > 0; d-- {
+		v := trace[d]
+		k := x - y
+
+		prevK := k - 1
+		if k == -d || (k != d && v[k-1+d] < v[k+1+d]) {
+			prevK = k + 1
+		}
+		prevX := v[prevK+d]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			edits = append(edits, edit{equal, a[x-1]})
+			x--
+			y--
+		}
+		if x == prevX {
+			edits = append(edits, edit{insert, b[y-1]})
+			y--
+		} else {
+			edits = append(edits, edit{remove, a[x-1]})
+			x--
+		}
+	}
+	for x > 0 && y > 0 {
+		edits = append(edits, edit{equal, a[x-1]})
+		x--
+		y--
+	}
+
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits
+}
+
+// writeHunks formats an edit script as unified diff hunks, merging changes
+// separated by at most twice the context
+func writeHunks(b *strings.Builder, edits []edit, context int) {
+	// Line numbers before each edit in the old and new file
+	oldPos := make([]int, len(edits)+1)
+	newPos := make([]int, len(edits)+1)
+	for i, e := range edits {
+		oldPos[i+1], newPos[i+1] = oldPos[i], newPos[i]
+		if e.kind != insert {
+			oldPos[i+1]++
+		}
+		if e.kind != remove {
+			newPos[i+1]++
+		}
+	}
+
+	for i := 0; i < len(edits); {
+		for i < len(edits) && edits[i].kind == equal {
+			i++
+		}
+		if i == len(edits) {
+			break
+		}
+
+		last := i
+		for j := i; j < len(edits); j++ {
+			if edits[j].kind != equal {
+				last = j
+			} else if j-last > 2*context {
+				break
+			}
+		}
+		start := max(i-context, 0)
+		end := min(last+context+1, len(edits))
+
+		fmt.Fprintf(b, "@@ -%s +%s @@\n",
+			hunkRange(oldPos[start], oldPos[end]-oldPos[start]),
+			hunkRange(newPos[start], newPos[end]-newPos[start]))
+		for _, e := range edits[start:end] {
+			prefix := " "
+			switch e.kind {
+			case remove:
+				prefix = "-"
+			case insert:
+				prefix = "+"
+			}
+			b.WriteString(prefix + e.line)
+			if !strings.HasSuffix(e.line, "\n") {
+				b.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+
+		i = end
+	}
+}
+
+// hunkRange formats the start,count part of a hunk header. Empty ranges
+// name the line before them, as diff does.
+func hunkRange(before, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", before)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", before+1)
+	}
+	return fmt.Sprintf("%d,%d", before+1, count)
+}
@@ -0,0 +1,89 @@
+package diff
+
+import "testing"
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name        string
+		oldText     string
+		newText     string
+		action      string
+		wantAdded   int
+		wantRemoved int
+		wantPatch   string
+	}{
+		{
+			"added", "", "package main\n\nfunc main() {}\n", Added, 3, 0,
+			"diff --git a/f.go b/f.go\nnew file mode 100644\n--- /dev/null\n+++ b/f.go\n@@ -0,0 +1,3 @@\n+package main\n+\n+func main() {}\n",
+		},
+		{
+			"deleted", "a\nb\n", "", Deleted, 0, 2,
+			"diff --git a/f.go b/f.go\ndeleted file mode 100644\n--- a/f.go\n+++ /dev/null\n@@ -1,2 +0,0 @@\n-a\n-b\n",
+		},
+		{
+			"modified", "a\nb\nc\n", "a\nB\nc\n", Modified, 1, 1,
+			"diff --git a/f.go b/f.go\n--- a/f.go\n+++ b/f.go\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+		},
+		{
+			"modified line outside the context", "1\n2\n3\n4\n5\n6\n7\n8\n", "1\n2\n3\n4\n5\n6\n7\nx\n", Modified, 1, 1,
+			"diff --git a/f.go b/f.go\n--- a/f.go\n+++ b/f.go\n@@ -5,4 +5,4 @@\n 5\n 6\n 7\n-8\n+x\n",
+		},
+		{
+			"distant changes make two hunks", "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n", "x\n2\n3\n4\n5\n6\n7\n8\n9\ny\n", Modified, 2, 2,
+			"diff --git a/f.go b/f.go\n--- a/f.go\n+++ b/f.go\n@@ -1,4 +1,4 @@\n-1\n+x\n 2\n 3\n 4\n@@ -7,4 +7,4 @@\n 7\n 8\n 9\n-10\n+y\n",
+		},
+		{
+			"newline added at end of file", "a\nb", "a\nb\n", Modified, 1, 1,
+			"diff --git a/f.go b/f.go\n--- a/f.go\n+++ b/f.go\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n",
+		},
+		{
+			"no trailing newline on either side", "a\nb", "a\nc", Modified, 1, 1,
+			"diff --git a/f.go b/f.go\n--- a/f.go\n+++ b/f.go\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+c\n\\ No newline at end of file\n",
+		},
+		{
+			"added without trailing newline", "", "a", Added, 1, 0,
+			"diff --git a/f.go b/f.go\nnew file mode 100644\n--- /dev/null\n+++ b/f.go\n@@ -0,0 +1 @@\n+a\n\\ No newline at end of file\n",
+		},
+		{
+			"empty file added", "", "", Added, 0, 0,
+			"diff --git a/f.go b/f.go\nnew file mode 100644\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := Unified("f.go", tt.oldText, tt.newText, tt.action)
+			if file.Path != "f.go" || file.Action != tt.action {
+				t.Errorf("file = %s %s, want f.go %s", file.Path, file.Action, tt.action)
+			}
+			if file.Added != tt.wantAdded || file.Removed != tt.wantRemoved {
+				t.Errorf("lines = +%d -%d, want +%d -%d", file.Added, file.Removed, tt.wantAdded, tt.wantRemoved)
+			}
+			if file.Patch != tt.wantPatch {
+				t.Errorf("patch =\n%s\nwant\n%s", file.Patch, tt.wantPatch)
+			}
+		})
+	}
+}
+
+func TestFiles(t *testing.T) {
+	before := map[string]string{"a.go": "a\n", "b.go": "b\n", "c.go": "c\n"}
+	after := map[string]string{"a.go": "a\n", "b.go": "B\n", "d.go": "d\n"}
+
+	files := Files(before, after)
+	want := []struct{ path, action string }{
+		{"b.go", Modified},
+		{"c.go", Deleted},
+		{"d.go", Added},
+	}
+	if len(files) != len(want) {
+		t.Fatalf("files = %+v, want %d changes", files, len(want))
+	}
+	for i, w := range want {
+		if files[i].Path != w.path || files[i].Action != w.action {
+			t.Errorf("file %d = %s %s, want %s %s", i, files[i].Path, files[i].Action, w.path, w.action)
+		}
+	}
+	if got := Join(files); got != files[0].Patch+files[1].Patch+files[2].Patch {
+		t.Errorf("Join = %q", got)
+	}
+}
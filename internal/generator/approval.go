@@ -0,0 +1,69 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/cosmos-link/gen-code/internal/github"
+	"github.com/cosmos-link/gen-code/internal/llm"
+	"github.com/cosmos-link/gen-code/internal/task"
+)
+
+// awaitApproval pauses the task until a reviewer approves or rejects it.
+// Files edited by the reviewer pass the file policy and secret scan and
+// replace the generated ones; they are not revalidated. It returns false
+// when the task was rejected and must not be pushed.
+func (g *Generator) awaitApproval(ctx context.Context, t *task.Task, project *llm.GeneratedProject, dir string) (bool, error) {
+	approval, err := g.taskManager.AwaitApproval(ctx, t.ID, g.approvalTimeout)
+	if err != nil {
+		return false, err
+	}
+
+	if !approval.Approved {
+		message := "Rejected by reviewer"
+		if approval.Reason != "" {
+			message = fmt.Sprintf("Rejected: %s", approval.Reason)
+		}
+		if err := g.taskManager.UpdateTask(t.ID, task.StatusRejected, message); err != nil {
+			return false, err
+		}
+		os.RemoveAll(dir)
+		return false, nil
+	}
+
+	if len(approval.Files) == 0 {
+		return true, nil
+	}
+
+	files, rejections := sanitizeFiles(approval.Files, g.filePolicy)
+	if len(rejections) > 0 {
+		g.taskManager.AddTaskRejections(t.ID, rejections)
+	}
+
+	if g.secretScanner != nil {
+		edited := &llm.GeneratedProject{Files: files}
+		findings, _, err := g.scanSecrets(t, edited)
+		if len(findings) > 0 {
+			g.taskManager.AddTaskSecretFindings(t.ID, findings)
+		}
+		if err != nil {
+			return false, fmt.Errorf("reviewer edits: %w", err)
+		}
+		files = edited.Files
+	}
+
+	changed := mergeFiles(project, files)
+	if err := github.WriteFilesToDirectory(dir, filesByPath(project.Files, changed)); err != nil {
+		return false, fmt.Errorf("failed to write edited files: %w", err)
+	}
+	g.taskManager.SetTaskProject(t.ID, project)
+
+	g.taskManager.AddTaskEvent(t.ID, task.Event{
+		Type:    "approval_edits",
+		Message: fmt.Sprintf("Approved with %d edited file(s)", len(changed)),
+		Files:   changed,
+	})
+
+	return true, nil
+}
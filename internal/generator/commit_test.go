@@ -0,0 +1,58 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/cosmos-link/gen-code/internal/github"
+	"github.com/cosmos-link/gen-code/internal/task"
+)
+
+// fakeSigner stands in for a configured signing key
+type fakeSigner struct{}
+
+func (fakeSigner) Sign(message io.Reader) ([]byte, error) {
+	var buf bytes.Buffer
+	_, err := io.Copy(&buf, message)
+	return buf.Bytes(), err
+}
+
+func TestCommitOptionsFor(t *testing.T) {
+	server := github.Identity{Name: "Gen Code", Email: "bot@example.com"}
+	alice := &task.CommitIdentity{Name: "Alice", Email: "alice@example.com"}
+	bob := task.CommitIdentity{Name: "Bob", Email: "bob@example.com"}
+
+	tests := []struct {
+		name          string
+		signed        bool
+		task          task.Task
+		wantAuthor    github.Identity
+		wantCommitter github.Identity
+		wantCoAuthors int
+	}{
+		{"defaults", false, task.Task{}, server, server, 0},
+		{"overrides", false, task.Task{CommitAuthor: alice, CommitCommitter: alice, CoAuthors: []task.CommitIdentity{bob}}, github.Identity(*alice), github.Identity(*alice), 1},
+		{"signed keeps the committer", true, task.Task{CommitAuthor: alice, CommitCommitter: alice}, github.Identity(*alice), server, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &Generator{commit: github.CommitOptions{Author: server, Committer: server}}
+			if tt.signed {
+				g.commit.Signer = fakeSigner{}
+			}
+
+			opts := g.commitOptionsFor(context.Background(), &tt.task, nil)
+			if opts.Author != tt.wantAuthor {
+				t.Errorf("author = %s, want %s", opts.Author, tt.wantAuthor)
+			}
+			if opts.Committer != tt.wantCommitter {
+				t.Errorf("committer = %s, want %s", opts.Committer, tt.wantCommitter)
+			}
+			if len(opts.CoAuthors) != tt.wantCoAuthors {
+				t.Errorf("co-authors = %v, want %d", opts.CoAuthors, tt.wantCoAuthors)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/cosmos-link/gen-code/internal/github"
+	"github.com/cosmos-link/gen-code/internal/llm"
+)
+
+// commitOrder is the order in which file categories are committed
+var commitOrder = []string{
+	llm.CategoryScaffolding,
+	llm.CategoryCore,
+	llm.CategoryTests,
+	llm.CategoryDocs,
+	llm.CategoryCI,
+}
+
+// defaultCommitMessages are used when commit messages are not LLM-generated
+var defaultCommitMessages = map[string]string{
+	llm.CategoryScaffolding: "Add project scaffolding",
+	llm.CategoryCore:        "Implement core functionality",
+	llm.CategoryTests:       "Add tests",
+	llm.CategoryDocs:        "Add documentation",
+	llm.CategoryCI:          "Add CI configuration",
+}
+
+// scaffoldingFiles are build and dependency files recognized by lowercase name
+var scaffoldingFiles = map[string]bool{
+	"go.mod": true, "go.sum": true, "makefile": true, "dockerfile": true,
+	".gitignore": true, ".dockerignore": true, ".editorconfig": true,
+	"package.json": true, "package-lock.json": true, "tsconfig.json": true,
+	"requirements.txt": true, "pyproject.toml": true, "setup.py": true, "setup.cfg": true,
+	"docker-compose.yml": true, "docker-compose.yaml": true,
+}
+
+// commitGroup is the set of files committed together
+type commitGroup struct {
+	Category string
+	Files    []llm.FileInfo
+}
+
+// planCommits groups files into an ordered commit sequence by category.
+// Categories from the LLM manifest win; otherwise the category is inferred
+// from the file path.
+func planCommits(files []llm.FileInfo) []commitGroup {
+	byCategory := make(map[string][]llm.FileInfo)
+	for _, file := range files {
+		category := fileCategory(file)
+		byCategory[category] = append(byCategory[category], file)
+	}
+
+	var groups []commitGroup
+	for _, category := range commitOrder {
+		if len(byCategory[category]) == 0 {
+			continue
+		}
+		groupFiles := byCategory[category]
+		sort.Slice(groupFiles, func(i, j int) bool { return groupFiles[i].Path < groupFiles[j].Path })
+		groups = append(groups, commitGroup{Category: category, Files: groupFiles})
+	}
+	return groups
+}
+
+// fileCategory returns the commit category of a file
+func fileCategory(file llm.FileInfo) string {
+	category := strings.ToLower(strings.TrimSpace(file.Category))
+	if _, ok := defaultCommitMessages[category]; ok {
+		return category
+	}
+	return inferCategory(file.Path)
+}
+
+// inferCategory guesses a file's category from its path
+func inferCategory(filePath string) string {
+	p := strings.ToLower(path.Clean(strings.ReplaceAll(filePath, "\\", "/")))
+	base := path.Base(p)
+	ext := path.Ext(base)
+
+	switch {
+	case strings.HasPrefix(p, ".github/"), strings.HasPrefix(p, ".circleci/"),
+		base == ".gitlab-ci.yml", base == "jenkinsfile", base == ".travis.yml":
+		return llm.CategoryCI
+
+	case strings.HasSuffix(base, "_test.go"), strings.HasPrefix(base, "test_") && ext == ".py",
+		strings.HasSuffix(base, "_test.py"), strings.Contains(base, ".test."), strings.Contains(base, ".spec."),
+		strings.HasPrefix(p, "test/"), strings.HasPrefix(p, "tests/"), strings.Contains(p, "/tests/"):
+		return llm.CategoryTests
+
+	case ext == ".md", ext == ".rst", ext == ".txt" && base != "requirements.txt",
+		strings.HasPrefix(p, "docs/"), strings.HasPrefix(base, "license"):
+		return llm.CategoryDocs
+
+	case scaffoldingFiles[base]:
+		return llm.CategoryScaffolding
+	}
+
+	return llm.CategoryCore
+}
+
+// buildCommits turns the planned groups into commits, optionally asking the
+// LLM to write each message
+func (g *Generator) buildCommits(ctx context.Context, project *llm.GeneratedProject, groups []commitGroup, llmOpts llm.CallOptions) []github.Commit {
+	commits := make([]github.Commit, 0, len(groups))
+	for i, group := range groups {
+		paths := make([]string, len(group.Files))
+		for j, file := range group.Files {
+			paths[j] = file.Path
+		}
+
+		message := defaultCommitMessages[group.Category]
+		if i == 0 && project.Description != "" {
+			message = fmt.Sprintf("%s\n\n%s", message, project.Description)
+		}
+		if g.llmCommitMessages {
+			if generated, err := g.generateCommitMessage(ctx, project, group, llmOpts); err == nil && generated != "" {
+				message = generated
+			}
+		}
+
+		commits = append(commits, github.Commit{Message: message, Paths: paths})
+	}
+	return commits
+}
+
+// generateCommitMessage asks the LLM for a commit message describing a group
+func (g *Generator) generateCommitMessage(ctx context.Context, project *llm.GeneratedProject, group commitGroup, llmOpts llm.CallOptions) (string, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Write a git commit message for the %s files of the project %q (%s).\n", group.Category, project.Name, project.Description)
+	b.WriteString("Use a short imperative subject line under 72 characters, optionally followed by a blank line and a brief body. Files:\n")
+	for _, file := range group.Files {
+		fmt.Fprintf(&b, "- %s\n", file.Path)
+	}
+
+	message, err := g.llmClient.GenerateFile(ctx, b.String(), "COMMIT_EDITMSG", "git commit message", llmOpts)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(strings.Trim(strings.TrimSpace(message), "`")), nil
+}
@@ -0,0 +1,35 @@
+package generator
+
+import (
+	"fmt"
+
+	"github.com/cosmos-link/gen-code/internal/llm"
+	"github.com/cosmos-link/gen-code/internal/task"
+)
+
+// llmOptions returns the options of a task's LLM calls: usage is
+// attributed to the task, the task's requested model is tried first with
+// its model variant, sampling overrides and prompts, responses are served
+// from the cache unless the task bypasses it, and failovers to other
+// providers are logged as task events
+func (g *Generator) llmOptions(t *task.Task) llm.CallOptions {
+	return llm.CallOptions{
+		Params: llm.Params{
+			Provider:    t.Model,
+			Model:       t.ModelVariant,
+			Temperature: t.Temperature,
+			MaxTokens:   t.MaxTokens,
+		},
+		Prompts:           g.promptOptions(t),
+		Usage:             g.usageTracker(t),
+		Cache:             g.cache,
+		RefreshCache:      t.NoCache,
+		PreferredProvider: t.Model,
+		OnFailover: func(provider string, err error) {
+			g.taskManager.AddTaskEvent(t.ID, task.Event{
+				Type:    "failover",
+				Message: fmt.Sprintf("LLM provider %s failed, trying the next one: %v", provider, err),
+			})
+		},
+	}
+}
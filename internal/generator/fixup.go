@@ -0,0 +1,158 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cosmos-link/gen-code/internal/github"
+	"github.com/cosmos-link/gen-code/internal/llm"
+	"github.com/cosmos-link/gen-code/internal/task"
+	"github.com/cosmos-link/gen-code/internal/validate"
+)
+
+// Fix-up policies for projects that still fail validation
+const (
+	FixPolicyBlock = "block" // Fail the task instead of pushing
+	FixPolicyPush  = "push"  // Push anyway and label the task needs-attention
+)
+
+// fixProject feeds validation diagnostics back to the LLM for up to
+// fixRounds rounds, updating project and the files in dir in place.
+// It returns the diagnostics that remain.
+func (g *Generator) fixProject(ctx context.Context, taskID string, project *llm.GeneratedProject, dir string, report validate.Report, llmOpts llm.CallOptions) (validate.Report, error) {
+	for round := 1; round <= g.fixRounds && report.Count() > 0; round++ {
+		g.taskManager.UpdateTask(taskID, task.StatusValidating,
+			fmt.Sprintf("Fixing %d issue(s), round %d/%d...", report.Count(), round, g.fixRounds))
+
+		revised, err := g.llmClient.ReviseProject(ctx, fixInstruction(report), offendingFiles(project, report), llmOpts)
+		if err != nil {
+			g.taskManager.AddTaskEvent(taskID, task.Event{
+				Type:    "fixup_failed",
+				Round:   round,
+				Message: fmt.Sprintf("LLM fix-up failed: %v", err),
+			})
+			break
+		}
+
+		files, rejections := sanitizeFiles(revised.Files, g.filePolicy)
+		if len(rejections) > 0 {
+			g.taskManager.AddTaskRejections(taskID, rejections)
+		}
+
+		changed := mergeFiles(project, files)
+		if err := github.WriteFilesToDirectory(dir, filesByPath(project.Files, changed)); err != nil {
+			return nil, fmt.Errorf("failed to write fixed files: %w", err)
+		}
+
+		next, err := g.validateProject(ctx, taskID, project, dir)
+		if err != nil {
+			return nil, err
+		}
+
+		g.taskManager.AddTaskEvent(taskID, task.Event{
+			Type:        "fixup",
+			Round:       round,
+			Message:     fmt.Sprintf("Fix-up round %d changed %d file(s); %d issue(s) remain", round, len(changed), next.Count()),
+			Files:       changed,
+			Diagnostics: taskDiagnostics(report),
+			Remaining:   taskDiagnostics(next),
+		})
+
+		report = next
+		if len(changed) == 0 {
+			// The model gave up; further rounds would send the same request
+			break
+		}
+	}
+
+	return report, nil
+}
+
+// fixInstruction describes the diagnostics the model must resolve
+func fixInstruction(report validate.Report) string {
+	var b strings.Builder
+	b.WriteString("The project fails validation. Fix every problem below and return the corrected files.\n\n")
+	for _, path := range report.Paths() {
+		for _, d := range report[path] {
+			if d.Line > 0 {
+				fmt.Fprintf(&b, "- [%s] %s:%d:%d: %s\n", d.Tool, path, d.Line, d.Column, d.Message)
+			} else {
+				fmt.Fprintf(&b, "- [%s] %s: %s\n", d.Tool, path, d.Message)
+			}
+		}
+	}
+	return b.String()
+}
+
+// offendingFiles returns the files named in the report. Project-level
+// problems cannot be pinned to a file, so they send the whole project.
+func offendingFiles(project *llm.GeneratedProject, report validate.Report) []llm.FileInfo {
+	if _, ok := report["."]; ok {
+		return project.Files
+	}
+
+	var files []llm.FileInfo
+	for _, file := range project.Files {
+		if _, ok := report[file.Path]; ok {
+			files = append(files, file)
+		}
+	}
+	if len(files) == 0 {
+		return project.Files
+	}
+	return files
+}
+
+// mergeFiles applies revised files to project, replacing files with the same
+// path (ignoring case) and adding new ones. It returns the changed paths.
+func mergeFiles(project *llm.GeneratedProject, revised []llm.FileInfo) []string {
+	index := make(map[string]int, len(project.Files))
+	for i, file := range project.Files {
+		index[strings.ToLower(file.Path)] = i
+	}
+
+	var changed []string
+	for _, file := range revised {
+		if i, ok := index[strings.ToLower(file.Path)]; ok {
+			if project.Files[i].Content == file.Content {
+				continue
+			}
+			project.Files[i].Content = file.Content
+			changed = append(changed, project.Files[i].Path)
+			continue
+		}
+
+		index[strings.ToLower(file.Path)] = len(project.Files)
+		project.Files = append(project.Files, file)
+		changed = append(changed, file.Path)
+	}
+
+	sort.Strings(changed)
+	return changed
+}
+
+// attentionIssueBody summarizes unresolved diagnostics for a needs-attention issue
+func attentionIssueBody(diagnostics map[string][]task.Diagnostic) string {
+	paths := make([]string, 0, len(diagnostics))
+	for path := range diagnostics {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var b strings.Builder
+	b.WriteString("This repository was generated and pushed with unresolved validation problems.\n\n")
+	for _, path := range paths {
+		fmt.Fprintf(&b, "### `%s`\n\n", path)
+		for _, d := range diagnostics[path] {
+			if d.Line > 0 {
+				fmt.Fprintf(&b, "- **%s** (%s) line %d: %s\n", d.Tool, d.Severity, d.Line, d.Message)
+			} else {
+				fmt.Fprintf(&b, "- **%s** (%s): %s\n", d.Tool, d.Severity, d.Message)
+			}
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,157 @@
+package generator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cosmos-link/gen-code/internal/github"
+	"github.com/cosmos-link/gen-code/internal/llm"
+	"github.com/cosmos-link/gen-code/internal/task"
+	"github.com/cosmos-link/gen-code/internal/validate"
+)
+
+// fixingLLM revises only the files named in fixes
+type fixingLLM struct {
+	llm.Client
+	fixes map[string]string
+}
+
+func (f *fixingLLM) ReviseProject(ctx context.Context, instruction string, files []llm.FileInfo, opts llm.CallOptions) (*llm.GeneratedProject, error) {
+	revised := &llm.GeneratedProject{}
+	for _, file := range files {
+		if content, ok := f.fixes[file.Path]; ok {
+			revised.Files = append(revised.Files, llm.FileInfo{Path: file.Path, Content: content, Type: file.Type})
+		}
+	}
+	return revised, nil
+}
+
+// brokenValidator reports every file on disk that contains BROKEN
+type brokenValidator struct{}
+
+func (brokenValidator) Name() string { return "broken" }
+
+func (brokenValidator) Validate(ctx context.Context, dir string, files []validate.File) ([]validate.Diagnostic, error) {
+	var diags []validate.Diagnostic
+	for _, file := range files {
+		data, err := os.ReadFile(filepath.Join(dir, file.Path))
+		if err != nil {
+			return nil, err
+		}
+		if strings.Contains(string(data), "BROKEN") {
+			diags = append(diags, validate.Diagnostic{Tool: "broken", Severity: validate.SeverityError, Path: file.Path, Line: 1, Message: "file is broken"})
+		}
+	}
+	return diags, nil
+}
+
+func TestFixProjectRecordsRemainingDiagnostics(t *testing.T) {
+	dir := t.TempDir()
+	project := &llm.GeneratedProject{Files: []llm.FileInfo{
+		{Path: "a.go", Content: "package a // BROKEN\n", Type: "go"},
+		{Path: "b.go", Content: "package a // BROKEN\n", Type: "go"},
+	}}
+	for _, file := range project.Files {
+		if err := os.WriteFile(filepath.Join(dir, file.Path), []byte(file.Content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	validators := validate.NewRegistry()
+	validators.Register(brokenValidator{}, validate.TypeGo)
+	manager := task.NewManager(1)
+	tk := manager.CreateTask("prompt", "repo", "", "", task.Options{})
+	g := &Generator{
+		llmClient:   &fixingLLM{fixes: map[string]string{"a.go": "package a\n"}},
+		validators:  validators,
+		taskManager: manager,
+		fixRounds:   1,
+	}
+
+	report, err := g.validateProject(context.Background(), tk.ID, project, dir)
+	if err != nil || report.Count() != 2 {
+		t.Fatalf("initial report = %v, %v, want two issues", report, err)
+	}
+
+	remaining, err := g.fixProject(context.Background(), tk.ID, project, dir, report, llm.CallOptions{})
+	if err != nil {
+		t.Fatalf("fixProject: %v", err)
+	}
+	if remaining.Count() != 1 || len(remaining["b.go"]) != 1 {
+		t.Fatalf("remaining = %v, want only b.go", remaining)
+	}
+
+	var event *task.Event
+	for i := range tk.Events {
+		if tk.Events[i].Type == "fixup" {
+			event = &tk.Events[i]
+		}
+	}
+	if event == nil {
+		t.Fatalf("no fixup event in %+v", tk.Events)
+	}
+	if len(event.Diagnostics) != 2 {
+		t.Errorf("event diagnostics = %v, want the two issues the round addressed", event.Diagnostics)
+	}
+	if _, ok := event.Remaining["b.go"]; !ok || len(event.Remaining) != 1 {
+		t.Errorf("event remaining = %v, want b.go after revalidation", event.Remaining)
+	}
+	if len(event.Files) != 1 || event.Files[0] != "a.go" || !strings.Contains(event.Message, "1 issue(s) remain") {
+		t.Errorf("event = %+v", event)
+	}
+}
+
+// litteringValidator writes into the directory it checks, like pytest
+// caches or go.mod rewritten by -mod=mod
+type litteringValidator struct{ checked string }
+
+func (*litteringValidator) Name() string { return "littering" }
+
+func (v *litteringValidator) Validate(ctx context.Context, dir string, files []validate.File) ([]validate.Diagnostic, error) {
+	v.checked = dir
+	err := github.WriteFilesToDirectory(dir, map[string]string{
+		".pytest_cache/v/cache": "x",
+		"go.mod":                "module rewritten\n",
+	})
+	return nil, err
+}
+
+func TestCheckProjectUsesThrowawayCopy(t *testing.T) {
+	tempDir := t.TempDir()
+	manager := task.NewManager(1)
+	tk := manager.CreateTask("prompt", "repo", "", "", task.Options{})
+	dir := filepath.Join(tempDir, tk.ID)
+
+	project := &llm.GeneratedProject{Files: []llm.FileInfo{
+		{Path: "go.mod", Content: "module example\n", Type: "go"},
+		{Path: "main.go", Content: "package main\n", Type: "go"},
+	}}
+	if err := github.WriteFilesToDirectory(dir, projectContents(project)); err != nil {
+		t.Fatal(err)
+	}
+
+	validator := &litteringValidator{}
+	validators := validate.NewRegistry()
+	validators.Register(validator, validate.TypeGo)
+	g := &Generator{validators: validators, taskManager: manager, tempDir: tempDir}
+
+	if err := g.checkProject(context.Background(), tk, project, dir, llm.CallOptions{}); err != nil {
+		t.Fatalf("checkProject: %v", err)
+	}
+
+	if validator.checked == "" || validator.checked == dir {
+		t.Fatalf("validator ran in %q, want a copy of %s", validator.checked, dir)
+	}
+	if _, err := os.Stat(validator.checked); !os.IsNotExist(err) {
+		t.Errorf("check directory %s not removed", validator.checked)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".pytest_cache")); !os.IsNotExist(err) {
+		t.Error("validator output leaked into the project directory")
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "go.mod")); string(data) != "module example\n" {
+		t.Errorf("go.mod = %q, want the generated content", data)
+	}
+}
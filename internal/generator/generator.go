@@ -3,29 +3,102 @@ package generator
 import (
 	"context"
 	"fmt"
+	"log"
 	"os"
 	"path/filepath"
+	"time"
 
+	"github.com/cosmos-link/gen-code/internal/deps"
 	"github.com/cosmos-link/gen-code/internal/github"
 	"github.com/cosmos-link/gen-code/internal/llm"
+	"github.com/cosmos-link/gen-code/internal/prompts"
+	"github.com/cosmos-link/gen-code/internal/scaffold"
+	"github.com/cosmos-link/gen-code/internal/secrets"
 	"github.com/cosmos-link/gen-code/internal/task"
+	"github.com/cosmos-link/gen-code/internal/templates"
+	"github.com/cosmos-link/gen-code/internal/usage"
+	"github.com/cosmos-link/gen-code/internal/validate"
 )
 
+// Options holds generator settings
+type Options struct {
+	TempDir           string
+	Commit            github.CommitOptions // Default commit identity and signer
+	SplitCommits      bool                 // Push one commit per file category
+	LLMCommitMessages bool                 // Ask the LLM to write commit messages
+	FilePolicy        FilePolicy           // Limits on generated file paths and sizes
+	SecretScanner     *secrets.Scanner     // Optional; nil disables secret scanning
+	SecretScanMode    secrets.Mode         // What to do when secrets are found
+	Validators        *validate.Registry   // Optional; nil disables static checks
+	FixRounds         int                  // LLM fix-up rounds for failing projects
+	FixPolicy         string               // FixPolicyBlock or FixPolicyPush
+	Tests             TestConfig           // Sandboxed execution of generated tests
+	Templates         *templates.Registry  // Optional; starter projects requests can reference
+	Scaffolder        *scaffold.Scaffolder // Optional; adds .gitignore, LICENSE, CI and Dockerfile
+	Reconciler        *deps.Reconciler     // Optional; fixes up dependency manifests
+	ApprovalTimeout   time.Duration        // How long a task waits for approval before it is rejected
+	Usage             *usage.Ledger        // Optional; prices and aggregates LLM usage across tasks
+	Prompts           *prompts.Library     // Optional; nil uses the embedded prompts
+	PromptSet         string               // Prompt set for every provider; empty uses each provider's own
+	PromptMaxFiles    int                  // File limit stated in project prompts
+	Cache             *llm.Cache           // Optional; nil disables LLM response caching
+}
+
 // Generator handles code generation and repository creation
 type Generator struct {
-	llmClient    llm.Client
-	githubClient *github.Client
-	taskManager  *task.Manager
-	tempDir      string
+	llmClient     llm.Client
+	githubClients *github.ClientFactory
+	taskManager   *task.Manager
+	tempDir       string
+	commit        github.CommitOptions
+
+	splitCommits      bool
+	llmCommitMessages bool
+	filePolicy        FilePolicy
+	secretScanner     *secrets.Scanner
+	secretScanMode    secrets.Mode
+	validators        *validate.Registry
+	fixRounds         int
+	fixPolicy         string
+	tests             TestConfig
+	templates         *templates.Registry
+	scaffolder        *scaffold.Scaffolder
+	reconciler        *deps.Reconciler
+	approvalTimeout   time.Duration
+	usage             *usage.Ledger
+	prompts           *prompts.Library
+	promptSet         string
+	promptMaxFiles    int
+	cache             *llm.Cache
 }
 
 // NewGenerator creates a new generator
-func NewGenerator(llmClient llm.Client, githubClient *github.Client, taskManager *task.Manager, tempDir string) *Generator {
+func NewGenerator(llmClient llm.Client, githubClients *github.ClientFactory, taskManager *task.Manager, opts Options) *Generator {
 	return &Generator{
-		llmClient:    llmClient,
-		githubClient: githubClient,
-		taskManager:  taskManager,
-		tempDir:      tempDir,
+		llmClient:     llmClient,
+		githubClients: githubClients,
+		taskManager:   taskManager,
+		tempDir:       opts.TempDir,
+		commit:        opts.Commit,
+
+		splitCommits:      opts.SplitCommits,
+		llmCommitMessages: opts.LLMCommitMessages,
+		filePolicy:        opts.FilePolicy,
+		secretScanner:     opts.SecretScanner,
+		secretScanMode:    opts.SecretScanMode,
+		validators:        opts.Validators,
+		fixRounds:         opts.FixRounds,
+		fixPolicy:         opts.FixPolicy,
+		tests:             opts.Tests,
+		templates:         opts.Templates,
+		scaffolder:        opts.Scaffolder,
+		reconciler:        opts.Reconciler,
+		approvalTimeout:   opts.ApprovalTimeout,
+		usage:             opts.Usage,
+		prompts:           opts.Prompts,
+		promptSet:         opts.PromptSet,
+		promptMaxFiles:    opts.PromptMaxFiles,
+		cache:             opts.Cache,
 	}
 }
 
@@ -37,13 +110,17 @@ func (g *Generator) GenerateAndPush(ctx context.Context, taskID string) error {
 		return err
 	}
 
+	// Attribute every LLM call to the task, enforce its token limit and
+	// log provider failovers
+	llmOpts := g.llmOptions(t)
+
 	// Update status to generating
 	if err := g.taskManager.UpdateTask(taskID, task.StatusGenerating, "Generating code with LLM..."); err != nil {
 		return err
 	}
 
 	// Generate project using LLM
-	project, err := g.llmClient.GenerateProject(ctx, t.Prompt)
+	project, err := g.generateProject(ctx, t, llmOpts)
 	if err != nil {
 		g.taskManager.SetTaskError(taskID, fmt.Errorf("failed to generate code: %w", err))
 		return err
@@ -62,6 +139,41 @@ func (g *Generator) GenerateAndPush(ctx context.Context, taskID string) error {
 		return err
 	}
 
+	// Drop unsafe paths and oversized files before anything touches disk
+	files, rejections := sanitizeFiles(project.Files, g.filePolicy)
+	if len(rejections) > 0 {
+		g.taskManager.AddTaskRejections(taskID, rejections)
+		g.taskManager.UpdateTask(taskID, task.StatusMergingFiles, fmt.Sprintf("Writing files to disk (%d rejected by file policy)...", len(rejections)))
+	}
+	if len(files) == 0 {
+		err := fmt.Errorf("no generated files passed the file policy")
+		g.taskManager.SetTaskError(taskID, err)
+		return err
+	}
+	project.Files = files
+
+	// Add standard repository files the LLM left out
+	if g.scaffolder != nil {
+		added, err := g.scaffolder.Apply(project, scaffold.Request{License: t.License, Owner: t.GitHubOrg})
+		if err != nil {
+			g.taskManager.SetTaskError(taskID, fmt.Errorf("failed to add scaffolding: %w", err))
+			return err
+		}
+		if len(added) > 0 {
+			g.taskManager.AddTaskEvent(taskID, task.Event{
+				Type:    "scaffold",
+				Message: fmt.Sprintf("Added %d scaffolding file(s)", len(added)),
+				Files:   added,
+			})
+		}
+	}
+
+	// Reconcile go.mod and requirements.txt with the generated imports
+	if err := g.reconcileDependencies(taskID, project); err != nil {
+		g.taskManager.SetTaskError(taskID, fmt.Errorf("failed to reconcile dependencies: %w", err))
+		return err
+	}
+
 	// Write files to disk
 	fileMap := make(map[string]string)
 	for _, file := range project.Files {
@@ -73,13 +185,46 @@ func (g *Generator) GenerateAndPush(ctx context.Context, taskID string) error {
 		return err
 	}
 
+	// Validate, repair and scan the project before anything leaves the host
+	if err := g.checkProject(ctx, t, project, projectDir, llmOpts); err != nil {
+		return err
+	}
+
+	// Dry runs stop here; the project stays available from the task store
+	if t.DryRun {
+		if err := g.taskManager.UpdateTask(taskID, task.StatusCompleted, fmt.Sprintf("Dry run complete: %d file(s) generated, nothing pushed", len(project.Files))); err != nil {
+			return err
+		}
+		os.RemoveAll(projectDir)
+		return nil
+	}
+
+	// Pause for a reviewer before anything reaches GitHub
+	if t.RequireApproval {
+		approved, err := g.awaitApproval(ctx, t, project, projectDir)
+		if err != nil {
+			g.taskManager.SetTaskError(taskID, fmt.Errorf("failed to apply approval: %w", err))
+			return err
+		}
+		if !approved {
+			return nil
+		}
+	}
+
 	// Update status to creating repo
 	if err := g.taskManager.UpdateTask(taskID, task.StatusCreatingRepo, "Creating GitHub repository..."); err != nil {
 		return err
 	}
 
+	// Build a GitHub client acting as the task's identity
+	githubClient, err := g.githubClientFor(t)
+	if err != nil {
+		g.taskManager.SetTaskError(taskID, fmt.Errorf("failed to resolve GitHub credentials: %w", err))
+		return err
+	}
+
 	// Create GitHub repository
-	repo, err := g.githubClient.CreateRepository(ctx, t.RepoName, project.Description, false)
+	repo, err := githubClient.CreateRepository(ctx, t.RepoName, project.Description, t.Private)
 	if err != nil {
 		g.taskManager.SetTaskError(taskID, fmt.Errorf("failed to create repository: %w", err))
 		return err
@@ -94,12 +239,25 @@ func (g *Generator) GenerateAndPush(ctx context.Context, taskID string) error {
 	}
 
 	// Push files to GitHub
-	commitMessage := fmt.Sprintf("Initial commit: %s", project.Description)
-	if err := g.githubClient.PushFiles(ctx, repoURL, projectDir, commitMessage); err != nil {
+	commits := []github.Commit{{Message: fmt.Sprintf("Initial commit: %s", project.Description), Paths: projectPaths(project)}}
+	if g.splitCommits {
+		commits = g.buildCommits(ctx, project, planCommits(project.Files), llmOpts)
+	}
+	commitOpts := g.commitOptionsFor(ctx, t, githubClient)
+	if err := githubClient.PushCommits(ctx, repoURL, projectDir, commits, commitOpts); err != nil {
 		g.taskManager.SetTaskError(taskID, fmt.Errorf("failed to push files: %w", err))
 		return err
 	}
 
+	// Flag unresolved problems on the repository itself
+	if t.HasLabel(task.LabelNeedsAttention) {
+		_, err := githubClient.CreateIssue(ctx, repo.GetOwner().GetLogin(), repo.GetName(),
+			"Generated code needs attention", attentionIssueBody(t.Diagnostics), []string{task.LabelNeedsAttention})
+		if err != nil {
+			g.taskManager.AddTaskEvent(taskID, task.Event{Type: "issue_failed", Message: err.Error()})
+		}
+	}
+
 	// Update status to completed
 	if err := g.taskManager.UpdateTask(taskID, task.StatusCompleted, "Successfully generated and pushed code!"); err != nil {
 		return err
@@ -111,6 +269,224 @@ func (g *Generator) GenerateAndPush(ctx context.Context, taskID string) error {
 	return nil
 }
 
+// checkProject validates the project written to dir, asks the LLM to repair
+// what fails and scans it for secrets. Failures are recorded on the task;
+// the project is stored on the task only once it passed the scan.
+func (g *Generator) checkProject(ctx context.Context, t *task.Task, project *llm.GeneratedProject, dir string, llmOpts llm.CallOptions) error {
+	// Check that the generated code is well-formed and its tests pass
+	if g.validators != nil || g.tests.Runner != nil {
+		if err := g.taskManager.UpdateTask(t.ID, task.StatusValidating, "Validating generated code..."); err != nil {
+			return err
+		}
+
+		// Validators and tests run on a throwaway copy: whatever they leave
+		// behind, such as caches or a go.mod rewritten by -mod=mod, must not
+		// reach the pushed directory
+		checkDir, err := g.writeCheckDir(t.ID, project)
+		if err != nil {
+			g.taskManager.SetTaskError(t.ID, err)
+			return err
+		}
+		defer os.RemoveAll(checkDir)
+
+		report, err := g.validateProject(ctx, t.ID, project, checkDir)
+		if err != nil {
+			g.taskManager.SetTaskError(t.ID, fmt.Errorf("failed to validate project: %w", err))
+			return err
+		}
+
+		// Ask the LLM to repair what failed
+		if report.Count() > 0 && g.fixRounds > 0 {
+			report, err = g.fixProject(ctx, t.ID, project, checkDir, report, llmOpts)
+			if err != nil {
+				g.taskManager.SetTaskError(t.ID, fmt.Errorf("failed to fix project: %w", err))
+				return err
+			}
+			if err := github.WriteFilesToDirectory(dir, projectContents(project)); err != nil {
+				g.taskManager.SetTaskError(t.ID, fmt.Errorf("failed to write fixed files: %w", err))
+				return err
+			}
+		}
+		g.taskManager.SetTaskDiagnostics(t.ID, taskDiagnostics(report))
+
+		if errCount := report.Errors(); errCount > 0 {
+			if g.fixPolicy != FixPolicyPush {
+				err := fmt.Errorf("project still has %d error(s) after validation", errCount)
+				g.taskManager.SetTaskError(t.ID, err)
+				return err
+			}
+			g.taskManager.AddTaskLabel(t.ID, task.LabelNeedsAttention)
+			g.taskManager.UpdateTask(t.ID, task.StatusValidating, fmt.Sprintf("Pushing despite %d unresolved error(s)", errCount))
+		} else if count := report.Count(); count > 0 {
+			g.taskManager.UpdateTask(t.ID, task.StatusValidating, fmt.Sprintf("Validation found %d warning(s) in %d file(s)", count, len(report)))
+		}
+	}
+
+	// Scan for secrets before anything leaves the host
+	if g.secretScanner != nil {
+		if err := g.taskManager.UpdateTask(t.ID, task.StatusScanning, "Scanning generated files for secrets..."); err != nil {
+			return err
+		}
+
+		findings, redacted, err := g.scanSecrets(t, project)
+		if len(findings) > 0 {
+			g.taskManager.AddTaskSecretFindings(t.ID, findings)
+		}
+		if err != nil {
+			// The raw files must not be served from the task store
+			g.taskManager.ClearTaskProject(t.ID)
+			g.taskManager.SetTaskError(t.ID, err)
+			return err
+		}
+
+		if len(redacted) > 0 {
+			if err := github.WriteFilesToDirectory(dir, filesByPath(project.Files, redacted)); err != nil {
+				g.taskManager.SetTaskError(t.ID, fmt.Errorf("failed to write redacted files: %w", err))
+				return err
+			}
+		}
+	}
+
+	// Only a project that passed the scan, or was redacted, is stored for
+	// download
+	g.taskManager.SetTaskProject(t.ID, project)
+
+	return nil
+}
+
+// reconcileDependencies fixes up go.mod and requirements.txt to match the
+// project's imports and records the changes on the task
+func (g *Generator) reconcileDependencies(taskID string, project *llm.GeneratedProject) error {
+	if g.reconciler == nil {
+		return nil
+	}
+
+	changes, err := g.reconciler.Reconcile(project)
+	if err != nil {
+		return err
+	}
+	if len(changes) > 0 {
+		g.taskManager.AddTaskDependencyChanges(taskID, taskDependencyChanges(changes))
+	}
+	return nil
+}
+
+// writeCheckDir writes the project to a fresh directory for validators and
+// tests to run in
+func (g *Generator) writeCheckDir(taskID string, project *llm.GeneratedProject) (string, error) {
+	dir := filepath.Join(g.tempDir, taskID+"-check")
+	if err := os.RemoveAll(dir); err != nil {
+		return "", fmt.Errorf("failed to clear check directory: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create check directory: %w", err)
+	}
+	if err := github.WriteFilesToDirectory(dir, projectContents(project)); err != nil {
+		os.RemoveAll(dir)
+		return "", fmt.Errorf("failed to write check directory: %w", err)
+	}
+	return dir, nil
+}
+
+// projectPaths returns the paths of a project's files
+func projectPaths(project *llm.GeneratedProject) []string {
+	paths := make([]string, 0, len(project.Files))
+	for _, file := range project.Files {
+		paths = append(paths, file.Path)
+	}
+	return paths
+}
+
+// filesByPath returns the contents of the named files keyed by path
+func filesByPath(files []llm.FileInfo, paths []string) map[string]string {
+	wanted := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		wanted[p] = true
+	}
+
+	fileMap := make(map[string]string, len(paths))
+	for _, file := range files {
+		if wanted[file.Path] {
+			fileMap[file.Path] = file.Content
+		}
+	}
+	return fileMap
+}
+
+// taskDependencyChanges converts reconciliation changes to their task
+// representation
+func taskDependencyChanges(changes []deps.Change) []task.DependencyChange {
+	converted := make([]task.DependencyChange, 0, len(changes))
+	for _, c := range changes {
+		converted = append(converted, task.DependencyChange{
+			Manifest: c.Manifest,
+			Package:  c.Package,
+			Version:  c.Version,
+			Action:   c.Action,
+			Reason:   c.Reason,
+		})
+	}
+	return converted
+}
+
+// githubClientFor returns a GitHub client for the task's credentials and org
+func (g *Generator) githubClientFor(t *task.Task) (*github.Client, error) {
+	var creds github.Credentials
+	if t.GitHub != nil {
+		creds = github.Credentials{
+			Token:          t.GitHub.Token,
+			CredentialRef:  t.GitHub.CredentialRef,
+			InstallationID: t.GitHub.InstallationID,
+			Caller:         t.GitHub.Caller,
+		}
+	}
+	return g.githubClients.ClientFor(creds, t.GitHubOrg)
+}
+
+// AuthorizeGitHub checks that a caller may use the stored credential or
+// App installation it requested
+func (g *Generator) AuthorizeGitHub(creds *task.GitHubCredentials) error {
+	if creds == nil {
+		return nil
+	}
+	return g.githubClients.Authorize(github.Credentials{
+		CredentialRef:  creds.CredentialRef,
+		InstallationID: creds.InstallationID,
+		Caller:         creds.Caller,
+	})
+}
+
+// commitOptionsFor applies the task's commit identity overrides to the
+// configured defaults
+func (g *Generator) commitOptionsFor(ctx context.Context, t *task.Task, githubClient *github.Client) github.CommitOptions {
+	opts := g.commit
+	if t.CommitAuthor != nil {
+		opts.Author = github.Identity{Name: t.CommitAuthor.Name, Email: t.CommitAuthor.Email}
+	}
+	// A signed commit is vouched for by the key's owner, so the configured
+	// committer stays in place however the task was created
+	if t.CommitCommitter != nil && opts.Signer == nil {
+		opts.Committer = github.Identity{Name: t.CommitCommitter.Name, Email: t.CommitCommitter.Email}
+	}
+
+	opts.CoAuthors = nil
+	for _, coAuthor := range t.CoAuthors {
+		opts.CoAuthors = append(opts.CoAuthors, github.Identity{Name: coAuthor.Name, Email: coAuthor.Email})
+	}
+
+	// Credit the user whose token the task runs with
+	if t.CoAuthorRequester {
+		requester, err := githubClient.AuthenticatedIdentity(ctx)
+		if err != nil {
+			log.Printf("Task %s: skipping requester co-author: %v", t.ID, err)
+		} else {
+			opts.CoAuthors = append(opts.CoAuthors, requester)
+		}
+	}
+
+	return opts
+}
+
 // ProcessTask is a convenience method to process a task asynchronously
 func (g *Generator) ProcessTask(taskID string) {
 	ctx := context.Background()
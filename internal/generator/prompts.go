@@ -0,0 +1,60 @@
+package generator
+
+import (
+	"github.com/cosmos-link/gen-code/internal/llm"
+	"github.com/cosmos-link/gen-code/internal/prompts"
+	"github.com/cosmos-link/gen-code/internal/task"
+)
+
+// HasPromptSet reports whether a prompt set exists
+func (g *Generator) HasPromptSet(set string) bool {
+	return g.promptLibrary().Has(set)
+}
+
+// PromptSets returns the names of the available prompt sets
+func (g *Generator) PromptSets() []string {
+	return g.promptLibrary().Sets()
+}
+
+// promptLibrary returns the configured prompts or the embedded defaults
+func (g *Generator) promptLibrary() *prompts.Library {
+	if g.prompts == nil {
+		return prompts.Default()
+	}
+	return g.prompts
+}
+
+// promptOptions selects a task's prompt set, target language and coding
+// conventions. Each comes from the request, then the task's template
+// manifest; the set falls back to the server's PROMPT_SET and then to each
+// provider's own.
+func (g *Generator) promptOptions(t *task.Task) llm.PromptOptions {
+	set, language, conventions := t.PromptSet, t.Language, t.Conventions
+
+	if t.Template != "" && g.templates != nil {
+		if tmpl, ok := g.templates.Get(t.Template); ok {
+			if set == "" {
+				set = tmpl.PromptSet
+			}
+			if language == "" {
+				language = tmpl.Language
+			}
+			if conventions == "" {
+				conventions = tmpl.Conventions
+			}
+		}
+	}
+	if set == "" {
+		set = g.promptSet
+	}
+
+	return llm.PromptOptions{
+		Library: g.promptLibrary(),
+		Set:     set,
+		Data: prompts.Data{
+			MaxFiles:    g.promptMaxFiles,
+			Language:    language,
+			Conventions: conventions,
+		},
+	}
+}
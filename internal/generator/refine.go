@@ -0,0 +1,457 @@
+package generator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/cosmos-link/gen-code/internal/diff"
+	"github.com/cosmos-link/gen-code/internal/github"
+	"github.com/cosmos-link/gen-code/internal/llm"
+	"github.com/cosmos-link/gen-code/internal/task"
+)
+
+// refineBranchPrefix prefixes the branches refinement pull requests come from
+const refineBranchPrefix = "gen-code/refine-"
+
+// RefineAndPush applies a refinement task's prompt to its parent's project
+// and pushes the result to the parent's repository, as a commit on the
+// parent's branch or as a pull request against it
+func (g *Generator) RefineAndPush(ctx context.Context, taskID string) error {
+	t, err := g.taskManager.GetTask(taskID)
+	if err != nil {
+		return err
+	}
+	parent, err := g.taskManager.GetTask(t.ParentID)
+	if err != nil {
+		g.taskManager.SetTaskError(taskID, err)
+		return err
+	}
+	llmOpts := g.llmOptions(t)
+
+	if err := g.taskManager.UpdateTask(taskID, task.StatusGenerating, "Loading the project to refine..."); err != nil {
+		return err
+	}
+
+	projectDir := filepath.Join(g.tempDir, taskID)
+
+	// Check out the parent's branch; dry runs work from the stored project only
+	var (
+		githubClient *github.Client
+		owner, name  string
+		base         string
+	)
+	if !t.DryRun {
+		owner, name, err = github.ParseRepoURL(parent.RepoURL)
+		if err != nil {
+			g.taskManager.SetTaskError(taskID, err)
+			return err
+		}
+		g.taskManager.SetTaskRepoURL(taskID, parent.RepoURL)
+
+		githubClient, err = g.githubClientFor(t)
+		if err != nil {
+			g.taskManager.SetTaskError(taskID, fmt.Errorf("failed to resolve GitHub credentials: %w", err))
+			return err
+		}
+
+		cloneURL := strings.TrimSuffix(parent.RepoURL, "/") + ".git"
+		base, err = githubClient.CloneRepository(ctx, cloneURL, projectDir, parent.Branch)
+		if err != nil {
+			g.taskManager.SetTaskError(taskID, err)
+			return err
+		}
+	} else if err := os.MkdirAll(projectDir, 0755); err != nil {
+		g.taskManager.SetTaskError(taskID, fmt.Errorf("failed to create temp directory: %w", err))
+		return err
+	}
+
+	// Prefer the stored project; fall back to what the clone contains
+	before, err := g.taskManager.GetTaskProject(parent.ID)
+	if errors.Is(err, task.ErrNoProject) && !t.DryRun {
+		before, err = readProject(projectDir, parent.RepoName, g.filePolicy)
+	}
+	if err != nil {
+		g.taskManager.SetTaskError(taskID, fmt.Errorf("failed to load project: %w", err))
+		return err
+	}
+
+	project := &llm.GeneratedProject{
+		Name:        before.Name,
+		Description: before.Description,
+		Files:       append([]llm.FileInfo(nil), before.Files...),
+	}
+
+	if err := g.taskManager.UpdateTask(taskID, task.StatusGenerating, "Revising project with LLM..."); err != nil {
+		return err
+	}
+
+	revised, err := g.llmClient.ReviseProject(ctx, t.Prompt, project.Files, llmOpts)
+	if err != nil {
+		g.taskManager.SetTaskError(taskID, fmt.Errorf("failed to revise code: %w", err))
+		return err
+	}
+
+	if err := g.taskManager.UpdateTask(taskID, task.StatusMergingFiles, "Applying changes..."); err != nil {
+		return err
+	}
+
+	files, rejections := sanitizeFiles(revised.Files, g.filePolicy)
+	if len(rejections) > 0 {
+		g.taskManager.AddTaskRejections(taskID, rejections)
+	}
+	mergeFiles(project, files)
+	removeFiles(project, revised.Deleted)
+
+	if err := g.reconcileDependencies(taskID, project); err != nil {
+		g.taskManager.SetTaskError(taskID, fmt.Errorf("failed to reconcile dependencies: %w", err))
+		return err
+	}
+
+	changes := diffProjects(before, project)
+	if len(changes) == 0 {
+		err := fmt.Errorf("the LLM made no changes")
+		g.taskManager.SetTaskError(taskID, err)
+		return err
+	}
+
+	// A dry run starts from an empty directory and needs every file
+	if err := writeChanges(projectDir, project, changes, t.DryRun); err != nil {
+		g.taskManager.SetTaskError(taskID, err)
+		return err
+	}
+	added, removed := lineCounts(changes)
+	g.taskManager.AddTaskEvent(taskID, task.Event{
+		Type:    "refine",
+		Message: fmt.Sprintf("Changed %d file(s), +%d -%d lines", len(changes), added, removed),
+		Files:   changedPaths(changes),
+	})
+
+	if err := g.checkProject(ctx, t, project, projectDir, llmOpts); err != nil {
+		return err
+	}
+
+	// The patches quote file contents, so they are stored only after the
+	// secret scan, and include fix-up rounds and redactions
+	changes = diffProjects(before, project)
+	g.taskManager.SetTaskFileChanges(taskID, changes)
+
+	if t.DryRun {
+		if err := g.taskManager.UpdateTask(taskID, task.StatusCompleted, fmt.Sprintf("Dry run complete: %d file(s) changed, nothing pushed", len(changes))); err != nil {
+			return err
+		}
+		os.RemoveAll(projectDir)
+		return nil
+	}
+
+	if t.RequireApproval {
+		approved, err := g.awaitApproval(ctx, t, project, projectDir)
+		if err != nil {
+			g.taskManager.SetTaskError(taskID, fmt.Errorf("failed to apply approval: %w", err))
+			return err
+		}
+		if !approved {
+			return nil
+		}
+	}
+
+	// Fix-up rounds and reviewer edits may have touched more files
+	changes = diffProjects(before, project)
+	g.taskManager.SetTaskFileChanges(taskID, changes)
+
+	if err := g.taskManager.UpdateTask(taskID, task.StatusPushing, "Pushing changes to GitHub..."); err != nil {
+		return err
+	}
+
+	branch := base
+	if t.RefineMode == task.RefineModePullRequest {
+		branch = refineBranchPrefix + taskID[:8]
+	}
+
+	message := refineCommitMessage(t.Prompt, revised.Description)
+	commitOpts := g.commitOptionsFor(ctx, t, githubClient)
+	if err := githubClient.PushChanges(ctx, projectDir, branch, []github.Commit{{Message: message, Paths: changedPaths(changes)}}, commitOpts); err != nil {
+		g.taskManager.SetTaskError(taskID, fmt.Errorf("failed to push changes: %w", err))
+		return err
+	}
+
+	var pullRequestURL string
+	if t.RefineMode == task.RefineModePullRequest {
+		pr, err := githubClient.CreatePullRequest(ctx, owner, name, refineSubject(t.Prompt), refineBody(t.Prompt, changes), branch, base)
+		if err != nil {
+			g.taskManager.SetTaskError(taskID, err)
+			return err
+		}
+		pullRequestURL = pr.GetHTMLURL()
+	}
+	g.taskManager.SetTaskBranch(taskID, branch, pullRequestURL)
+
+	if t.HasLabel(task.LabelNeedsAttention) {
+		_, err := githubClient.CreateIssue(ctx, owner, name,
+			"Refined code needs attention", attentionIssueBody(t.Diagnostics), []string{task.LabelNeedsAttention})
+		if err != nil {
+			g.taskManager.AddTaskEvent(taskID, task.Event{Type: "issue_failed", Message: err.Error()})
+		}
+	}
+
+	if err := g.taskManager.UpdateTask(taskID, task.StatusCompleted, "Successfully refined and pushed changes!"); err != nil {
+		return err
+	}
+
+	os.RemoveAll(projectDir)
+
+	return nil
+}
+
+// ProcessRefinement runs a refinement task asynchronously
+func (g *Generator) ProcessRefinement(taskID string) {
+	ctx := context.Background()
+	if err := g.RefineAndPush(ctx, taskID); err != nil {
+		// Error is already set in the task
+		return
+	}
+}
+
+// removeFiles drops the named files from the project, ignoring paths it
+// does not contain, and returns the paths removed
+func removeFiles(project *llm.GeneratedProject, paths []string) []string {
+	remove := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		if cleaned, err := normalizePath(p); err == nil {
+			remove[strings.ToLower(cleaned)] = true
+		}
+	}
+
+	var removed []string
+	kept := project.Files[:0]
+	for _, file := range project.Files {
+		if remove[strings.ToLower(file.Path)] {
+			removed = append(removed, file.Path)
+			continue
+		}
+		kept = append(kept, file)
+	}
+	project.Files = kept
+
+	return removed
+}
+
+// diffProjects diffs two versions of a project, returning the files
+// added, modified or deleted sorted by path
+func diffProjects(before, after *llm.GeneratedProject) []task.FileChange {
+	files := diff.Files(projectContents(before), projectContents(after))
+
+	changes := make([]task.FileChange, 0, len(files))
+	for _, file := range files {
+		changes = append(changes, task.FileChange{
+			Path:    file.Path,
+			Action:  file.Action,
+			Added:   file.Added,
+			Removed: file.Removed,
+			Patch:   file.Patch,
+		})
+	}
+	return changes
+}
+
+// projectContents returns a project's file contents keyed by path
+func projectContents(project *llm.GeneratedProject) map[string]string {
+	contents := make(map[string]string, len(project.Files))
+	for _, file := range project.Files {
+		contents[file.Path] = file.Content
+	}
+	return contents
+}
+
+// changedPaths returns the paths of a list of file changes
+func changedPaths(changes []task.FileChange) []string {
+	paths := make([]string, 0, len(changes))
+	for _, change := range changes {
+		paths = append(paths, change.Path)
+	}
+	return paths
+}
+
+// lineCounts totals the lines added and removed by a set of file changes
+func lineCounts(changes []task.FileChange) (int, int) {
+	added, removed := 0, 0
+	for _, change := range changes {
+		added += change.Added
+		removed += change.Removed
+	}
+	return added, removed
+}
+
+// writeChanges applies file changes to dir, or writes the whole project
+// when all is set
+func writeChanges(dir string, project *llm.GeneratedProject, changes []task.FileChange, all bool) error {
+	var written []string
+	for _, change := range changes {
+		if change.Action != task.FileDeleted {
+			written = append(written, change.Path)
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, filepath.FromSlash(change.Path))); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to delete %s: %w", change.Path, err)
+		}
+	}
+	if all {
+		written = written[:0]
+		for _, file := range project.Files {
+			written = append(written, file.Path)
+		}
+	}
+
+	if err := github.WriteFilesToDirectory(dir, filesByPath(project.Files, written)); err != nil {
+		return fmt.Errorf("failed to write files: %w", err)
+	}
+	return nil
+}
+
+// readProject loads the text files of a cloned repository, skipping git
+// metadata, symlinks, binary files and files over the policy's size limit
+func readProject(dir, name string, policy FilePolicy) (*llm.GeneratedProject, error) {
+	project := &llm.GeneratedProject{Name: name}
+
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		if (policy.MaxFileSize > 0 && len(data) > policy.MaxFileSize) || !utf8.Valid(data) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		project.Files = append(project.Files, llm.FileInfo{Path: filepath.ToSlash(rel), Content: string(data)})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read repository: %w", err)
+	}
+
+	return project, nil
+}
+
+// refineSubject turns a refinement prompt into a one-line summary
+func refineSubject(prompt string) string {
+	subject, _, _ := strings.Cut(strings.TrimSpace(prompt), "\n")
+	subject = strings.TrimSpace(subject)
+	if runes := []rune(subject); len(runes) > 72 {
+		subject = strings.TrimSpace(string(runes[:69])) + "..."
+	}
+	return "Refine: " + subject
+}
+
+// refineCommitMessage builds the commit message for a refinement, using
+// the LLM's summary of the change as the body
+func refineCommitMessage(prompt, description string) string {
+	subject := refineSubject(prompt)
+	if description = strings.TrimSpace(description); description == "" {
+		return subject
+	}
+	return subject + "\n\n" + description
+}
+
+// maxPullRequestBody is GitHub's limit on the length of a pull request body
+const maxPullRequestBody = 65536
+
+// Notes appended where refineBody cuts content to fit the limit
+const (
+	diffTruncated        = "\n... diff truncated; see the Files changed tab\n"
+	descriptionTruncated = "\n... description truncated; see the task for the full prompt and file list\n"
+)
+
+// refineBody describes a refinement for its pull request: the prompt, a
+// per-file summary and as much of the unified diff as fits. The result is
+// always shorter than maxPullRequestBody bytes, and so characters.
+func refineBody(prompt string, changes []task.FileChange) string {
+	var b strings.Builder
+	b.WriteString("Follow-up prompt:\n\n")
+	for _, line := range strings.Split(strings.TrimSpace(prompt), "\n") {
+		b.WriteString("> " + line + "\n")
+	}
+
+	b.WriteString("\n| File | Change | Lines |\n|------|--------|-------|\n")
+	for _, change := range changes {
+		fmt.Fprintf(&b, "| `%s` | %s | +%d -%d |\n", change.Path, change.Action, change.Added, change.Removed)
+	}
+
+	// A prompt or file list too long on its own leaves no room for the diff
+	head := b.String()
+	if len(head) > maxPullRequestBody-len(descriptionTruncated)-1 {
+		return truncateLines(head, maxPullRequestBody-len(descriptionTruncated)-1) + descriptionTruncated
+	}
+
+	fence := diffFence(changes)
+	open, end := "\n"+fence+"diff\n", fence+"\n"
+	room := maxPullRequestBody - 1 - len(head) - len(open) - len(end) - len(diffTruncated)
+
+	var patch strings.Builder
+	for _, change := range changes {
+		if patch.Len()+len(change.Patch) > room {
+			if room > 0 {
+				patch.WriteString(diffTruncated)
+			}
+			break
+		}
+		patch.WriteString(change.Patch)
+	}
+	if patch.Len() == 0 {
+		return head
+	}
+
+	return head + open + patch.String() + end
+}
+
+// diffFence returns a code fence longer than any run of backticks in the
+// patches, so no line of the diff can close the block early
+func diffFence(changes []task.FileChange) string {
+	longest := 0
+	for _, change := range changes {
+		run := 0
+		for _, r := range change.Patch {
+			if r == '`' {
+				run++
+				longest = max(longest, run)
+			} else {
+				run = 0
+			}
+		}
+	}
+	return strings.Repeat("`", max(3, longest+1))
+}
+
+// truncateLines cuts s to at most n bytes, ending after a whole line where
+// there is one
+func truncateLines(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	s = s[:n]
+	if i := strings.LastIndexByte(s, '\n'); i >= 0 {
+		return s[:i+1]
+	}
+	return strings.ToValidUTF8(s, "")
+}
@@ -0,0 +1,58 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cosmos-link/gen-code/internal/task"
+)
+
+func TestRefineBody(t *testing.T) {
+	change := func(path, patch string) task.FileChange {
+		return task.FileChange{Path: path, Action: task.FileModified, Added: 1, Removed: 1, Patch: patch}
+	}
+	small := change("main.go", "diff --git a/main.go b/main.go\n-old\n+new\n")
+	backticks := change("README.md", "diff --git a/README.md b/README.md\n+````go\n+```\n")
+	large := change("data.txt", "diff --git a/data.txt b/data.txt\n"+strings.Repeat("+0123456789abcdef\n", 4000))
+
+	tests := []struct {
+		name      string
+		prompt    string
+		changes   []task.FileChange
+		wantFence string // "" when no diff block is expected
+		want      []string
+		notWant   []string
+	}{
+		{"small diff", "Rename the flag", []task.FileChange{small}, "```", []string{"> Rename the flag\n", "| `main.go` | modified | +1 -1 |", "-old\n+new\n"}, []string{"truncated"}},
+		{"fence outruns backticks", "Fix docs", []task.FileChange{backticks}, "`````", []string{"+````go\n"}, nil},
+		{"large diff keeps earlier files", "Add data", []task.FileChange{small, large, small}, "```", []string{"-old\n+new\n", diffTruncated}, []string{"+0123456789abcdef"}},
+		{"large first file", "Add data", []task.FileChange{large}, "```", []string{"| `data.txt` |", diffTruncated}, []string{"+0123456789abcdef"}},
+		{"huge prompt", strings.Repeat("Please also handle this case.\n", 3000), []task.FileChange{small}, "", []string{"> Please also", descriptionTruncated}, []string{"-old\n+new\n"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := refineBody(tt.prompt, tt.changes)
+
+			if len(body) >= maxPullRequestBody {
+				t.Errorf("body is %d bytes, want fewer than %d", len(body), maxPullRequestBody)
+			}
+			if tt.wantFence == "" {
+				if strings.Contains(body, "```") {
+					t.Errorf("body has a diff block, want none")
+				}
+			} else if !strings.Contains(body, "\n"+tt.wantFence+"diff\n") || !strings.HasSuffix(body, "\n"+tt.wantFence+"\n") {
+				t.Errorf("body is not fenced with %s:\n%s", tt.wantFence, body)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(body, want) {
+					t.Errorf("body lacks %q", want)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(body, notWant) {
+					t.Errorf("body contains %q", notWant)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package generator
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/cosmos-link/gen-code/internal/llm"
+	"github.com/cosmos-link/gen-code/internal/task"
+)
+
+// FilePolicy limits which LLM-generated files may be written
+type FilePolicy struct {
+	MaxFileSize int // Maximum size of a single file in bytes, 0 for no limit
+	MaxFiles    int // Maximum number of files, 0 for no limit
+}
+
+// windowsReservedNames are device names that cannot be used as file names
+var windowsReservedNames = map[string]bool{
+	"con": true, "prn": true, "aux": true, "nul": true,
+	"com1": true, "com2": true, "com3": true, "com4": true, "com5": true,
+	"com6": true, "com7": true, "com8": true, "com9": true,
+	"lpt1": true, "lpt2": true, "lpt3": true, "lpt4": true, "lpt5": true,
+	"lpt6": true, "lpt7": true, "lpt8": true, "lpt9": true,
+}
+
+// sanitizeFiles normalizes file paths and drops files that violate the
+// policy, returning the accepted files and a rejection for each dropped one
+func sanitizeFiles(files []llm.FileInfo, policy FilePolicy) ([]llm.FileInfo, []task.FileRejection) {
+	var (
+		accepted   []llm.FileInfo
+		rejections []task.FileRejection
+		seen       = make(map[string]string) // lowercase path -> accepted path
+	)
+
+	reject := func(filePath, reason string) {
+		rejections = append(rejections, task.FileRejection{Path: filePath, Reason: reason})
+	}
+
+	for _, file := range files {
+		cleaned, err := normalizePath(file.Path)
+		if err != nil {
+			reject(file.Path, err.Error())
+			continue
+		}
+
+		if policy.MaxFileSize > 0 && len(file.Content) > policy.MaxFileSize {
+			reject(file.Path, fmt.Sprintf("file is %d bytes, exceeding the %d byte limit", len(file.Content), policy.MaxFileSize))
+			continue
+		}
+
+		key := strings.ToLower(cleaned)
+		if existing, ok := seen[key]; ok {
+			reject(file.Path, fmt.Sprintf("duplicate of %s", existing))
+			continue
+		}
+
+		if conflict := pathConflict(key, seen); conflict != "" {
+			reject(file.Path, fmt.Sprintf("conflicts with %s (a path cannot be both a file and a directory)", conflict))
+			continue
+		}
+
+		if policy.MaxFiles > 0 && len(accepted) >= policy.MaxFiles {
+			reject(file.Path, fmt.Sprintf("project exceeds the %d file limit", policy.MaxFiles))
+			continue
+		}
+
+		seen[key] = cleaned
+		file.Path = cleaned
+		accepted = append(accepted, file)
+	}
+
+	return accepted, rejections
+}
+
+// normalizePath converts a model-supplied path into a clean relative
+// slash-separated path, or returns why it is unsafe
+func normalizePath(filePath string) (string, error) {
+	p := strings.TrimSpace(strings.ReplaceAll(filePath, "\\", "/"))
+	if p == "" {
+		return "", fmt.Errorf("empty path")
+	}
+
+	for _, r := range p {
+		if r < 0x20 || r == 0x7f {
+			return "", fmt.Errorf("path contains control characters")
+		}
+	}
+
+	if strings.HasPrefix(p, "/") || (len(p) >= 2 && p[1] == ':') {
+		return "", fmt.Errorf("absolute paths are not allowed")
+	}
+
+	cleaned := path.Clean(p)
+	if cleaned == "." {
+		return "", fmt.Errorf("path does not name a file")
+	}
+
+	for _, part := range strings.Split(cleaned, "/") {
+		lower := strings.ToLower(part)
+		switch {
+		case part == "..":
+			return "", fmt.Errorf("path escapes the project directory")
+		case lower == ".git":
+			return "", fmt.Errorf("writing into .git is not allowed")
+		case strings.HasPrefix(part, "-"):
+			return "", fmt.Errorf("path component %q starts with a dash", part)
+		case strings.HasSuffix(part, ".") || strings.HasSuffix(part, " "):
+			return "", fmt.Errorf("path component %q ends with a dot or space", part)
+		case windowsReservedNames[strings.SplitN(lower, ".", 2)[0]]:
+			return "", fmt.Errorf("path component %q is a reserved device name", part)
+		}
+	}
+
+	return cleaned, nil
+}
+
+// pathConflict returns an accepted path that is a parent or child of key
+func pathConflict(key string, seen map[string]string) string {
+	for other, original := range seen {
+		if strings.HasPrefix(key, other+"/") || strings.HasPrefix(other, key+"/") {
+			return original
+		}
+	}
+	return ""
+}
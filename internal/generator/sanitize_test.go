@@ -0,0 +1,135 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cosmos-link/gen-code/internal/llm"
+)
+
+func TestNormalizePath(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr string
+	}{
+		{"main.go", "main.go", ""},
+		{"  cmd/server/main.go  ", "cmd/server/main.go", ""},
+		{`internal\api\handler.go`, "internal/api/handler.go", ""},
+		{"./pkg//util/../util/strings.go", "pkg/util/strings.go", ""},
+		{".github/workflows/ci.yml", ".github/workflows/ci.yml", ""},
+		{"docs/con.txt.md", "", "reserved device name"},
+		{"", "", "empty path"},
+		{"   ", "", "empty path"},
+		{"src/\x00evil.go", "", "control characters"},
+		{"src/evil\x7f.go", "", "control characters"},
+		{"/etc/passwd", "", "absolute paths"},
+		{`\\server\share\x.go`, "", "absolute paths"},
+		{"C:/Windows/x.go", "", "absolute paths"},
+		{"c:evil.go", "", "absolute paths"},
+		{".", "", "does not name a file"},
+		{"src/..", "", "does not name a file"},
+		{"../outside.go", "", "escapes the project"},
+		{"src/../../outside.go", "", "escapes the project"},
+		{".git/hooks/post-checkout", "", ".git"},
+		{"sub/.GIT/config", "", ".git"},
+		{"--require=x.js", "", "starts with a dash"},
+		{"src/-rf.py", "", "starts with a dash"},
+		{"my-app/main.go", "my-app/main.go", ""},
+		{"notes./readme.md", "", "ends with a dot or space"},
+		{"dir /file.go", "", "ends with a dot or space"},
+		{"NUL", "", "reserved device name"},
+		{"logs/com1.log", "", "reserved device name"},
+		{"Lpt9.txt", "", "reserved device name"},
+		{"console.go", "console.go", ""},
+		{"aux_test.go", "aux_test.go", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := normalizePath(tt.in)
+			if tt.wantErr == "" {
+				if err != nil || got != tt.want {
+					t.Errorf("normalizePath(%q) = %q, %v, want %q", tt.in, got, err, tt.want)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("normalizePath(%q) = %q, %v, want an error containing %q", tt.in, got, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSanitizeFiles(t *testing.T) {
+	file := func(p, content string) llm.FileInfo {
+		return llm.FileInfo{Path: p, Content: content}
+	}
+
+	tests := []struct {
+		name     string
+		policy   FilePolicy
+		files    []llm.FileInfo
+		accepted []string
+		rejected map[string]string // path -> reason substring
+	}{
+		{
+			name:     "clean",
+			files:    []llm.FileInfo{file("main.go", "package main"), file(`pkg\a.go`, "package pkg")},
+			accepted: []string{"main.go", "pkg/a.go"},
+		},
+		{
+			name:     "unsafe path",
+			files:    []llm.FileInfo{file("../evil.sh", ""), file("ok.go", "")},
+			accepted: []string{"ok.go"},
+			rejected: map[string]string{"../evil.sh": "escapes"},
+		},
+		{
+			name:     "case-insensitive duplicate",
+			files:    []llm.FileInfo{file("README.md", "a"), file("readme.md", "b"), file("./README.md", "c")},
+			accepted: []string{"README.md"},
+			rejected: map[string]string{"readme.md": "duplicate of README.md", "./README.md": "duplicate of README.md"},
+		},
+		{
+			name:     "file and directory",
+			files:    []llm.FileInfo{file("config", ""), file("Config/app.yaml", ""), file("docs/a.md", ""), file("docs", "")},
+			accepted: []string{"config", "docs/a.md"},
+			rejected: map[string]string{"Config/app.yaml": "conflicts with config", "docs": "conflicts with docs/a.md"},
+		},
+		{
+			name:     "size limit",
+			policy:   FilePolicy{MaxFileSize: 4},
+			files:    []llm.FileInfo{file("small.txt", "1234"), file("big.txt", "12345")},
+			accepted: []string{"small.txt"},
+			rejected: map[string]string{"big.txt": "5 bytes, exceeding the 4 byte limit"},
+		},
+		{
+			name:     "file limit counts accepted files only",
+			policy:   FilePolicy{MaxFiles: 2},
+			files:    []llm.FileInfo{file("a.go", ""), file("/abs.go", ""), file("b.go", ""), file("c.go", "")},
+			accepted: []string{"a.go", "b.go"},
+			rejected: map[string]string{"/abs.go": "absolute", "c.go": "2 file limit"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accepted, rejections := sanitizeFiles(tt.files, tt.policy)
+
+			var paths []string
+			for _, f := range accepted {
+				paths = append(paths, f.Path)
+			}
+			if strings.Join(paths, ",") != strings.Join(tt.accepted, ",") {
+				t.Errorf("accepted = %v, want %v", paths, tt.accepted)
+			}
+
+			if len(rejections) != len(tt.rejected) {
+				t.Errorf("rejections = %+v, want %d", rejections, len(tt.rejected))
+			}
+			for _, r := range rejections {
+				if want, ok := tt.rejected[r.Path]; !ok || !strings.Contains(r.Reason, want) {
+					t.Errorf("rejection %s: %q, want %q", r.Path, r.Reason, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package generator
+
+import (
+	"fmt"
+
+	"github.com/cosmos-link/gen-code/internal/llm"
+	"github.com/cosmos-link/gen-code/internal/secrets"
+	"github.com/cosmos-link/gen-code/internal/task"
+)
+
+// taskScanner returns the scanner for a task's secrets: the configured
+// rules, or the built-in ones when file scanning is disabled, plus the
+// task's GitHub token
+func (g *Generator) taskScanner(t *task.Task) *secrets.Scanner {
+	scanner := g.secretScanner
+	if scanner == nil {
+		scanner = secrets.NewScanner(secrets.Config{})
+	}
+	if t != nil && t.GitHub != nil && t.GitHub.Token != "" {
+		scanner = scanner.WithLiterals(map[string]string{"github_token": t.GitHub.Token})
+	}
+	return scanner
+}
+
+// redactOutput removes secrets from command output before it is stored on
+// the task
+func (g *Generator) redactOutput(taskID, output string) string {
+	t, _ := g.taskManager.GetTask(taskID)
+	scanner := g.taskScanner(t)
+	return secrets.Redact(output, scanner.Scan("", output))
+}
+
+// scanSecrets scans the project's files for secrets and applies the
+// configured mode. Redacted files are rewritten in place in project; the
+// returned paths list the files whose content changed. An error is returned
+// when the project must not be pushed.
+func (g *Generator) scanSecrets(t *task.Task, project *llm.GeneratedProject) ([]task.SecretFinding, []string, error) {
+	scanner := g.taskScanner(t)
+
+	action := map[secrets.Mode]string{
+		secrets.ModeBlock:  "blocked",
+		secrets.ModeRedact: "redacted",
+		secrets.ModeWarn:   "warned",
+	}[g.secretScanMode]
+
+	var (
+		findings []task.SecretFinding
+		changed  []string
+	)
+	for i, file := range project.Files {
+		fileFindings := scanner.Scan(file.Path, file.Content)
+		if len(fileFindings) == 0 {
+			continue
+		}
+
+		for _, f := range fileFindings {
+			findings = append(findings, task.SecretFinding{
+				Rule:   f.RuleID,
+				Path:   f.Path,
+				Line:   f.Line,
+				Match:  f.Match,
+				Action: action,
+			})
+		}
+
+		if g.secretScanMode == secrets.ModeRedact {
+			project.Files[i].Content = secrets.Redact(file.Content, fileFindings)
+			changed = append(changed, file.Path)
+		}
+	}
+
+	if len(findings) > 0 && g.secretScanMode == secrets.ModeBlock {
+		return findings, nil, fmt.Errorf("found %d potential secret(s) in generated files", len(findings))
+	}
+
+	return findings, changed, nil
+}
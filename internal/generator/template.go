@@ -0,0 +1,101 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cosmos-link/gen-code/internal/llm"
+	"github.com/cosmos-link/gen-code/internal/task"
+	"github.com/cosmos-link/gen-code/internal/templates"
+)
+
+// Templates returns the manifests of the available project templates
+func (g *Generator) Templates() []templates.Manifest {
+	if g.templates == nil {
+		return nil
+	}
+	return g.templates.List()
+}
+
+// ValidateTemplate checks that a template exists and vars satisfy it
+func (g *Generator) ValidateTemplate(name string, vars map[string]string) error {
+	_, _, err := g.resolveTemplate(name, vars)
+	return err
+}
+
+// resolveTemplate looks up a template and resolves its variables
+func (g *Generator) resolveTemplate(name string, vars map[string]string) (*templates.Template, map[string]string, error) {
+	if g.templates == nil {
+		return nil, nil, fmt.Errorf("templates are not configured")
+	}
+	tmpl, ok := g.templates.Get(name)
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown template: %s", name)
+	}
+	resolved, err := tmpl.ResolveVars(vars)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tmpl, resolved, nil
+}
+
+// generateProject asks the LLM for the task's project, starting from its
+// template when one is set
+func (g *Generator) generateProject(ctx context.Context, t *task.Task, llmOpts llm.CallOptions) (*llm.GeneratedProject, error) {
+	if t.Template == "" {
+		return g.llmClient.GenerateProject(ctx, t.Prompt, llmOpts)
+	}
+
+	tmpl, vars, err := g.resolveTemplate(t.Template, t.TemplateVars)
+	if err != nil {
+		return nil, err
+	}
+	files, err := tmpl.Render(vars)
+	if err != nil {
+		return nil, err
+	}
+
+	revised, err := g.llmClient.ReviseProject(ctx, tmpl.Instruction(t.Prompt, vars), files, llmOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	project := &llm.GeneratedProject{
+		Name:        revised.Name,
+		Description: revised.Description,
+		Files:       files,
+	}
+	if project.Description == "" {
+		project.Description = tmpl.Description
+	}
+
+	// Template files are copied verbatim; the LLM may only fill
+	// placeholders and add files
+	rendered := make(map[string]bool, len(files))
+	for _, file := range files {
+		rendered[strings.ToLower(file.Path)] = true
+	}
+
+	var accepted []llm.FileInfo
+	var rejections []task.FileRejection
+	for _, file := range revised.Files {
+		if rendered[strings.ToLower(file.Path)] && !tmpl.IsPlaceholder(file.Path, vars) {
+			rejections = append(rejections, task.FileRejection{Path: file.Path, Reason: "modifies a template file that is not a placeholder"})
+			continue
+		}
+		accepted = append(accepted, file)
+	}
+	if len(rejections) > 0 {
+		g.taskManager.AddTaskRejections(t.ID, rejections)
+	}
+
+	changed := mergeFiles(project, accepted)
+	g.taskManager.AddTaskEvent(t.ID, task.Event{
+		Type:    "template",
+		Message: fmt.Sprintf("Rendered template %s with %d file(s); the LLM filled or added %d", tmpl.Name, len(files), len(changed)),
+		Files:   changed,
+	})
+
+	return project, nil
+}
@@ -0,0 +1,166 @@
+package generator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/cosmos-link/gen-code/internal/llm"
+	"github.com/cosmos-link/gen-code/internal/sandbox"
+	"github.com/cosmos-link/gen-code/internal/task"
+	"github.com/cosmos-link/gen-code/internal/validate"
+)
+
+// maxTestFeedback is how much failing test output is fed back to the LLM
+const maxTestFeedback = 4000
+
+// TestConfig holds settings for running generated tests in a sandbox
+type TestConfig struct {
+	Runner sandbox.Runner    // Nil disables test execution
+	Limits sandbox.Limits    // Resource limits for each test command
+	Images map[string]string // Container image per language, for container runners
+
+	// GoModCache is mounted read-only as GOMODCACHE so that Go tests resolve
+	// dependencies offline, like the Go validator
+	GoModCache string
+}
+
+// testPlan is a test command for one language
+type testPlan struct {
+	Language string
+	Command  []string
+	Env      []string
+	ReadOnly []string // Host paths the command reads, such as a module cache
+}
+
+// testPlans returns the test commands that apply to the project. Go tests
+// resolve modules from goModCache when it is set.
+func testPlans(project *llm.GeneratedProject, goModCache string) []testPlan {
+	var (
+		plans                           []testPlan
+		goTests, pyTests, hasTestScript bool
+	)
+
+	for _, file := range project.Files {
+		base := path.Base(file.Path)
+		switch {
+		case strings.HasSuffix(base, "_test.go"):
+			goTests = true
+		case strings.HasSuffix(base, ".py") && (strings.HasPrefix(base, "test_") || strings.HasSuffix(base, "_test.py")):
+			pyTests = true
+		case file.Path == "package.json":
+			var pkg struct {
+				Scripts map[string]string `json:"scripts"`
+			}
+			if json.Unmarshal([]byte(file.Content), &pkg) == nil && pkg.Scripts["test"] != "" {
+				hasTestScript = true
+			}
+		}
+	}
+
+	if goTests {
+		plan := testPlan{
+			Language: llm.LanguageGo,
+			Command:  []string{"go", "test", "./..."},
+			Env:      []string{"GOFLAGS=-mod=mod", "GOPROXY=off", "GOSUMDB=off", "GOCACHE=/tmp/go-build", "GOTOOLCHAIN=local"},
+		}
+		if goModCache != "" {
+			plan.Env = append(plan.Env, "GOMODCACHE="+goModCache)
+			plan.ReadOnly = []string{goModCache}
+		}
+		plans = append(plans, plan)
+	}
+	if pyTests {
+		plans = append(plans, testPlan{
+			Language: llm.LanguagePython,
+			Command:  []string{"python3", "-m", "pytest", "-q"},
+			Env:      []string{"PYTHONDONTWRITEBYTECODE=1"},
+		})
+	}
+	if hasTestScript {
+		plans = append(plans, testPlan{
+			Language: llm.LanguageJavaScript,
+			Command:  []string{"npm", "test", "--silent"},
+			Env:      []string{"CI=true", "npm_config_cache=/tmp/npm"},
+		})
+	}
+
+	return plans
+}
+
+// runTests runs the project's tests in the sandbox, recording the results
+// on the task and returning a diagnostic for each failing suite
+func (g *Generator) runTests(ctx context.Context, taskID string, project *llm.GeneratedProject, dir string) ([]validate.Diagnostic, error) {
+	plans := testPlans(project, g.tests.GoModCache)
+	if len(plans) == 0 {
+		return nil, nil
+	}
+
+	g.taskManager.UpdateTask(taskID, task.StatusTesting, fmt.Sprintf("Running tests in %s sandbox...", g.tests.Runner.Name()))
+
+	var (
+		results []task.TestResult
+		diags   []validate.Diagnostic
+	)
+	for _, plan := range plans {
+		res, err := g.tests.Runner.Run(ctx, sandbox.Spec{
+			Dir:      dir,
+			Command:  plan.Command,
+			Env:      plan.Env,
+			ReadOnly: plan.ReadOnly,
+			Image:    g.tests.Images[plan.Language],
+			Limits:   g.tests.Limits,
+		})
+		if errors.Is(err, sandbox.ErrUnavailable) {
+			g.taskManager.AddTaskEvent(taskID, task.Event{Type: "tests_skipped", Message: err.Error()})
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		// Tests can print environment or fixture secrets; redact them before
+		// the output is stored or sent to the LLM
+		res.Output = g.redactOutput(taskID, res.Output)
+
+		command := strings.Join(plan.Command, " ")
+		results = append(results, task.TestResult{
+			Language:   plan.Language,
+			Runner:     g.tests.Runner.Name(),
+			Command:    command,
+			ExitCode:   res.ExitCode,
+			Passed:     res.Passed(),
+			TimedOut:   res.TimedOut,
+			DurationMs: res.Duration.Milliseconds(),
+			Output:     res.Output,
+			Truncated:  res.Truncated,
+		})
+
+		if !res.Passed() {
+			diags = append(diags, validate.Diagnostic{
+				Tool:     command,
+				Severity: validate.SeverityError,
+				Path:     ".",
+				Message:  testFailureMessage(res),
+			})
+		}
+	}
+
+	g.taskManager.SetTaskTestResults(taskID, results)
+	return diags, nil
+}
+
+// testFailureMessage summarizes a failed test run for the fix-up prompt
+func testFailureMessage(res *sandbox.Result) string {
+	if res.TimedOut {
+		return fmt.Sprintf("tests timed out after %s", res.Duration.Round(1e9))
+	}
+
+	output := res.Output
+	if len(output) > maxTestFeedback {
+		output = "..." + output[len(output)-maxTestFeedback:]
+	}
+	return fmt.Sprintf("tests failed with exit code %d:\n%s", res.ExitCode, output)
+}
@@ -0,0 +1,106 @@
+package generator
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cosmos-link/gen-code/internal/llm"
+	"github.com/cosmos-link/gen-code/internal/task"
+	"github.com/cosmos-link/gen-code/internal/usage"
+)
+
+// UsageByKey aggregates LLM usage in [from, to) by provider and API key
+func (g *Generator) UsageByKey(from, to time.Time) []usage.Summary {
+	if g.usage == nil {
+		return []usage.Summary{}
+	}
+	return g.usage.ByKey(from, to)
+}
+
+// UsageByDay aggregates LLM usage in [from, to) by UTC day
+func (g *Generator) UsageByDay(from, to time.Time) []usage.Summary {
+	if g.usage == nil {
+		return []usage.Summary{}
+	}
+	return g.usage.ByDay(from, to)
+}
+
+// taskUsage attributes LLM calls to a task, prices them and enforces the
+// task's token limit
+type taskUsage struct {
+	taskManager *task.Manager
+	ledger      *usage.Ledger
+	taskID      string
+	limit       int
+	used        int
+}
+
+// usageTracker returns the tracker for a task's LLM calls
+func (g *Generator) usageTracker(t *task.Task) *taskUsage {
+	return &taskUsage{
+		taskManager: g.taskManager,
+		ledger:      g.usage,
+		taskID:      t.ID,
+		limit:       t.TokenLimit,
+		used:        t.TokensUsed,
+	}
+}
+
+// Allow refuses further calls once the task has reached its token limit
+func (u *taskUsage) Allow() error {
+	if u.limit > 0 && u.used >= u.limit {
+		return fmt.Errorf("%w: task used %d of %d tokens", llm.ErrTokenLimit, u.used, u.limit)
+	}
+	return nil
+}
+
+// Record stores a call on the task and in the ledger, failing when it took
+// the task over its token limit. Cache hits cost nothing and are kept out
+// of the ledger.
+func (u *taskUsage) Record(call llm.Usage) error {
+	var (
+		cost   float64
+		priced bool
+	)
+	if u.ledger != nil && !call.Cached {
+		cost, priced = u.ledger.Price(call.Model, call.PromptTokens, call.CompletionTokens)
+		u.ledger.Add(usage.Record{
+			TaskID:           u.taskID,
+			Provider:         call.Provider,
+			Model:            call.Model,
+			KeyID:            call.KeyID,
+			Operation:        call.Operation,
+			PromptTokens:     call.PromptTokens,
+			CompletionTokens: call.CompletionTokens,
+			TotalTokens:      call.TotalTokens,
+			Cost:             cost,
+			Time:             call.Time,
+		})
+	}
+
+	used, err := u.taskManager.AddTaskUsage(u.taskID, task.UsageRecord{
+		Provider:         call.Provider,
+		Model:            call.Model,
+		KeyID:            call.KeyID,
+		Operation:        call.Operation,
+		Temperature:      call.Temperature,
+		MaxTokens:        call.MaxTokens,
+		PromptTokens:     call.PromptTokens,
+		CompletionTokens: call.CompletionTokens,
+		TotalTokens:      call.TotalTokens,
+		LatencyMs:        call.Latency.Milliseconds(),
+		Cost:             cost,
+		Priced:           priced,
+		Cached:           call.Cached,
+		Time:             call.Time,
+	})
+	if err != nil {
+		return err
+	}
+	u.used = used
+
+	if u.limit > 0 && u.used > u.limit {
+		return fmt.Errorf("%w: task used %d of %d tokens", llm.ErrTokenLimit, u.used, u.limit)
+	}
+	return nil
+}
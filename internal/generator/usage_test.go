@@ -0,0 +1,82 @@
+package generator
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/cosmos-link/gen-code/internal/llm"
+	"github.com/cosmos-link/gen-code/internal/task"
+	"github.com/cosmos-link/gen-code/internal/usage"
+)
+
+func TestTaskUsageLimit(t *testing.T) {
+	call := func(tokens int, cached bool) llm.Usage {
+		return llm.Usage{Provider: "openai", Model: "gpt-4o", KeyID: "sk-...", PromptTokens: tokens, TotalTokens: tokens, Cached: cached, Time: time.Now()}
+	}
+
+	tests := []struct {
+		name       string
+		limit      int
+		calls      []llm.Usage
+		wantRecord []bool // Whether each Record fails with ErrTokenLimit
+		wantAllow  bool   // Whether further calls are allowed afterwards
+		wantUsed   int
+	}{
+		{"no limit", 0, []llm.Usage{call(500, false), call(500, false)}, []bool{false, false}, true, 1000},
+		{"under the limit", 100, []llm.Usage{call(40, false), call(40, false)}, []bool{false, false}, true, 80},
+		{"reaching the limit", 100, []llm.Usage{call(60, false), call(40, false)}, []bool{false, false}, false, 100},
+		{"crossing the limit", 100, []llm.Usage{call(60, false), call(50, false)}, []bool{false, true}, false, 110},
+		{"cache hits are free", 100, []llm.Usage{call(90, false), call(90, true)}, []bool{false, false}, true, 90},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := task.NewManager(1)
+			tk := manager.CreateTask("prompt", "repo", "", "", task.Options{TokenLimit: tt.limit})
+			ledger := usage.NewLedger(usage.DefaultPrices())
+			g := &Generator{taskManager: manager, usage: ledger}
+
+			tracker := g.usageTracker(tk)
+			for i, c := range tt.calls {
+				if err := tracker.Allow(); err != nil {
+					t.Fatalf("call %d not allowed: %v", i, err)
+				}
+				err := tracker.Record(c)
+				if errors.Is(err, llm.ErrTokenLimit) != tt.wantRecord[i] {
+					t.Errorf("Record %d err = %v, want token limit error %v", i, err, tt.wantRecord[i])
+				}
+			}
+
+			if err := tracker.Allow(); (err == nil) != tt.wantAllow {
+				t.Errorf("Allow = %v, want allowed %v", err, tt.wantAllow)
+			} else if err != nil && !errors.Is(err, llm.ErrTokenLimit) {
+				t.Errorf("Allow err = %v, want ErrTokenLimit", err)
+			}
+			if tk.TokensUsed != tt.wantUsed {
+				t.Errorf("TokensUsed = %d, want %d", tk.TokensUsed, tt.wantUsed)
+			}
+
+			// The ledger sees only calls that spent tokens
+			summaries := ledger.ByKey(time.Time{}, time.Time{})
+			if len(summaries) != 1 || summaries[0].TotalTokens != tt.wantUsed {
+				t.Errorf("ledger = %+v, want %d tokens", summaries, tt.wantUsed)
+			}
+			if want := float64(tt.wantUsed) * 2.5 / 1e6; math.Abs(tk.Cost-want) > 1e-12 {
+				t.Errorf("task cost = %v, want %v", tk.Cost, want)
+			}
+		})
+	}
+}
+
+func TestTaskUsageResumesFromTask(t *testing.T) {
+	manager := task.NewManager(1)
+	tk := manager.CreateTask("prompt", "repo", "", "", task.Options{TokenLimit: 100})
+	tk.TokensUsed = 100
+
+	// A tracker for a task that already spent its budget refuses at once
+	g := &Generator{taskManager: manager}
+	if err := g.usageTracker(tk).Allow(); !errors.Is(err, llm.ErrTokenLimit) {
+		t.Errorf("Allow = %v, want ErrTokenLimit", err)
+	}
+}
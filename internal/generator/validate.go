@@ -0,0 +1,72 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/cosmos-link/gen-code/internal/llm"
+	"github.com/cosmos-link/gen-code/internal/task"
+	"github.com/cosmos-link/gen-code/internal/validate"
+)
+
+// validateProject runs the validators registered for the project's file
+// types against the files written to dir and, once the project compiles,
+// its tests in the sandbox
+func (g *Generator) validateProject(ctx context.Context, taskID string, project *llm.GeneratedProject, dir string) (validate.Report, error) {
+	report := make(validate.Report)
+
+	if g.validators != nil {
+		files := make([]validate.File, 0, len(project.Files))
+		for _, file := range project.Files {
+			files = append(files, validate.File{Path: file.Path, Type: file.Type})
+		}
+
+		var skipped []string
+		var err error
+		report, skipped, err = g.validators.Validate(ctx, dir, files)
+		if err != nil {
+			return nil, err
+		}
+		if len(skipped) > 0 {
+			log.Printf("Task %s: skipped validators with no toolchain: %s", taskID, strings.Join(skipped, ", "))
+			g.taskManager.AddTaskEvent(taskID, task.Event{
+				Type:    "validators_skipped",
+				Message: fmt.Sprintf("Toolchain not installed for: %s", strings.Join(skipped, ", ")),
+			})
+		}
+	}
+
+	// Tests are only meaningful for code that builds
+	if g.tests.Runner != nil && report.Errors() == 0 {
+		diags, err := g.runTests(ctx, taskID, project, dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to run tests: %w", err)
+		}
+		report.Add(diags...)
+	}
+
+	return report, nil
+}
+
+// taskDiagnostics converts a validation report to its task representation
+func taskDiagnostics(report validate.Report) map[string][]task.Diagnostic {
+	if len(report) == 0 {
+		return nil
+	}
+
+	diagnostics := make(map[string][]task.Diagnostic, len(report))
+	for path, diags := range report {
+		for _, d := range diags {
+			diagnostics[path] = append(diagnostics[path], task.Diagnostic{
+				Tool:     d.Tool,
+				Severity: d.Severity,
+				Line:     d.Line,
+				Column:   d.Column,
+				Message:  d.Message,
+			})
+		}
+	}
+	return diagnostics
+}
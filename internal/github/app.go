@@ -0,0 +1,251 @@
+package github
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	// DefaultAPIURL is the public GitHub REST API endpoint
+	DefaultAPIURL = "https://api.github.com/"
+
+	// appJWTLifetime is how long an app JWT is valid; GitHub allows at most 10 minutes
+	appJWTLifetime = 9 * time.Minute
+
+	// tokenRefreshWindow is how long before expiry an installation token is renewed
+	tokenRefreshWindow = 5 * time.Minute
+
+	// tokenExchangeTimeout bounds an installation token request, since
+	// oauth2.TokenSource gives Token no context of its caller
+	tokenExchangeTimeout = 30 * time.Second
+)
+
+// AppConfig holds GitHub App settings
+type AppConfig struct {
+	AppID          int64
+	PrivateKeyPath string
+	APIURL         string       // Defaults to DefaultAPIURL
+	HTTPClient     *http.Client // Defaults to http.DefaultClient
+
+	// OnToken, when set, is called with each installation token minted,
+	// e.g. to scrub it from recorded exchanges
+	OnToken func(token string)
+}
+
+// App authenticates as a GitHub App and mints installation access tokens
+type App struct {
+	appID      int64
+	key        *rsa.PrivateKey
+	apiURL     string
+	httpClient *http.Client
+	onToken    func(token string)
+
+	mu      sync.Mutex
+	sources map[int64]*installationTokenSource
+}
+
+// NewApp creates a GitHub App authenticator from a PEM private key file
+func NewApp(cfg AppConfig) (*App, error) {
+	if cfg.AppID == 0 {
+		return nil, fmt.Errorf("GitHub App ID is required")
+	}
+
+	data, err := os.ReadFile(cfg.PrivateKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read GitHub App private key: %w", err)
+	}
+
+	key, err := parsePrivateKey(data)
+	if err != nil {
+		return nil, err
+	}
+
+	apiURL := cfg.APIURL
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+	if !strings.HasSuffix(apiURL, "/") {
+		apiURL += "/"
+	}
+
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	return &App{
+		appID:      cfg.AppID,
+		key:        key,
+		apiURL:     apiURL,
+		httpClient: httpClient,
+		onToken:    cfg.OnToken,
+		sources:    make(map[int64]*installationTokenSource),
+	}, nil
+}
+
+// TokenSource returns a cached, self-refreshing token source for an installation
+func (a *App) TokenSource(installationID int64) oauth2.TokenSource {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	src, ok := a.sources[installationID]
+	if !ok {
+		src = &installationTokenSource{app: a, installationID: installationID}
+		a.sources[installationID] = src
+	}
+	return src
+}
+
+// JWT returns a signed app JWT valid from now
+func (a *App) JWT(now time.Time) (string, error) {
+	header := map[string]string{"alg": "RS256", "typ": "JWT"}
+	claims := map[string]interface{}{
+		// Backdate to tolerate clock drift between us and GitHub
+		"iat": now.Add(-60 * time.Second).Unix(),
+		"exp": now.Add(appJWTLifetime).Unix(),
+		"iss": strconv.FormatInt(a.appID, 10),
+	}
+
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(headerJSON) + "." +
+		base64.RawURLEncoding.EncodeToString(claimsJSON)
+
+	digest := sha256.Sum256([]byte(signingInput))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, a.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign GitHub App JWT: %w", err)
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// exchange trades an app JWT for an installation access token
+func (a *App) exchange(ctx context.Context, installationID int64) (*oauth2.Token, error) {
+	jwt, err := a.JWT(time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%sapp/installations/%d/access_tokens", a.apiURL, installationID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request installation token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read installation token response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("failed to request installation token for installation %d: %s: %s",
+			installationID, resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var payload struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("failed to parse installation token response: %w", err)
+	}
+	if payload.Token == "" {
+		return nil, fmt.Errorf("installation token response did not contain a token")
+	}
+	if a.onToken != nil {
+		a.onToken(payload.Token)
+	}
+
+	return &oauth2.Token{
+		AccessToken: payload.Token,
+		TokenType:   "token",
+		Expiry:      payload.ExpiresAt,
+	}, nil
+}
+
+// installationTokenSource caches an installation token and renews it
+// shortly before it expires
+type installationTokenSource struct {
+	app            *App
+	installationID int64
+
+	mu    sync.Mutex
+	token *oauth2.Token
+}
+
+// Token returns a valid installation token, exchanging a new one if needed
+func (s *installationTokenSource) Token() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != nil && time.Until(s.token.Expiry) > tokenRefreshWindow {
+		return s.token, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), tokenExchangeTimeout)
+	defer cancel()
+
+	token, err := s.app.exchange(ctx, s.installationID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.token = token
+	return token, nil
+}
+
+// parsePrivateKey parses a PKCS#1 or PKCS#8 PEM-encoded RSA private key
+func parsePrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("GitHub App private key is not PEM encoded")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse GitHub App private key: %w", err)
+	}
+
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("GitHub App private key is not an RSA key")
+	}
+	return key, nil
+}
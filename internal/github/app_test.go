@@ -0,0 +1,171 @@
+package github
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestApp creates an App with a fresh RSA key whose API is served by
+// handler
+func newTestApp(t *testing.T, handler http.Handler) (*App, *rsa.PublicKey) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "app.pem")
+	pemData := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	if err := os.WriteFile(path, pemData, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	app, err := NewApp(AppConfig{AppID: 1234, PrivateKeyPath: path, APIURL: srv.URL, HTTPClient: srv.Client()})
+	if err != nil {
+		t.Fatalf("NewApp: %v", err)
+	}
+	return app, &key.PublicKey
+}
+
+// verifyJWT checks an RS256 JWT against pub and returns its header and claims
+func verifyJWT(jwt string, pub *rsa.PublicKey) (map[string]string, map[string]any, error) {
+	parts := strings.Split(jwt, ".")
+	if len(parts) != 3 {
+		return nil, nil, fmt.Errorf("JWT has %d parts, want 3", len(parts))
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decode signature: %w", err)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig); err != nil {
+		return nil, nil, fmt.Errorf("signature does not verify: %w", err)
+	}
+
+	var header map[string]string
+	var claims map[string]any
+	for i, v := range []any{&header, &claims} {
+		data, err := base64.RawURLEncoding.DecodeString(parts[i])
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to decode part %d: %w", i, err)
+		}
+		if err := json.Unmarshal(data, v); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse part %d: %w", i, err)
+		}
+	}
+	return header, claims, nil
+}
+
+func TestAppJWT(t *testing.T) {
+	app, pub := newTestApp(t, http.NotFoundHandler())
+
+	now := time.Unix(1700000000, 0)
+	jwt, err := app.JWT(now)
+	if err != nil {
+		t.Fatalf("JWT: %v", err)
+	}
+
+	header, claims, err := verifyJWT(jwt, pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header["alg"] != "RS256" || header["typ"] != "JWT" {
+		t.Errorf("header = %v", header)
+	}
+	if claims["iss"] != "1234" {
+		t.Errorf("iss = %v, want the app ID as a string", claims["iss"])
+	}
+	if iat := int64(claims["iat"].(float64)); iat != now.Unix()-60 {
+		t.Errorf("iat = %d, want 60s before now", iat)
+	}
+	if exp := int64(claims["exp"].(float64)); exp != now.Add(appJWTLifetime).Unix() || exp-now.Unix() > 600 {
+		t.Errorf("exp = %d, want %v after now and within GitHub's 10 minute limit", exp, appJWTLifetime)
+	}
+}
+
+func TestAppInstallationTokenExchange(t *testing.T) {
+	var (
+		pub      *rsa.PublicKey
+		requests atomic.Int32
+	)
+	app, pub := newTestApp(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := requests.Add(1)
+		if r.Method != http.MethodPost || r.URL.Path != "/app/installations/42/access_tokens" {
+			http.Error(w, "unexpected request "+r.Method+" "+r.URL.Path, http.StatusNotFound)
+			return
+		}
+		jwt, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok {
+			http.Error(w, "missing JWT", http.StatusUnauthorized)
+			return
+		}
+		if _, _, err := verifyJWT(jwt, pub); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"token": "ghs_token%d", "expires_at": %q}`, n, time.Now().Add(time.Hour).Format(time.RFC3339))
+	}))
+
+	src := app.TokenSource(42).(*installationTokenSource)
+	if app.TokenSource(42) != src {
+		t.Error("TokenSource does not reuse the installation's source")
+	}
+
+	token, err := src.Token()
+	if err != nil {
+		t.Fatalf("Token: %v", err)
+	}
+	if token.AccessToken != "ghs_token1" || time.Until(token.Expiry) < 59*time.Minute {
+		t.Errorf("token = %q expiring %v", token.AccessToken, token.Expiry)
+	}
+
+	// A cached token is reused while it is outside the refresh window
+	if token, err = src.Token(); err != nil || token.AccessToken != "ghs_token1" || requests.Load() != 1 {
+		t.Errorf("second Token() = %v, %v after %d requests, want the cached token", token, err, requests.Load())
+	}
+
+	src.token.Expiry = time.Now().Add(tokenRefreshWindow + time.Minute)
+	if token, _ = src.Token(); token.AccessToken != "ghs_token1" {
+		t.Errorf("token renewed %v before expiry, want it kept", tokenRefreshWindow+time.Minute)
+	}
+
+	src.token.Expiry = time.Now().Add(tokenRefreshWindow - time.Minute)
+	if token, err = src.Token(); err != nil || token.AccessToken != "ghs_token2" {
+		t.Errorf("token within the refresh window = %v, %v, want a new token", token, err)
+	}
+}
+
+func TestAppInstallationTokenError(t *testing.T) {
+	app, _ := newTestApp(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message": "Integration not found"}`, http.StatusNotFound)
+	}))
+
+	_, err := app.TokenSource(7).Token()
+	if err == nil || !strings.Contains(err.Error(), "installation 7") || !strings.Contains(err.Error(), "Integration not found") {
+		t.Fatalf("err = %v, want the installation and GitHub's message", err)
+	}
+	if app.sources[7].token != nil {
+		t.Error("a failed exchange must not be cached")
+	}
+}
@@ -3,13 +3,18 @@ package github
 import (
 	"context"
 	"fmt"
+	nethttp "net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
+	gitclient "github.com/go-git/go-git/v5/plumbing/transport/client"
 	"github.com/go-git/go-git/v5/plumbing/transport/http"
 	"github.com/google/go-github/v57/github"
 	"golang.org/x/oauth2"
@@ -18,23 +23,55 @@ import (
 // Client handles GitHub operations
 type Client struct {
 	client *github.Client
-	token  string
+	tokens oauth2.TokenSource
 	owner  string
 }
 
 // NewClient creates a new GitHub client
 func NewClient(token, owner string) *Client {
-	ctx := context.Background()
 	ts := oauth2.StaticTokenSource(
 		&oauth2.Token{AccessToken: token},
 	)
+	client, _ := NewClientWithTokenSource(ts, owner, "", nil)
+	return client
+}
+
+// InstallGitTransport sends the Git smart HTTP traffic of clones and pushes
+// through httpClient, like the API requests of clients created with it.
+// go-git keeps one transport per protocol, so this applies process-wide.
+func InstallGitTransport(httpClient *nethttp.Client) {
+	transport := http.NewClient(httpClient)
+	gitclient.InstallProtocol("https", transport)
+	gitclient.InstallProtocol("http", transport)
+}
+
+// NewClientWithTokenSource creates a GitHub client whose tokens come from ts,
+// such as a GitHub App installation. apiURL defaults to DefaultAPIURL and
+// httpClient, which sends the authenticated requests, to the default client.
+func NewClientWithTokenSource(ts oauth2.TokenSource, owner, apiURL string, httpClient *nethttp.Client) (*Client, error) {
+	ctx := context.Background()
+	if httpClient != nil {
+		ctx = context.WithValue(ctx, oauth2.HTTPClient, httpClient)
+	}
 	tc := oauth2.NewClient(ctx, ts)
 
+	gh := github.NewClient(tc)
+	if apiURL != "" && apiURL != DefaultAPIURL {
+		if !strings.HasSuffix(apiURL, "/") {
+			apiURL += "/"
+		}
+		baseURL, err := url.Parse(apiURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid GitHub API URL: %w", err)
+		}
+		gh.BaseURL = baseURL
+	}
+
 	return &Client{
-		client: github.NewClient(tc),
-		token:  token,
+		client: gh,
+		tokens: ts,
 		owner:  owner,
-	}
+	}, nil
 }
 
 // CreateRepository creates a new GitHub repository
@@ -67,8 +104,127 @@ func (c *Client) CreateRepository(ctx context.Context, name, description string,
 	return createdRepo, nil
 }
 
-// PushFiles pushes files to a GitHub repository
-func (c *Client) PushFiles(ctx context.Context, repoURL, localPath, commitMessage string) error {
+// CreateIssue opens an issue on a repository, creating any missing labels
+func (c *Client) CreateIssue(ctx context.Context, owner, repoName, title, body string, labels []string) (*github.Issue, error) {
+	for _, label := range labels {
+		_, resp, err := c.client.Issues.GetLabel(ctx, owner, repoName, label)
+		if err == nil {
+			continue
+		}
+		if resp == nil || resp.StatusCode != 404 {
+			return nil, fmt.Errorf("failed to look up label %s: %w", label, err)
+		}
+		if _, _, err := c.client.Issues.CreateLabel(ctx, owner, repoName, &github.Label{
+			Name:  github.String(label),
+			Color: github.String("d93f0b"),
+		}); err != nil {
+			return nil, fmt.Errorf("failed to create label %s: %w", label, err)
+		}
+	}
+
+	issue, _, err := c.client.Issues.Create(ctx, owner, repoName, &github.IssueRequest{
+		Title:  github.String(title),
+		Body:   github.String(body),
+		Labels: &labels,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create issue: %w", err)
+	}
+
+	return issue, nil
+}
+
+// Identity is a git author or committer
+type Identity struct {
+	Name  string
+	Email string
+}
+
+// String formats the identity as "Name <email>"
+func (i Identity) String() string {
+	return fmt.Sprintf("%s <%s>", i.Name, i.Email)
+}
+
+// CommitOptions controls authorship and signing of pushed commits
+type CommitOptions struct {
+	Author    Identity
+	Committer Identity   // Defaults to Author when empty
+	CoAuthors []Identity // Added as Co-authored-by trailers
+	Signer    git.Signer // Optional; nil leaves commits unsigned
+	When      time.Time  // Optional fixed commit time; zero uses the current time
+}
+
+// message appends Co-authored-by trailers to a commit message
+func (o CommitOptions) message(commitMessage string) string {
+	if len(o.CoAuthors) == 0 {
+		return commitMessage
+	}
+
+	var b strings.Builder
+	b.WriteString(strings.TrimRight(commitMessage, "\n"))
+	b.WriteString("\n\n")
+	for _, coAuthor := range o.CoAuthors {
+		b.WriteString("Co-authored-by: ")
+		b.WriteString(coAuthor.String())
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// gitOptions converts the options to go-git commit options
+func (o CommitOptions) gitOptions() *git.CommitOptions {
+	now := o.When
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	committer := o.Committer
+	if committer.Name == "" || committer.Email == "" {
+		committer = o.Author
+	}
+
+	return &git.CommitOptions{
+		Author: &object.Signature{
+			Name:  o.Author.Name,
+			Email: o.Author.Email,
+			When:  now,
+		},
+		Committer: &object.Signature{
+			Name:  committer.Name,
+			Email: committer.Email,
+			When:  now,
+		},
+		Signer: o.Signer,
+	}
+}
+
+// AuthenticatedIdentity returns the commit identity of the token's user,
+// using the GitHub noreply address so commits attribute to the account
+func (c *Client) AuthenticatedIdentity(ctx context.Context) (Identity, error) {
+	user, _, err := c.client.Users.Get(ctx, "")
+	if err != nil {
+		return Identity{}, fmt.Errorf("failed to get authenticated user: %w", err)
+	}
+
+	name := user.GetName()
+	if name == "" {
+		name = user.GetLogin()
+	}
+
+	return Identity{
+		Name:  name,
+		Email: fmt.Sprintf("%d+%s@users.noreply.github.com", user.GetID(), user.GetLogin()),
+	}, nil
+}
+
+// Commit is one commit in a pushed history
+type Commit struct {
+	Message string
+	Paths   []string // Paths relative to the repository root to stage, including deletions
+}
+
+// PushCommits records the given commits in order and pushes them in one go
+func (c *Client) PushCommits(ctx context.Context, repoURL, localPath string, commits []Commit, opts CommitOptions) error {
 	// Clone or init the repository
 	repo, err := git.PlainInit(localPath, false)
 	if err != nil {
@@ -90,31 +246,48 @@ func (c *Client) PushFiles(ctx context.Context, repoURL, localPath, commitMessag
 		return fmt.Errorf("failed to get worktree: %w", err)
 	}
 
-	// Add all files
-	err = w.AddGlob(".")
-	if err != nil {
-		return fmt.Errorf("failed to add files: %w", err)
+	for _, commit := range commits {
+		if err := stage(w, commit.Paths); err != nil {
+			return err
+		}
+
+		// Commit
+		_, err = w.Commit(opts.message(commit.Message), opts.gitOptions())
+		if err != nil {
+			return fmt.Errorf("failed to commit: %w", err)
+		}
 	}
 
-	// Commit
-	_, err = w.Commit(commitMessage, &git.CommitOptions{
-		Author: &object.Signature{
-			Name:  "Gen Code Bot",
-			Email: "bot@gencode.dev",
-			When:  time.Now(),
-		},
-	})
+	return c.push(ctx, repo)
+}
+
+// stage adds the listed paths to the index; paths missing from the
+// worktree are staged as deletions. Nothing else in the directory is
+// staged, so files left behind by tools never reach a commit.
+func stage(w *git.Worktree, paths []string) error {
+	if len(paths) == 0 {
+		return fmt.Errorf("failed to add files: commit lists no paths")
+	}
+	for _, path := range paths {
+		if _, err := w.Add(filepath.ToSlash(path)); err != nil {
+			return fmt.Errorf("failed to add files: %w", err)
+		}
+	}
+	return nil
+}
+
+// push pushes the repository's branches to origin
+func (c *Client) push(ctx context.Context, repo *git.Repository, refSpecs ...config.RefSpec) error {
+	auth, err := c.auth()
 	if err != nil {
-		return fmt.Errorf("failed to commit: %w", err)
+		return err
 	}
 
 	// Push
-	err = repo.Push(&git.PushOptions{
+	err = repo.PushContext(ctx, &git.PushOptions{
 		RemoteName: "origin",
-		Auth: &http.BasicAuth{
-			Username: "git",
-			Password: c.token,
-		},
+		RefSpecs:   refSpecs,
+		Auth:       auth,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to push: %w", err)
@@ -123,11 +296,123 @@ func (c *Client) PushFiles(ctx context.Context, repoURL, localPath, commitMessag
 	return nil
 }
 
-// WriteFilesToDirectory writes files to a local directory
+// auth returns git credentials for the client's current token
+func (c *Client) auth() (*http.BasicAuth, error) {
+	// Fetch a current token; installation tokens may have been renewed
+	token, err := c.tokens.Token()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get GitHub token: %w", err)
+	}
+
+	return &http.BasicAuth{
+		Username: "x-access-token",
+		Password: token.AccessToken,
+	}, nil
+}
+
+// CloneRepository clones a repository into localPath, checking out branch
+// or the default branch when branch is empty. It returns the name of the
+// checked out branch.
+func (c *Client) CloneRepository(ctx context.Context, repoURL, localPath, branch string) (string, error) {
+	auth, err := c.auth()
+	if err != nil {
+		return "", err
+	}
+
+	opts := &git.CloneOptions{
+		URL:  repoURL,
+		Auth: auth,
+	}
+	if branch != "" {
+		opts.ReferenceName = plumbing.NewBranchReferenceName(branch)
+		opts.SingleBranch = true
+	}
+
+	repo, err := git.PlainCloneContext(ctx, localPath, false, opts)
+	if err != nil {
+		return "", fmt.Errorf("failed to clone repository: %w", err)
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve HEAD: %w", err)
+	}
+	return head.Name().Short(), nil
+}
+
+// PushChanges commits the changes in a repository cloned by CloneRepository
+// and pushes them to branch, which is created from the checked out branch
+// if it does not exist yet.
+func (c *Client) PushChanges(ctx context.Context, localPath, branch string, commits []Commit, opts CommitOptions) error {
+	repo, err := git.PlainOpen(localPath)
+	if err != nil {
+		return fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	w, err := repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to get worktree: %w", err)
+	}
+
+	ref := plumbing.NewBranchReferenceName(branch)
+	head, err := repo.Head()
+	if err != nil {
+		return fmt.Errorf("failed to resolve HEAD: %w", err)
+	}
+	if head.Name() != ref {
+		if err := w.Checkout(&git.CheckoutOptions{Branch: ref, Create: true, Keep: true}); err != nil {
+			return fmt.Errorf("failed to create branch %s: %w", branch, err)
+		}
+	}
+
+	for _, commit := range commits {
+		if err := stage(w, commit.Paths); err != nil {
+			return err
+		}
+
+		// Commit
+		_, err = w.Commit(opts.message(commit.Message), opts.gitOptions())
+		if err != nil {
+			return fmt.Errorf("failed to commit: %w", err)
+		}
+	}
+
+	return c.push(ctx, repo, config.RefSpec(ref+":"+ref))
+}
+
+// CreatePullRequest opens a pull request merging head into base
+func (c *Client) CreatePullRequest(ctx context.Context, owner, repoName, title, body, head, base string) (*github.PullRequest, error) {
+	pr, _, err := c.client.PullRequests.Create(ctx, owner, repoName, &github.NewPullRequest{
+		Title: github.String(title),
+		Body:  github.String(body),
+		Head:  github.String(head),
+		Base:  github.String(base),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pull request: %w", err)
+	}
+
+	return pr, nil
+}
+
+// WriteFilesToDirectory writes files to a local directory. Paths must stay
+// inside baseDir and may not pass through symlinks.
 func WriteFilesToDirectory(baseDir string, files map[string]string) error {
 	for filePath, content := range files {
+		if filepath.IsAbs(filePath) {
+			return fmt.Errorf("refusing to write absolute path %s", filePath)
+		}
+
 		fullPath := filepath.Join(baseDir, filePath)
-		
+		rel, err := filepath.Rel(baseDir, fullPath)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("refusing to write %s outside %s", filePath, baseDir)
+		}
+
+		if err := checkNoSymlinks(baseDir, rel); err != nil {
+			return err
+		}
+
 		// Create directory if it doesn't exist
 		dir := filepath.Dir(fullPath)
 		if err := os.MkdirAll(dir, 0755); err != nil {
@@ -143,7 +428,41 @@ func WriteFilesToDirectory(baseDir string, files map[string]string) error {
 	return nil
 }
 
+// checkNoSymlinks fails if any existing component of rel under baseDir is a symlink
+func checkNoSymlinks(baseDir, rel string) error {
+	current := baseDir
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		current = filepath.Join(current, part)
+		info, err := os.Lstat(current)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to inspect %s: %w", current, err)
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("refusing to write through symlink %s", current)
+		}
+	}
+	return nil
+}
+
 // GetRepoURL returns the HTTPS clone URL for a repository
 func GetRepoURL(owner, repoName string) string {
 	return fmt.Sprintf("https://github.com/%s/%s.git", owner, repoName)
 }
+
+// ParseRepoURL returns the owner and name of a repository from its web or
+// clone URL
+func ParseRepoURL(repoURL string) (string, string, error) {
+	u, err := url.Parse(repoURL)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid repository URL: %w", err)
+	}
+
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("invalid repository URL: %s", repoURL)
+	}
+	return parts[0], strings.TrimSuffix(parts[1], ".git"), nil
+}
@@ -0,0 +1,72 @@
+package github
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+)
+
+func TestStage(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	write := func(files map[string]string) {
+		if err := WriteFilesToDirectory(dir, files); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(map[string]string{"main.go": "package main", "go.mod": "module x", "README.md": "# x"})
+	if err := stage(w, []string{"main.go", "go.mod", "README.md"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Commit("initial", CommitOptions{Author: Identity{Name: "t", Email: "t@example.com"}}.gitOptions()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Files left behind by tools stay out of the index, deleted paths are
+	// staged as deletions
+	write(map[string]string{"main.go": "package main // changed", ".pytest_cache/v/cache": "x", "go.sum": "x"})
+	if err := os.Remove(filepath.Join(dir, "README.md")); err != nil {
+		t.Fatal(err)
+	}
+	if err := stage(w, []string{"main.go", "README.md"}); err != nil {
+		t.Fatal(err)
+	}
+
+	status, err := w.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path string
+		want git.StatusCode
+	}{
+		{"main.go", git.Modified},
+		{"README.md", git.Deleted},
+		{"go.mod", git.Unmodified},
+		{"go.sum", git.Untracked},
+		{".pytest_cache/v/cache", git.Untracked},
+	}
+	for _, tt := range tests {
+		got := git.Unmodified
+		if fs, ok := status[tt.path]; ok {
+			got = fs.Staging
+		}
+		if got != tt.want {
+			t.Errorf("%s staged as %q, want %q", tt.path, got, tt.want)
+		}
+	}
+
+	if err := stage(w, nil); err == nil {
+		t.Error("stage without paths succeeded, want an error")
+	}
+}
@@ -0,0 +1,168 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+
+	"golang.org/x/oauth2"
+)
+
+// Credentials identifies the GitHub identity used for a single task
+type Credentials struct {
+	Token          string // Raw user token supplied with the request
+	CredentialRef  string // Name of a token kept in the credential store
+	InstallationID int64  // GitHub App installation to act as
+	Caller         string // API client the task belongs to, empty for anonymous callers
+}
+
+// Grant lists the stored credentials and App installations an API caller
+// may act as
+type Grant struct {
+	CredentialRefs  []string
+	InstallationIDs []int64
+}
+
+// TokenStore resolves stored user tokens by reference name
+type TokenStore interface {
+	Token(ref string) (string, error)
+}
+
+// FileTokenStore is a TokenStore backed by a JSON file of name -> token
+type FileTokenStore struct {
+	tokens map[string]string
+}
+
+// LoadTokenStore loads stored user tokens from a JSON file
+func LoadTokenStore(path string) (*FileTokenStore, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credentials file: %w", err)
+	}
+
+	tokens := make(map[string]string)
+	if err := json.Unmarshal(data, &tokens); err != nil {
+		return nil, fmt.Errorf("failed to parse credentials file: %w", err)
+	}
+
+	return &FileTokenStore{tokens: tokens}, nil
+}
+
+// Token returns the token stored under ref
+func (s *FileTokenStore) Token(ref string) (string, error) {
+	token, ok := s.tokens[ref]
+	if !ok || token == "" {
+		return "", fmt.Errorf("unknown GitHub credential: %s", ref)
+	}
+	return token, nil
+}
+
+// FactoryConfig holds the settings used to build per-task clients
+type FactoryConfig struct {
+	DefaultToken          string     // Shared service token for tasks without credentials
+	DefaultInstallationID int64      // App installation used when DefaultToken is empty
+	Owner                 string     // Default repository owner
+	APIURL                string     // REST API base URL, defaults to DefaultAPIURL
+	Store                 TokenStore // Optional stored user tokens
+	App                   *App       // Optional GitHub App authenticator
+
+	// HTTPClient sends API requests, e.g. through a recording transport;
+	// defaults to http.DefaultClient
+	HTTPClient *http.Client
+
+	// OnToken, when set, is called with each token a client is built with,
+	// e.g. to scrub it from recorded exchanges
+	OnToken func(token string)
+
+	// Grants maps API callers to the credential refs and installations
+	// they may use; the empty name is the grant of anonymous callers.
+	// Callers without a grant may use neither.
+	Grants map[string]Grant
+}
+
+// ClientFactory builds GitHub clients for individual tasks
+type ClientFactory struct {
+	cfg FactoryConfig
+}
+
+// NewClientFactory creates a new client factory
+func NewClientFactory(cfg FactoryConfig) *ClientFactory {
+	return &ClientFactory{cfg: cfg}
+}
+
+// Authorize checks that the caller of creds may use the stored credential
+// or App installation they name. Raw tokens and the service defaults are
+// always allowed.
+func (f *ClientFactory) Authorize(creds Credentials) error {
+	grant := f.cfg.Grants[creds.Caller]
+
+	if creds.CredentialRef != "" && !slices.Contains(grant.CredentialRefs, creds.CredentialRef) {
+		return fmt.Errorf("GitHub credential %q is not available to this caller", creds.CredentialRef)
+	}
+	if creds.InstallationID != 0 && creds.InstallationID != f.cfg.DefaultInstallationID && !slices.Contains(grant.InstallationIDs, creds.InstallationID) {
+		return fmt.Errorf("GitHub App installation %d is not available to this caller", creds.InstallationID)
+	}
+	return nil
+}
+
+// ClientFor returns a client acting with the given credentials, once the
+// caller is authorized to use them. owner overrides the configured default
+// owner when non-empty.
+func (f *ClientFactory) ClientFor(creds Credentials, owner string) (*Client, error) {
+	if err := f.Authorize(creds); err != nil {
+		return nil, err
+	}
+	if owner == "" {
+		owner = f.cfg.Owner
+	}
+
+	switch {
+	case creds.Token != "":
+		return f.tokenClient(creds.Token, owner)
+
+	case creds.CredentialRef != "":
+		if f.cfg.Store == nil {
+			return nil, fmt.Errorf("no GitHub credential store is configured")
+		}
+		token, err := f.cfg.Store.Token(creds.CredentialRef)
+		if err != nil {
+			return nil, err
+		}
+		return f.tokenClient(token, owner)
+
+	case creds.InstallationID != 0:
+		return f.installationClient(creds.InstallationID, owner)
+
+	case f.cfg.DefaultToken != "":
+		return f.tokenClient(f.cfg.DefaultToken, owner)
+
+	case f.cfg.DefaultInstallationID != 0:
+		return f.installationClient(f.cfg.DefaultInstallationID, owner)
+
+	default:
+		return nil, fmt.Errorf("no GitHub credentials supplied and no default GITHUB_TOKEN or GitHub App installation configured")
+	}
+}
+
+// tokenClient builds a client authenticated with a static token
+func (f *ClientFactory) tokenClient(token, owner string) (*Client, error) {
+	if f.cfg.OnToken != nil {
+		f.cfg.OnToken(token)
+	}
+	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
+	return NewClientWithTokenSource(ts, owner, f.cfg.APIURL, f.cfg.HTTPClient)
+}
+
+// installationClient builds a client acting as a GitHub App installation
+func (f *ClientFactory) installationClient(installationID int64, owner string) (*Client, error) {
+	if f.cfg.App == nil {
+		return nil, fmt.Errorf("GitHub App authentication is not configured")
+	}
+	// Installation tokens cannot create repositories under /user
+	if owner == "" {
+		return nil, fmt.Errorf("github_org or GITHUB_OWNER is required when using GitHub App authentication")
+	}
+	return NewClientWithTokenSource(f.cfg.App.TokenSource(installationID), owner, f.cfg.APIURL, f.cfg.HTTPClient)
+}
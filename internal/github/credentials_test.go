@@ -0,0 +1,54 @@
+package github
+
+import "testing"
+
+func TestClientFactoryAuthorize(t *testing.T) {
+	f := NewClientFactory(FactoryConfig{
+		DefaultInstallationID: 1,
+		Grants: map[string]Grant{
+			"":      {CredentialRefs: []string{"shared"}},
+			"alice": {CredentialRefs: []string{"alice"}, InstallationIDs: []int64{42}},
+		},
+	})
+
+	tests := []struct {
+		name    string
+		creds   Credentials
+		wantErr bool
+	}{
+		{"raw token", Credentials{Token: "ghp_x"}, false},
+		{"service default", Credentials{}, false},
+		{"default installation", Credentials{InstallationID: 1}, false},
+		{"anonymous exposed ref", Credentials{CredentialRef: "shared"}, false},
+		{"anonymous unexposed ref", Credentials{CredentialRef: "alice"}, true},
+		{"anonymous installation", Credentials{InstallationID: 42}, true},
+		{"granted ref", Credentials{CredentialRef: "alice", Caller: "alice"}, false},
+		{"granted installation", Credentials{InstallationID: 42, Caller: "alice"}, false},
+		{"other caller's installation", Credentials{InstallationID: 43, Caller: "alice"}, true},
+		{"anonymous grant not inherited", Credentials{CredentialRef: "shared", Caller: "alice"}, true},
+		{"unknown caller", Credentials{CredentialRef: "alice", Caller: "mallory"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := f.Authorize(tt.creds)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Authorize(%+v) error = %v, want error %v", tt.creds, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClientForRejectsUnauthorized(t *testing.T) {
+	f := NewClientFactory(FactoryConfig{Store: mapStore{"alice": "ghp_alice"}})
+
+	if _, err := f.ClientFor(Credentials{CredentialRef: "alice"}, "acme"); err == nil {
+		t.Fatal("ClientFor issued a client for an ungranted credential ref")
+	}
+}
+
+// mapStore is an in-memory TokenStore
+type mapStore map[string]string
+
+func (s mapStore) Token(ref string) (string, error) {
+	return s[ref], nil
+}
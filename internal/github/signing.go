@@ -0,0 +1,178 @@
+package github
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/go-git/go-git/v5"
+	"golang.org/x/crypto/ssh"
+)
+
+// Supported commit signing formats
+const (
+	SigningFormatGPG = "gpg"
+	SigningFormatSSH = "ssh"
+)
+
+// NewSigner loads a commit signer of the given format from a private key file
+func NewSigner(format, keyPath, passphrase string) (git.Signer, error) {
+	data, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key: %w", err)
+	}
+
+	switch format {
+	case SigningFormatGPG:
+		return newGPGSigner(data, passphrase)
+	case SigningFormatSSH:
+		return newSSHSigner(data, passphrase)
+	default:
+		return nil, fmt.Errorf("unknown signing format: %s", format)
+	}
+}
+
+// gpgSigner signs commits with an OpenPGP private key
+type gpgSigner struct {
+	entity *openpgp.Entity
+}
+
+// newGPGSigner parses an armored OpenPGP private key
+func newGPGSigner(data []byte, passphrase string) (*gpgSigner, error) {
+	entities, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse GPG signing key: %w", err)
+	}
+	if len(entities) == 0 || entities[0].PrivateKey == nil {
+		return nil, fmt.Errorf("GPG signing key file contains no private key")
+	}
+
+	entity := entities[0]
+	if entity.PrivateKey.Encrypted {
+		if passphrase == "" {
+			return nil, fmt.Errorf("GPG signing key is encrypted but no passphrase was configured")
+		}
+		if err := entity.DecryptPrivateKeys([]byte(passphrase)); err != nil {
+			return nil, fmt.Errorf("failed to decrypt GPG signing key: %w", err)
+		}
+	}
+
+	return &gpgSigner{entity: entity}, nil
+}
+
+// Sign returns an armored detached signature of message
+func (s *gpgSigner) Sign(message io.Reader) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := openpgp.ArmoredDetachSign(&buf, s.entity, message, nil); err != nil {
+		return nil, fmt.Errorf("failed to sign commit: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// sshSigNamespace is the namespace git uses for SSH commit signatures
+const sshSigNamespace = "git"
+
+// sshSigner signs commits in the SSHSIG format used by `git -c gpg.format=ssh`
+type sshSigner struct {
+	signer ssh.Signer
+}
+
+// newSSHSigner parses an OpenSSH private key
+func newSSHSigner(data []byte, passphrase string) (*sshSigner, error) {
+	var (
+		signer ssh.Signer
+		err    error
+	)
+	if passphrase != "" {
+		signer, err = ssh.ParsePrivateKeyWithPassphrase(data, []byte(passphrase))
+	} else {
+		signer, err = ssh.ParsePrivateKey(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SSH signing key: %w", err)
+	}
+
+	return &sshSigner{signer: signer}, nil
+}
+
+// Sign returns an armored SSHSIG signature of message
+func (s *sshSigner) Sign(message io.Reader) ([]byte, error) {
+	h := sha512.New()
+	if _, err := io.Copy(h, message); err != nil {
+		return nil, err
+	}
+
+	signedData := ssh.Marshal(struct {
+		Magic     [6]byte
+		Namespace string
+		Reserved  string
+		HashAlg   string
+		Hash      string
+	}{
+		Magic:     sshSigMagic(),
+		Namespace: sshSigNamespace,
+		HashAlg:   "sha512",
+		Hash:      string(h.Sum(nil)),
+	})
+
+	sig, err := s.sign(signedData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign commit: %w", err)
+	}
+
+	blob := ssh.Marshal(struct {
+		Magic     [6]byte
+		Version   uint32
+		PublicKey string
+		Namespace string
+		Reserved  string
+		HashAlg   string
+		Signature string
+	}{
+		Magic:     sshSigMagic(),
+		Version:   1,
+		PublicKey: string(s.signer.PublicKey().Marshal()),
+		Namespace: sshSigNamespace,
+		HashAlg:   "sha512",
+		Signature: string(ssh.Marshal(sig)),
+	})
+
+	return armorSSHSignature(blob), nil
+}
+
+// sign signs data, preferring rsa-sha2-512 over the legacy ssh-rsa algorithm
+func (s *sshSigner) sign(data []byte) (*ssh.Signature, error) {
+	if algSigner, ok := s.signer.(ssh.AlgorithmSigner); ok && s.signer.PublicKey().Type() == ssh.KeyAlgoRSA {
+		return algSigner.SignWithAlgorithm(rand.Reader, data, ssh.KeyAlgoRSASHA512)
+	}
+	return s.signer.Sign(rand.Reader, data)
+}
+
+// sshSigMagic returns the SSHSIG preamble
+func sshSigMagic() [6]byte {
+	var magic [6]byte
+	copy(magic[:], "SSHSIG")
+	return magic
+}
+
+// armorSSHSignature wraps a signature blob in SSH SIGNATURE armor
+func armorSSHSignature(blob []byte) []byte {
+	encoded := base64.StdEncoding.EncodeToString(blob)
+
+	var b strings.Builder
+	b.WriteString("-----BEGIN SSH SIGNATURE-----\n")
+	for len(encoded) > 70 {
+		b.WriteString(encoded[:70])
+		b.WriteString("\n")
+		encoded = encoded[70:]
+	}
+	b.WriteString(encoded)
+	b.WriteString("\n-----END SSH SIGNATURE-----\n")
+	return []byte(b.String())
+}
@@ -0,0 +1,356 @@
+package github
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/go-git/go-git/v5"
+	"golang.org/x/crypto/ssh"
+)
+
+// writeKey writes key material to a file in a temporary directory
+func writeKey(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// newGPGKey returns an armored private key, optionally encrypted with
+// passphrase, and the armored public key ring to verify its signatures
+func newGPGKey(t *testing.T, passphrase string) (string, string) {
+	t.Helper()
+	entity, err := openpgp.NewEntity("Gen Code", "", "bot@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var public bytes.Buffer
+	w, _ := armor.Encode(&public, openpgp.PublicKeyType, nil)
+	if err := entity.Serialize(w); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	if passphrase != "" {
+		if err := entity.EncryptPrivateKeys([]byte(passphrase), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var private bytes.Buffer
+	w, _ = armor.Encode(&private, openpgp.PrivateKeyType, nil)
+	if err := entity.SerializePrivateWithoutSigning(w, nil); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	return writeKey(t, "signing.asc", private.Bytes()), public.String()
+}
+
+// newSSHKey returns an OpenSSH private key file, optionally encrypted with
+// passphrase, and its public key
+func newSSHKey(t *testing.T, algorithm, passphrase string) (string, ssh.PublicKey) {
+	t.Helper()
+	var key any
+	switch algorithm {
+	case "ed25519":
+		_, key, _ = ed25519.GenerateKey(rand.Reader)
+	case "rsa":
+		key, _ = rsa.GenerateKey(rand.Reader, 2048)
+	}
+
+	var (
+		block *pem.Block
+		err   error
+	)
+	if passphrase != "" {
+		block, err = ssh.MarshalPrivateKeyWithPassphrase(key, "", []byte(passphrase))
+	} else {
+		block, err = ssh.MarshalPrivateKey(key, "")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return writeKey(t, "id_"+algorithm, pem.EncodeToMemory(block)), signer.PublicKey()
+}
+
+// verifySSHSignature checks an armored SSHSIG signature of message against
+// key, following PROTOCOL.sshsig
+func verifySSHSignature(t *testing.T, key ssh.PublicKey, message, armored []byte) {
+	t.Helper()
+	body := strings.TrimSpace(string(armored))
+	if !strings.HasPrefix(body, "-----BEGIN SSH SIGNATURE-----\n") || !strings.HasSuffix(body, "\n-----END SSH SIGNATURE-----") {
+		t.Fatalf("signature is not SSH SIGNATURE armored:\n%s", armored)
+	}
+	lines := strings.Split(body, "\n")
+	for _, line := range lines[1 : len(lines)-1] {
+		if len(line) > 70 {
+			t.Errorf("armor line is %d characters, want at most 70", len(line))
+		}
+	}
+	blob, err := base64.StdEncoding.DecodeString(strings.Join(lines[1:len(lines)-1], ""))
+	if err != nil {
+		t.Fatalf("failed to decode armor: %v", err)
+	}
+
+	var sig struct {
+		Magic     [6]byte
+		Version   uint32
+		PublicKey string
+		Namespace string
+		Reserved  string
+		HashAlg   string
+		Signature string
+	}
+	if err := ssh.Unmarshal(blob, &sig); err != nil {
+		t.Fatalf("failed to parse SSHSIG blob: %v", err)
+	}
+	if string(sig.Magic[:]) != "SSHSIG" || sig.Version != 1 || sig.Namespace != "git" || sig.HashAlg != "sha512" {
+		t.Fatalf("SSHSIG header = %q v%d namespace %q hash %q", sig.Magic, sig.Version, sig.Namespace, sig.HashAlg)
+	}
+	if !bytes.Equal([]byte(sig.PublicKey), key.Marshal()) {
+		t.Fatal("SSHSIG names a different public key")
+	}
+
+	var signature ssh.Signature
+	if err := ssh.Unmarshal([]byte(sig.Signature), &signature); err != nil {
+		t.Fatalf("failed to parse signature: %v", err)
+	}
+	if key.Type() == ssh.KeyAlgoRSA && signature.Format != ssh.KeyAlgoRSASHA512 {
+		t.Errorf("RSA signature format = %s, want %s", signature.Format, ssh.KeyAlgoRSASHA512)
+	}
+
+	hash := sha512.Sum512(message)
+	signed := ssh.Marshal(struct {
+		Magic     [6]byte
+		Namespace string
+		Reserved  string
+		HashAlg   string
+		Hash      string
+	}{sig.Magic, "git", "", "sha512", string(hash[:])})
+	if err := key.Verify(signed, &signature); err != nil {
+		t.Errorf("signature does not verify: %v", err)
+	}
+}
+
+func TestGPGSigner(t *testing.T) {
+	tests := []struct {
+		name       string
+		encrypted  string // Passphrase the key is encrypted with
+		passphrase string // Passphrase configured for the signer
+		wantErr    string
+	}{
+		{"plain key", "", "", ""},
+		{"encrypted key", "s3cret", "s3cret", ""},
+		{"missing passphrase", "s3cret", "", "no passphrase was configured"},
+		{"wrong passphrase", "s3cret", "wrong", "failed to decrypt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keyPath, publicKey := newGPGKey(t, tt.encrypted)
+
+			signer, err := NewSigner(SigningFormatGPG, keyPath, tt.passphrase)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("err = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewSigner: %v", err)
+			}
+
+			message := []byte("tree 4b825dc642cb6eb9a060e54bf8d69288fbee4904\n\nInitial commit\n")
+			sig, err := signer.Sign(bytes.NewReader(message))
+			if err != nil {
+				t.Fatalf("Sign: %v", err)
+			}
+			keyring, err := openpgp.ReadArmoredKeyRing(strings.NewReader(publicKey))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := openpgp.CheckArmoredDetachedSignature(keyring, bytes.NewReader(message), bytes.NewReader(sig), nil); err != nil {
+				t.Errorf("signature does not verify: %v", err)
+			}
+		})
+	}
+}
+
+func TestSSHSigner(t *testing.T) {
+	tests := []struct {
+		name       string
+		algorithm  string
+		encrypted  string
+		passphrase string
+		wantErr    string
+	}{
+		{"ed25519", "ed25519", "", "", ""},
+		{"rsa uses rsa-sha2-512", "rsa", "", "", ""},
+		{"encrypted key", "ed25519", "s3cret", "s3cret", ""},
+		{"missing passphrase", "ed25519", "s3cret", "", "failed to parse SSH signing key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keyPath, publicKey := newSSHKey(t, tt.algorithm, tt.encrypted)
+
+			signer, err := NewSigner(SigningFormatSSH, keyPath, tt.passphrase)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("err = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewSigner: %v", err)
+			}
+
+			message := []byte(strings.Repeat("commit body line\n", 20))
+			sig, err := signer.Sign(bytes.NewReader(message))
+			if err != nil {
+				t.Fatalf("Sign: %v", err)
+			}
+			verifySSHSignature(t, publicKey, message, sig)
+
+			// Cross-check with OpenSSH where it is installed
+			if keygen, err := exec.LookPath("ssh-keygen"); err == nil {
+				dir := t.TempDir()
+				allowed := "bot@example.com " + string(ssh.MarshalAuthorizedKey(publicKey))
+				sigPath := filepath.Join(dir, "commit.sig")
+				os.WriteFile(filepath.Join(dir, "allowed_signers"), []byte(allowed), 0600)
+				os.WriteFile(sigPath, sig, 0600)
+
+				cmd := exec.Command(keygen, "-Y", "verify", "-f", filepath.Join(dir, "allowed_signers"),
+					"-I", "bot@example.com", "-n", "git", "-s", sigPath)
+				cmd.Stdin = bytes.NewReader(message)
+				if out, err := cmd.CombinedOutput(); err != nil {
+					t.Errorf("ssh-keygen -Y verify: %v\n%s", err, out)
+				}
+			}
+		})
+	}
+}
+
+func TestNewSignerErrors(t *testing.T) {
+	keyPath, _ := newSSHKey(t, "ed25519", "")
+	tests := []struct {
+		name    string
+		format  string
+		path    string
+		wantErr string
+	}{
+		{"unknown format", "x509", keyPath, "unknown signing format"},
+		{"missing file", SigningFormatSSH, filepath.Join(t.TempDir(), "missing"), "failed to read signing key"},
+		{"ssh key as gpg", SigningFormatGPG, keyPath, "failed to parse GPG signing key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewSigner(tt.format, tt.path, ""); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCommitOptions(t *testing.T) {
+	author := Identity{Name: "Gen Code", Email: "bot@example.com"}
+	alice := Identity{Name: "Alice", Email: "alice@example.com"}
+	bob := Identity{Name: "Bob", Email: "bob@example.com"}
+
+	tests := []struct {
+		name          string
+		opts          CommitOptions
+		message       string
+		wantMessage   string
+		wantCommitter Identity
+	}{
+		{"committer defaults to author", CommitOptions{Author: author}, "Add tests", "Add tests", author},
+		{"committer override", CommitOptions{Author: author, Committer: alice}, "Add tests", "Add tests", alice},
+		{"incomplete committer ignored", CommitOptions{Author: author, Committer: Identity{Name: "Alice"}}, "Add tests", "Add tests", author},
+		{
+			"co-author trailers",
+			CommitOptions{Author: author, CoAuthors: []Identity{alice, bob}},
+			"Add tests\n\nCovers the parser.\n",
+			"Add tests\n\nCovers the parser.\n\nCo-authored-by: Alice <alice@example.com>\nCo-authored-by: Bob <bob@example.com>\n",
+			author,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.opts.message(tt.message); got != tt.wantMessage {
+				t.Errorf("message = %q, want %q", got, tt.wantMessage)
+			}
+			opts := tt.opts.gitOptions()
+			if opts.Author.Name != author.Name || opts.Author.Email != author.Email {
+				t.Errorf("author = %s <%s>, want %s", opts.Author.Name, opts.Author.Email, author)
+			}
+			if got := (Identity{Name: opts.Committer.Name, Email: opts.Committer.Email}); got != tt.wantCommitter {
+				t.Errorf("committer = %s, want %s", got, tt.wantCommitter)
+			}
+		})
+	}
+}
+
+func TestSignedCommitVerifies(t *testing.T) {
+	keyPath, publicKey := newGPGKey(t, "")
+	signer, err := NewSigner(SigningFormatGPG, keyPath, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, _ := repo.Worktree()
+	if err := WriteFilesToDirectory(dir, map[string]string{"main.go": "package main\n"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := stage(w, []string{"main.go"}); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := CommitOptions{
+		Author:    Identity{Name: "Alice", Email: "alice@example.com"},
+		Committer: Identity{Name: "Gen Code", Email: "bot@example.com"},
+		CoAuthors: []Identity{{Name: "Bob", Email: "bob@example.com"}},
+		Signer:    signer,
+	}
+	hash, err := w.Commit(opts.message("Initial commit"), opts.gitOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := commit.Verify(publicKey); err != nil {
+		t.Errorf("commit signature does not verify: %v", err)
+	}
+	if commit.Author.Email != "alice@example.com" || commit.Committer.Email != "bot@example.com" || time.Since(commit.Committer.When) > time.Minute {
+		t.Errorf("author = %s, committer = %s", commit.Author, commit.Committer)
+	}
+	if !strings.HasSuffix(commit.Message, "\n\nCo-authored-by: Bob <bob@example.com>\n") {
+		t.Errorf("message = %q, want a Co-authored-by trailer", commit.Message)
+	}
+}
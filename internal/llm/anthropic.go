@@ -0,0 +1,359 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/cosmos-link/gen-code/internal/prompts"
+)
+
+// anthropicVersion is the Messages API version the client speaks
+const anthropicVersion = "2023-06-01"
+
+// submitProjectTool is the tool the model calls with a generated or
+// revised project, so the project arrives as structured input rather than
+// JSON embedded in text
+const submitProjectTool = "submit_project"
+
+// toolInstruction tells the model to answer with the tool instead of text
+const toolInstruction = "\n\nSubmit the JSON document described above by calling the " + submitProjectTool + " tool instead of replying with text."
+
+// projectSchema is the JSON schema of submitProjectTool's input
+var projectSchema = json.RawMessage(`{
+  "type": "object",
+  "properties": {
+    "name": {"type": "string", "description": "project name"},
+    "description": {"type": "string", "description": "project description or summary of the change"},
+    "files": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "properties": {
+          "path": {"type": "string"},
+          "content": {"type": "string", "description": "complete file content"},
+          "type": {"type": "string", "description": "file type (go/python/js/md etc.)"},
+          "category": {"type": "string", "enum": ["scaffolding", "core", "tests", "docs", "ci"]}
+        },
+        "required": ["path", "content"]
+      }
+    },
+    "deleted": {"type": "array", "items": {"type": "string"}, "description": "paths of files to remove"}
+  },
+  "required": ["name", "files"]
+}`)
+
+// AnthropicConfig configures the Anthropic Messages API client
+type AnthropicConfig struct {
+	APIKey  string
+	BaseURL string // Defaults to https://api.anthropic.com
+
+	// Model defaults to claude-sonnet-4-5
+	ModelConfig
+
+	// HTTPClient replaces the default HTTP client, e.g. for tests
+	HTTPClient *http.Client
+}
+
+// AnthropicClient implements the Client interface for the Anthropic
+// Messages API
+type AnthropicClient struct {
+	httpClient *http.Client
+	baseURL    string
+	apiKey     string
+	cfg        ModelConfig
+	keyID      string
+}
+
+// NewAnthropicClient creates a new Anthropic client
+func NewAnthropicClient(cfg AnthropicConfig) *AnthropicClient {
+	c := &AnthropicClient{
+		httpClient: cfg.HTTPClient,
+		baseURL:    strings.TrimSuffix(cfg.BaseURL, "/"),
+		apiKey:     cfg.APIKey,
+		cfg:        cfg.ModelConfig,
+		keyID:      KeyID(cfg.APIKey),
+	}
+	if c.httpClient == nil {
+		c.httpClient = &http.Client{}
+	}
+	if c.baseURL == "" {
+		c.baseURL = "https://api.anthropic.com"
+	}
+	if c.cfg.Model == "" {
+		c.cfg.Model = "claude-sonnet-4-5"
+	}
+
+	return c
+}
+
+// GetModelName returns the model name
+func (c *AnthropicClient) GetModelName() string {
+	return "anthropic"
+}
+
+// GenerateProject generates a complete project structure and files
+func (c *AnthropicClient) GenerateProject(ctx context.Context, prompt string, opts CallOptions) (*GeneratedProject, error) {
+	systemPrompt, err := renderPrompt(opts.Prompts, prompts.SetEnglish, prompts.Project, "")
+	if err != nil {
+		return nil, err
+	}
+
+	userPrompt := fmt.Sprintf("Please generate a project based on the following requirements:\n%s", prompt)
+
+	resp, err := c.createMessage(ctx, opts, OperationGenerateProject, anthropicRequest{
+		System:      systemPrompt + toolInstruction,
+		Messages:    []anthropicMessage{{Role: "user", Content: userPrompt}},
+		MaxTokens:   8000,
+		Temperature: 0.7,
+		Tools:       []anthropicTool{{Name: submitProjectTool, Description: "Submit the generated project", InputSchema: projectSchema}},
+		ToolChoice:  &anthropicToolChoice{Type: "tool", Name: submitProjectTool},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.project()
+}
+
+// GenerateFile generates a single file
+func (c *AnthropicClient) GenerateFile(ctx context.Context, prompt string, filePath string, fileType string, opts CallOptions) (string, error) {
+	systemPrompt, err := renderPrompt(opts.Prompts, prompts.SetEnglish, prompts.File, fileType)
+	if err != nil {
+		return "", err
+	}
+
+	userPrompt := fmt.Sprintf("Please generate content for file %s:\n%s", filePath, prompt)
+
+	resp, err := c.createMessage(ctx, opts, OperationGenerateFile, anthropicRequest{
+		System:      systemPrompt,
+		Messages:    []anthropicMessage{{Role: "user", Content: userPrompt}},
+		MaxTokens:   2000,
+		Temperature: 0.7,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return resp.text(), nil
+}
+
+// ReviseProject changes existing files according to an instruction
+func (c *AnthropicClient) ReviseProject(ctx context.Context, instruction string, files []FileInfo, opts CallOptions) (*GeneratedProject, error) {
+	systemPrompt, err := renderPrompt(opts.Prompts, prompts.SetEnglish, prompts.Revise, "")
+	if err != nil {
+		return nil, err
+	}
+
+	userPrompt := fmt.Sprintf("Instruction:\n%s\n\nExisting files:\n%s", instruction, formatFiles(files))
+
+	resp, err := c.createMessage(ctx, opts, OperationReviseProject, anthropicRequest{
+		System:      systemPrompt + toolInstruction,
+		Messages:    []anthropicMessage{{Role: "user", Content: userPrompt}},
+		MaxTokens:   8000,
+		Temperature: 0.2,
+		Tools:       []anthropicTool{{Name: submitProjectTool, Description: "Submit the added, changed and deleted files", InputSchema: projectSchema}},
+		ToolChoice:  &anthropicToolChoice{Type: "tool", Name: submitProjectTool},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.project()
+}
+
+// anthropicRequest is a Messages API request
+type anthropicRequest struct {
+	Model       string               `json:"model"`
+	System      string               `json:"system,omitempty"`
+	Messages    []anthropicMessage   `json:"messages"`
+	MaxTokens   int                  `json:"max_tokens"`
+	Temperature float32              `json:"temperature"`
+	Tools       []anthropicTool      `json:"tools,omitempty"`
+	ToolChoice  *anthropicToolChoice `json:"tool_choice,omitempty"`
+}
+
+// anthropicMessage is a conversation turn with text content
+type anthropicMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// anthropicTool is a tool the model may call
+type anthropicTool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	InputSchema json.RawMessage `json:"input_schema"`
+}
+
+// anthropicToolChoice forces the model to call a tool
+type anthropicToolChoice struct {
+	Type string `json:"type"`
+	Name string `json:"name,omitempty"`
+}
+
+// anthropicResponse is a Messages API response
+type anthropicResponse struct {
+	Model      string `json:"model"`
+	StopReason string `json:"stop_reason"`
+	Content    []struct {
+		Type  string          `json:"type"`
+		Text  string          `json:"text,omitempty"`
+		Name  string          `json:"name,omitempty"`
+		Input json.RawMessage `json:"input,omitempty"`
+	} `json:"content"`
+	Usage struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+// text returns the response's text content
+func (r *anthropicResponse) text() string {
+	var b strings.Builder
+	for _, block := range r.Content {
+		if block.Type == "text" {
+			b.WriteString(block.Text)
+		}
+	}
+	return b.String()
+}
+
+// project returns the project passed to submitProjectTool, falling back to
+// JSON in the text content if the model did not call the tool
+func (r *anthropicResponse) project() (*GeneratedProject, error) {
+	for _, block := range r.Content {
+		if block.Type == "tool_use" && block.Name == submitProjectTool {
+			var project GeneratedProject
+			if err := json.Unmarshal(block.Input, &project); err != nil {
+				return nil, fmt.Errorf("failed to parse %s input: %w", submitProjectTool, err)
+			}
+			return &project, nil
+		}
+	}
+	return parseProjectContent(r.text())
+}
+
+// AnthropicError is an error response from the Messages API
+type AnthropicError struct {
+	HTTPStatusCode int
+	Type           string
+	Message        string
+}
+
+// Error implements the error interface
+func (e *AnthropicError) Error() string {
+	return fmt.Sprintf("status code: %d, type: %s, message: %s", e.HTTPStatusCode, e.Type, e.Message)
+}
+
+// StatusCode returns the HTTP status of the response, used to decide
+// whether another provider should be tried
+func (e *AnthropicError) StatusCode() int {
+	return e.HTTPStatusCode
+}
+
+// createMessage sends a Messages API request with the configured and
+// requested model and sampling parameters, reporting usage for operation.
+// A response cut off by max_tokens is returned as an error.
+func (c *AnthropicClient) createMessage(ctx context.Context, opts CallOptions, operation string, req anthropicRequest) (*anthropicResponse, error) {
+	req.Model, req.Temperature, req.MaxTokens = sampling(opts.Params, "anthropic", c.cfg, req.Temperature, req.MaxTokens)
+
+	key := req
+	key.System = normalizePrompt(req.System)
+	key.Messages = make([]anthropicMessage, len(req.Messages))
+	for i, message := range req.Messages {
+		message.Content = normalizePrompt(message.Content)
+		key.Messages[i] = message
+	}
+
+	// Only complete responses are cached
+	var resp anthropicResponse
+	keep := func() bool {
+		return resp.StopReason != "max_tokens" && len(resp.Content) > 0
+	}
+
+	err := cachedCall(opts, "anthropic", key, &resp, keep, func() (Usage, error) {
+		if err := c.post(ctx, "/v1/messages", req, &resp); err != nil {
+			return Usage{}, err
+		}
+
+		model := resp.Model
+		if model == "" {
+			model = req.Model
+		}
+		return Usage{
+			Provider:         "anthropic",
+			Model:            model,
+			KeyID:            c.keyID,
+			Operation:        operation,
+			Temperature:      req.Temperature,
+			MaxTokens:        req.MaxTokens,
+			PromptTokens:     resp.Usage.InputTokens,
+			CompletionTokens: resp.Usage.OutputTokens,
+			TotalTokens:      resp.Usage.InputTokens + resp.Usage.OutputTokens,
+		}, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to call Anthropic API: %w", err)
+	}
+
+	if resp.StopReason == "max_tokens" {
+		return nil, fmt.Errorf("response was truncated at max_tokens, please simplify your prompt or reduce project complexity")
+	}
+	if len(resp.Content) == 0 {
+		return nil, fmt.Errorf("no response from Anthropic API")
+	}
+
+	return &resp, nil
+}
+
+// post sends a JSON request to the API and decodes the JSON response into out
+func (c *AnthropicClient) post(ctx context.Context, path string, body, out any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("X-Api-Key", c.apiKey)
+	httpReq.Header.Set("Anthropic-Version", anthropicVersion)
+
+	httpResp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+
+	respBody, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if httpResp.StatusCode != http.StatusOK {
+		apiErr := &AnthropicError{HTTPStatusCode: httpResp.StatusCode, Message: strings.TrimSpace(string(respBody))}
+		var errResp struct {
+			Error struct {
+				Type    string `json:"type"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if json.Unmarshal(respBody, &errResp) == nil && errResp.Error.Message != "" {
+			apiErr.Type = errResp.Error.Type
+			apiErr.Message = errResp.Error.Message
+		}
+		return apiErr
+	}
+
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeAnthropic serves one canned Messages API response and keeps the last
+// request it received
+type fakeAnthropic struct {
+	status   int
+	response string
+
+	header http.Header
+	body   map[string]any
+}
+
+func (f *fakeAnthropic) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.header = r.Header.Clone()
+	data, _ := io.ReadAll(r.Body)
+	f.body = nil
+	json.Unmarshal(data, &f.body)
+
+	if r.URL.Path != "/v1/messages" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if f.status != 0 {
+		w.WriteHeader(f.status)
+	}
+	io.WriteString(w, f.response)
+}
+
+func newFakeAnthropic(t *testing.T, status int, response string) (*fakeAnthropic, *AnthropicClient) {
+	t.Helper()
+	fake := &fakeAnthropic{status: status, response: response}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	client := NewAnthropicClient(AnthropicConfig{
+		APIKey:     "sk-ant-test-key-0000",
+		BaseURL:    srv.URL,
+		HTTPClient: srv.Client(),
+	})
+	return fake, client
+}
+
+func TestAnthropicGenerateProjectToolUse(t *testing.T) {
+	fake, client := newFakeAnthropic(t, 0, `{
+		"model": "claude-sonnet-4-5",
+		"stop_reason": "tool_use",
+		"content": [
+			{"type": "text", "text": "Here is the project."},
+			{"type": "tool_use", "name": "submit_project", "input": {
+				"name": "hello",
+				"description": "says hello",
+				"files": [{"path": "main.go", "content": "package main\n", "type": "go", "category": "core"}]
+			}}
+		],
+		"usage": {"input_tokens": 120, "output_tokens": 45}
+	}`)
+
+	project, err := client.GenerateProject(context.Background(), "a hello world program", CallOptions{})
+	if err != nil {
+		t.Fatalf("GenerateProject: %v", err)
+	}
+	if project.Name != "hello" || len(project.Files) != 1 || project.Files[0].Path != "main.go" || project.Files[0].Category != "core" {
+		t.Errorf("project = %+v", project)
+	}
+
+	if got := fake.header.Get("X-Api-Key"); got != "sk-ant-test-key-0000" {
+		t.Errorf("X-Api-Key = %q", got)
+	}
+	if got := fake.header.Get("Anthropic-Version"); got != anthropicVersion {
+		t.Errorf("Anthropic-Version = %q", got)
+	}
+
+	toolChoice, _ := fake.body["tool_choice"].(map[string]any)
+	if toolChoice["type"] != "tool" || toolChoice["name"] != submitProjectTool {
+		t.Errorf("tool_choice = %v, want the %s tool to be forced", fake.body["tool_choice"], submitProjectTool)
+	}
+	tools, _ := fake.body["tools"].([]any)
+	if len(tools) != 1 || tools[0].(map[string]any)["name"] != submitProjectTool {
+		t.Errorf("tools = %v", fake.body["tools"])
+	}
+	if system, _ := fake.body["system"].(string); !strings.HasSuffix(system, toolInstruction) {
+		t.Errorf("system prompt does not end with the tool instruction")
+	}
+	if fake.body["model"] != "claude-sonnet-4-5" || fake.body["max_tokens"] != float64(8000) {
+		t.Errorf("model = %v, max_tokens = %v", fake.body["model"], fake.body["max_tokens"])
+	}
+}
+
+func TestAnthropicReviseProjectFallsBackToText(t *testing.T) {
+	_, client := newFakeAnthropic(t, 0, `{
+		"stop_reason": "end_turn",
+		"content": [{"type": "text", "text": "{\"name\": \"hello\", \"files\": [], \"deleted\": [\"old.go\"]}"}]
+	}`)
+
+	project, err := client.ReviseProject(context.Background(), "remove old.go", []FileInfo{{Path: "old.go", Content: "package main\n"}}, CallOptions{})
+	if err != nil {
+		t.Fatalf("ReviseProject: %v", err)
+	}
+	if len(project.Deleted) != 1 || project.Deleted[0] != "old.go" {
+		t.Errorf("deleted = %v", project.Deleted)
+	}
+}
+
+func TestAnthropicGenerateFileHasNoTools(t *testing.T) {
+	fake, client := newFakeAnthropic(t, 0, `{
+		"stop_reason": "end_turn",
+		"content": [{"type": "text", "text": "package main"}, {"type": "text", "text": "\n"}]
+	}`)
+
+	content, err := client.GenerateFile(context.Background(), "entry point", "main.go", "go", CallOptions{})
+	if err != nil {
+		t.Fatalf("GenerateFile: %v", err)
+	}
+	if content != "package main\n" {
+		t.Errorf("content = %q", content)
+	}
+	if _, ok := fake.body["tool_choice"]; ok {
+		t.Error("file requests must not force a tool")
+	}
+}
+
+func TestAnthropicMaxTokensIsAnError(t *testing.T) {
+	_, client := newFakeAnthropic(t, 0, `{
+		"stop_reason": "max_tokens",
+		"content": [{"type": "tool_use", "name": "submit_project", "input": {"name": "cut", "files": []}}]
+	}`)
+
+	if _, err := client.GenerateProject(context.Background(), "a large project", CallOptions{}); err == nil || !strings.Contains(err.Error(), "max_tokens") {
+		t.Fatalf("err = %v, want a max_tokens truncation error", err)
+	}
+}
+
+func TestAnthropicErrorStatus(t *testing.T) {
+	tests := []struct {
+		status    int
+		body      string
+		wantType  string
+		retryable bool
+	}{
+		{http.StatusBadRequest, `{"type": "error", "error": {"type": "invalid_request_error", "message": "max_tokens too large"}}`, "invalid_request_error", false},
+		{http.StatusUnauthorized, `{"type": "error", "error": {"type": "authentication_error", "message": "invalid x-api-key"}}`, "authentication_error", false},
+		{http.StatusTooManyRequests, `{"type": "error", "error": {"type": "rate_limit_error", "message": "slow down"}}`, "rate_limit_error", true},
+		{529, `{"type": "error", "error": {"type": "overloaded_error", "message": "Overloaded"}}`, "overloaded_error", true},
+		{http.StatusBadGateway, `upstream unavailable`, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			_, client := newFakeAnthropic(t, tt.status, tt.body)
+
+			_, err := client.GenerateFile(context.Background(), "x", "x.go", "go", CallOptions{})
+			var apiErr *AnthropicError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("err = %v, want an *AnthropicError", err)
+			}
+			if apiErr.StatusCode() != tt.status || apiErr.Type != tt.wantType {
+				t.Errorf("StatusCode() = %d, Type = %q", apiErr.StatusCode(), apiErr.Type)
+			}
+			if IsRetryable(err) != tt.retryable {
+				t.Errorf("IsRetryable = %v, want %v", IsRetryable(err), tt.retryable)
+			}
+		})
+	}
+}
@@ -0,0 +1,252 @@
+package llm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CacheConfig configures the LLM response cache
+type CacheConfig struct {
+	Dir      string        // Directory holding one file per cached response
+	TTL      time.Duration // How long a response is served, 0 for no expiry
+	MaxBytes int64         // Size of Dir above which the least recently used responses are removed, 0 for no limit
+}
+
+// Cache stores LLM responses on disk, keyed by provider, model, sampling
+// parameters and normalized prompts, so that repeating a request does not
+// call the provider again
+type Cache struct {
+	cfg CacheConfig
+	mu  sync.Mutex
+}
+
+// cacheEntry is the on-disk form of a cached response
+type cacheEntry struct {
+	Created  time.Time       `json:"created"`
+	Usage    Usage           `json:"usage"` // Usage of the call that produced the response
+	Response json.RawMessage `json:"response"`
+}
+
+// cacheExt is the extension of cache entry files
+const cacheExt = ".json"
+
+// NewCache creates a cache in cfg.Dir, creating the directory if needed
+func NewCache(cfg CacheConfig) (*Cache, error) {
+	if err := os.MkdirAll(cfg.Dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	return &Cache{cfg: cfg}, nil
+}
+
+// normalizePrompt removes differences in line endings and surrounding
+// whitespace that do not change a prompt's meaning. Indentation is kept
+// since it is significant in the code revision prompts carry.
+func normalizePrompt(prompt string) string {
+	prompt = strings.ReplaceAll(prompt, "\r\n", "\n")
+	lines := strings.Split(prompt, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// cachedCall runs call through trackCall unless the cache in opts holds a
+// response to request, in which case it is decoded into resp and reported
+// to the usage tracker as a cache hit. request is the provider's request
+// with normalized prompts. A fresh response is cached when keep accepts it.
+func cachedCall(opts CallOptions, provider string, request, resp any, keep func() bool, call func() (Usage, error)) error {
+	if opts.Cache == nil {
+		return trackCall(opts.Usage, call)
+	}
+
+	key, err := cacheKey(provider, request)
+	if err != nil {
+		return trackCall(opts.Usage, call)
+	}
+
+	if !opts.RefreshCache {
+		if usage, ok := opts.Cache.get(key, resp); ok {
+			usage.Cached = true
+			usage.Latency = 0
+			usage.Time = time.Now()
+			if opts.Usage != nil {
+				return opts.Usage.Record(usage)
+			}
+			return nil
+		}
+	}
+
+	var (
+		usage  Usage
+		called bool
+	)
+	err = trackCall(opts.Usage, func() (Usage, error) {
+		var err error
+		usage, err = call()
+		called = err == nil
+		return usage, err
+	})
+
+	// The response is cached even when the tracker rejects it, since the
+	// tokens have been spent
+	if called && keep() {
+		if err := opts.Cache.put(key, usage, resp); err != nil {
+			log.Printf("Failed to cache %s response: %v", provider, err)
+		}
+	}
+	return err
+}
+
+// cacheKey hashes a provider and its request
+func cacheKey(provider string, request any) (string, error) {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	h.Write([]byte(provider))
+	h.Write([]byte{0})
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// path returns the file of a cache entry
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.cfg.Dir, key+cacheExt)
+}
+
+// get decodes the response cached under key into resp and returns the
+// usage of the call that produced it. Expired entries are removed.
+func (c *Cache) get(key string, resp any) (Usage, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	path := c.path(key)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Usage{}, false
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || json.Unmarshal(entry.Response, resp) != nil {
+		os.Remove(path)
+		return Usage{}, false
+	}
+	if c.expired(entry.Created) {
+		os.Remove(path)
+		return Usage{}, false
+	}
+
+	// Mark the entry as recently used so size pruning keeps it
+	now := time.Now()
+	os.Chtimes(path, now, now)
+
+	return entry.Usage, true
+}
+
+// put caches resp under key and prunes the cache to its size limit
+func (c *Cache) put(key string, usage Usage, resp any) error {
+	response, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(cacheEntry{Created: time.Now(), Usage: usage, Response: response})
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Write to a temporary file first so readers never see a partial entry
+	tmp, err := os.CreateTemp(c.cfg.Dir, key+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), c.path(key)); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return c.prune()
+}
+
+// prune removes expired entries, then the least recently used ones until
+// the cache fits in MaxBytes
+func (c *Cache) prune() error {
+	entries, err := os.ReadDir(c.cfg.Dir)
+	if err != nil {
+		return err
+	}
+
+	type file struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+	var (
+		files []file
+		total int64
+	)
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != cacheExt {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		path := filepath.Join(c.cfg.Dir, entry.Name())
+		// Hits only move the modification time forward, so an entry whose
+		// modification time has expired was created even earlier
+		if c.expired(info.ModTime()) {
+			os.Remove(path)
+			continue
+		}
+		files = append(files, file{path: path, size: info.Size(), modTime: info.ModTime()})
+		total += info.Size()
+	}
+
+	if c.cfg.MaxBytes <= 0 || total <= c.cfg.MaxBytes {
+		return nil
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.Before(files[j].modTime)
+	})
+	for _, f := range files {
+		if total <= c.cfg.MaxBytes {
+			break
+		}
+		if err := os.Remove(f.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		total -= f.size
+	}
+	return nil
+}
+
+// expired reports whether an entry created at created is past its TTL
+func (c *Cache) expired(created time.Time) bool {
+	return c.cfg.TTL > 0 && time.Since(created) > c.cfg.TTL
+}
@@ -0,0 +1,250 @@
+package llm
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func TestNormalizePrompt(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"hello", "hello"},
+		{"  hello\n\n", "hello"},
+		{"line one  \r\nline two\t\r\n", "line one\nline two"},
+		{"func main() {\n\tfmt.Println()\n}", "func main() {\n\tfmt.Println()\n}"},
+		{"def f():\n    return 1   \n", "def f():\n    return 1"},
+	}
+	for _, tt := range tests {
+		if got := normalizePrompt(tt.in); got != tt.want {
+			t.Errorf("normalizePrompt(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCacheKey(t *testing.T) {
+	request := func(model string, temperature float32, prompt string) openai.ChatCompletionRequest {
+		return openai.ChatCompletionRequest{
+			Model:       model,
+			Temperature: temperature,
+			Messages:    []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: normalizePrompt(prompt)}},
+		}
+	}
+	base, err := cacheKey("openai", request("gpt-4o", 0.7, "build a CLI"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		provider string
+		request  openai.ChatCompletionRequest
+		same     bool
+	}{
+		{"identical", "openai", request("gpt-4o", 0.7, "build a CLI"), true},
+		{"whitespace only", "openai", request("gpt-4o", 0.7, "build a CLI  \r\n"), true},
+		{"provider", "local", request("gpt-4o", 0.7, "build a CLI"), false},
+		{"model", "openai", request("gpt-4o-mini", 0.7, "build a CLI"), false},
+		{"temperature", "openai", request("gpt-4o", 0.2, "build a CLI"), false},
+		{"prompt", "openai", request("gpt-4o", 0.7, "build a web app"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := cacheKey(tt.provider, tt.request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (key == base) != tt.same {
+				t.Errorf("key equal to base = %v, want %v", key == base, tt.same)
+			}
+		})
+	}
+
+	// The provider is separated from the request, so names cannot run into it
+	a, _ := cacheKey("a", "bc")
+	b, _ := cacheKey("ab", "c")
+	if a == b {
+		t.Error("cacheKey does not separate the provider from the request")
+	}
+}
+
+// recordingTracker keeps the usage it is given
+type recordingTracker struct {
+	usages []Usage
+}
+
+func (r *recordingTracker) Allow() error { return nil }
+
+func (r *recordingTracker) Record(usage Usage) error {
+	r.usages = append(r.usages, usage)
+	return nil
+}
+
+func newTestCache(t *testing.T, cfg CacheConfig) *Cache {
+	t.Helper()
+	cfg.Dir = filepath.Join(t.TempDir(), "llm")
+	cache, err := NewCache(cfg)
+	if err != nil {
+		t.Fatalf("NewCache: %v", err)
+	}
+	return cache
+}
+
+func TestCachedCall(t *testing.T) {
+	cache := newTestCache(t, CacheConfig{})
+	tracker := &recordingTracker{}
+	opts := CallOptions{Usage: tracker}
+	withCache := func(refresh bool) CallOptions {
+		return CallOptions{Usage: tracker, Cache: cache, RefreshCache: refresh}
+	}
+
+	calls := 0
+	call := func(opts CallOptions, request string, keep bool) (string, error) {
+		var resp string
+		err := cachedCall(opts, "test", request, &resp, func() bool { return keep }, func() (Usage, error) {
+			calls++
+			resp = "reply to " + request
+			return Usage{Provider: "test", PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}, nil
+		})
+		return resp, err
+	}
+
+	steps := []struct {
+		name       string
+		opts       CallOptions
+		request    string
+		keep       bool
+		wantCalls  int
+		wantCached bool
+	}{
+		{"no cache", opts, "a", true, 1, false},
+		{"miss", withCache(false), "a", true, 2, false},
+		{"hit", withCache(false), "a", true, 2, true},
+		{"refresh", withCache(true), "a", true, 3, false},
+		{"truncated response not kept", withCache(false), "b", false, 4, false},
+		{"truncated response not served", withCache(false), "b", true, 5, false},
+		{"kept after retry", withCache(false), "b", true, 5, true},
+	}
+	for _, step := range steps {
+		resp, err := call(step.opts, step.request, step.keep)
+		if err != nil || resp != "reply to "+step.request {
+			t.Fatalf("%s: resp = %q, err = %v", step.name, resp, err)
+		}
+		if calls != step.wantCalls {
+			t.Errorf("%s: %d provider calls, want %d", step.name, calls, step.wantCalls)
+		}
+		last := tracker.usages[len(tracker.usages)-1]
+		if last.Cached != step.wantCached || last.TotalTokens != 15 {
+			t.Errorf("%s: recorded usage %+v, want Cached = %v with the original tokens", step.name, last, step.wantCached)
+		}
+	}
+}
+
+func TestCachedCallErrorsAreNotCached(t *testing.T) {
+	cache := newTestCache(t, CacheConfig{})
+	var resp string
+	failed := errors.New("rate limited")
+	err := cachedCall(CallOptions{Cache: cache}, "test", "a", &resp, func() bool { return true }, func() (Usage, error) {
+		return Usage{}, failed
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("err = %v, want the provider error", err)
+	}
+	if entries, _ := os.ReadDir(cache.cfg.Dir); len(entries) != 0 {
+		t.Errorf("failed call left %d cache entries", len(entries))
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	cache := newTestCache(t, CacheConfig{TTL: time.Hour})
+
+	// Entries written directly with an old creation time
+	write := func(key string, created time.Time) {
+		data, _ := json.Marshal(cacheEntry{Created: created, Response: json.RawMessage(`"cached"`)})
+		if err := os.WriteFile(cache.path(key), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		os.Chtimes(cache.path(key), created, created)
+	}
+	write("fresh", time.Now().Add(-30*time.Minute))
+	write("stale", time.Now().Add(-2*time.Hour))
+
+	var resp string
+	if _, ok := cache.get("fresh", &resp); !ok || resp != "cached" {
+		t.Errorf("fresh entry not served")
+	}
+	if _, ok := cache.get("stale", &resp); ok {
+		t.Errorf("expired entry served")
+	}
+	if _, err := os.Stat(cache.path("stale")); !os.IsNotExist(err) {
+		t.Errorf("expired entry not removed on read")
+	}
+
+	// Pruning also drops entries nobody reads again
+	write("stale", time.Now().Add(-2*time.Hour))
+	if err := cache.put("new", Usage{}, "x"); err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]bool{"fresh": true, "stale": false, "new": true} {
+		if _, err := os.Stat(cache.path(key)); (err == nil) != want {
+			t.Errorf("entry %s present = %v after pruning, want %v", key, err == nil, want)
+		}
+	}
+}
+
+func TestCachePruneLRU(t *testing.T) {
+	cache := newTestCache(t, CacheConfig{})
+	payload := string(make([]byte, 100))
+
+	// Entries used at increasing times; "a" is read again last
+	base := time.Now().Add(-time.Hour)
+	for i, key := range []string{"a", "b", "c"} {
+		if err := cache.put(key, Usage{}, payload); err != nil {
+			t.Fatal(err)
+		}
+		used := base.Add(time.Duration(i) * time.Minute)
+		os.Chtimes(cache.path(key), used, used)
+	}
+	var resp string
+	if _, ok := cache.get("a", &resp); !ok {
+		t.Fatal("entry a not served")
+	}
+
+	info, err := os.Stat(cache.path("b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Room for three entries: adding a fourth evicts the least recently used
+	cache.cfg.MaxBytes = 3 * info.Size()
+	if err := cache.put("d", Usage{}, payload); err != nil {
+		t.Fatal(err)
+	}
+
+	for key, want := range map[string]bool{"a": true, "b": false, "c": true, "d": true} {
+		if _, err := os.Stat(cache.path(key)); (err == nil) != want {
+			t.Errorf("entry %s present = %v, want %v", key, err == nil, want)
+		}
+	}
+}
+
+func TestCacheCorruptEntry(t *testing.T) {
+	cache := newTestCache(t, CacheConfig{})
+	if err := os.WriteFile(cache.path("bad"), []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var resp string
+	if _, ok := cache.get("bad", &resp); ok {
+		t.Error("corrupt entry served")
+	}
+	if _, err := os.Stat(cache.path("bad")); !os.IsNotExist(err) {
+		t.Error("corrupt entry not removed")
+	}
+}
@@ -3,13 +3,27 @@ package llm
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// File categories used to split the generated project into commits
+const (
+	CategoryScaffolding = "scaffolding"
+	CategoryCore        = "core"
+	CategoryTests       = "tests"
+	CategoryDocs        = "docs"
+	CategoryCI          = "ci"
 )
 
 // FileInfo represents a file in the generated project
 type FileInfo struct {
-	Path    string `json:"path"`
-	Content string `json:"content"`
-	Type    string `json:"type"` // go, python, js, md, etc.
+	Path     string `json:"path"`
+	Content  string `json:"content"`
+	Type     string `json:"type"`               // go, python, js, md, etc.
+	Category string `json:"category,omitempty"` // scaffolding, core, tests, docs, ci
 }
 
 // GeneratedProject represents the complete generated project
@@ -17,16 +31,83 @@ type GeneratedProject struct {
 	Name        string     `json:"name"`
 	Description string     `json:"description"`
 	Files       []FileInfo `json:"files"`
+	Deleted     []string   `json:"deleted,omitempty"` // Paths a revision removes
+}
+
+// Project languages reported by Languages
+const (
+	LanguageGo         = "go"
+	LanguagePython     = "python"
+	LanguageJavaScript = "javascript"
+	LanguageTypeScript = "typescript"
+)
+
+// languageByExt maps source file extensions to languages
+var languageByExt = map[string]string{
+	".go":  LanguageGo,
+	".py":  LanguagePython,
+	".js":  LanguageJavaScript,
+	".mjs": LanguageJavaScript,
+	".cjs": LanguageJavaScript,
+	".jsx": LanguageJavaScript,
+	".ts":  LanguageTypeScript,
+	".tsx": LanguageTypeScript,
+}
+
+// languageByManifest maps dependency manifests to languages
+var languageByManifest = map[string]string{
+	"go.mod":           LanguageGo,
+	"requirements.txt": LanguagePython,
+	"pyproject.toml":   LanguagePython,
+	"setup.py":         LanguagePython,
+	"package.json":     LanguageJavaScript,
+	"tsconfig.json":    LanguageTypeScript,
 }
 
-// Client is the interface for LLM clients
+// Languages returns the programming languages used by the project, sorted
+func (p *GeneratedProject) Languages() []string {
+	found := make(map[string]bool)
+	for _, file := range p.Files {
+		name := path.Base(strings.ReplaceAll(file.Path, "\\", "/"))
+		if lang, ok := languageByManifest[strings.ToLower(name)]; ok {
+			found[lang] = true
+		}
+		if lang, ok := languageByExt[strings.ToLower(path.Ext(name))]; ok {
+			found[lang] = true
+		}
+	}
+
+	languages := make([]string, 0, len(found))
+	for lang := range found {
+		languages = append(languages, lang)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// HasLanguage reports whether the project uses the given language
+func (p *GeneratedProject) HasLanguage(language string) bool {
+	for _, lang := range p.Languages() {
+		if lang == language {
+			return true
+		}
+	}
+	return false
+}
+
+// Client is the interface for LLM clients. The context only cancels a call;
+// everything else a request needs is in its CallOptions.
 type Client interface {
 	// GenerateProject generates a complete project from a prompt
-	GenerateProject(ctx context.Context, prompt string) (*GeneratedProject, error)
+	GenerateProject(ctx context.Context, prompt string, opts CallOptions) (*GeneratedProject, error)
 	
 	// GenerateFile generates a single file content
-	GenerateFile(ctx context.Context, prompt string, filePath string, fileType string) (string, error)
+	GenerateFile(ctx context.Context, prompt string, filePath string, fileType string, opts CallOptions) (string, error)
 	
+	// ReviseProject asks the model to change existing files according to an
+	// instruction and returns only the files it added or modified
+	ReviseProject(ctx context.Context, instruction string, files []FileInfo, opts CallOptions) (*GeneratedProject, error)
+
 	// GetModelName returns the name of the model being used
 	GetModelName() string
 }
@@ -39,3 +120,37 @@ func ParseProjectStructure(response string) (*GeneratedProject, error) {
 	}
 	return &project, nil
 }
+
+// parseProjectContent parses a model response containing a project JSON
+// document, possibly wrapped in a markdown code block
+func parseProjectContent(content string) (*GeneratedProject, error) {
+	content = extractJSON(content)
+
+	// Check if content is truncated
+	if !strings.HasSuffix(strings.TrimSpace(content), "}") && !strings.HasSuffix(strings.TrimSpace(content), "]") {
+		return nil, fmt.Errorf("response appears to be truncated, please simplify your prompt or reduce project complexity")
+	}
+
+	var project GeneratedProject
+	if err := json.Unmarshal([]byte(content), &project); err != nil {
+		contentPreview := content
+		if len(content) > 500 {
+			contentPreview = content[:500] + "..." + content[len(content)-100:]
+		}
+		return nil, fmt.Errorf("failed to parse JSON response: %w. Preview: %s", err, contentPreview)
+	}
+
+	return &project, nil
+}
+
+// formatFiles renders files for inclusion in a prompt
+func formatFiles(files []FileInfo) string {
+	var b strings.Builder
+	for _, file := range files {
+		fmt.Fprintf(&b, "=== %s ===\n%s\n", file.Path, file.Content)
+		if !strings.HasSuffix(file.Content, "\n") {
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,170 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/cosmos-link/gen-code/internal/prompts"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// CompatibleConfig configures a provider that speaks the OpenAI chat
+// completions API, such as Ollama, vLLM or a llama.cpp server
+type CompatibleConfig struct {
+	Name     string `json:"name"`                // Provider name used in LLM_PROVIDERS and usage records
+	BaseURL  string `json:"base_url"`            // e.g. http://localhost:11434/v1
+	APIKey   string `json:"api_key,omitempty"`   // Optional; no Authorization header is sent when empty
+	JSONMode bool   `json:"json_mode,omitempty"` // Request response_format json_object for project responses
+
+	// Model and sampling settings; max_tokens defaults to the server's limit
+	ModelConfig
+
+	// HTTPClient replaces the default HTTP client, e.g. for tests
+	HTTPClient *http.Client `json:"-"`
+}
+
+// LoadCompatibleProviders reads a JSON array of CompatibleConfig
+func LoadCompatibleProviders(path string) ([]CompatibleConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read providers file: %w", err)
+	}
+
+	var providers []CompatibleConfig
+	if err := json.Unmarshal(data, &providers); err != nil {
+		return nil, fmt.Errorf("failed to parse providers file: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	for _, p := range providers {
+		switch {
+		case p.Name == "":
+			return nil, fmt.Errorf("provider without a name in %s", path)
+		case p.BaseURL == "" || p.Model == "":
+			return nil, fmt.Errorf("provider %s needs base_url and model", p.Name)
+		case seen[p.Name]:
+			return nil, fmt.Errorf("duplicate provider %s", p.Name)
+		}
+		seen[p.Name] = true
+	}
+
+	return providers, nil
+}
+
+// CompatibleClient implements the Client interface for any OpenAI-compatible
+// chat completions server
+type CompatibleClient struct {
+	client *openai.Client
+	cfg    CompatibleConfig
+	keyID  string
+}
+
+// NewCompatibleClient creates a client for an OpenAI-compatible server
+func NewCompatibleClient(cfg CompatibleConfig) *CompatibleClient {
+	config := openai.DefaultConfig(cfg.APIKey)
+	config.BaseURL = cfg.BaseURL
+	if cfg.HTTPClient != nil {
+		config.HTTPClient = cfg.HTTPClient
+	}
+
+	keyID := ""
+	if cfg.APIKey != "" {
+		keyID = KeyID(cfg.APIKey)
+	}
+
+	return &CompatibleClient{
+		client: openai.NewClientWithConfig(config),
+		cfg:    cfg,
+		keyID:  keyID,
+	}
+}
+
+// GetModelName returns the provider name
+func (c *CompatibleClient) GetModelName() string {
+	return c.cfg.Name
+}
+
+// GenerateProject generates a complete project structure and files
+func (c *CompatibleClient) GenerateProject(ctx context.Context, prompt string, opts CallOptions) (*GeneratedProject, error) {
+	systemPrompt, err := renderPrompt(opts.Prompts, prompts.SetEnglish, prompts.Project, "")
+	if err != nil {
+		return nil, err
+	}
+
+	userPrompt := fmt.Sprintf("Please generate a project based on the following requirements:\n%s", prompt)
+
+	content, err := c.chat(ctx, opts, OperationGenerateProject, systemPrompt, userPrompt, 0.7, c.cfg.JSONMode)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseProjectContent(content)
+}
+
+// GenerateFile generates a single file
+func (c *CompatibleClient) GenerateFile(ctx context.Context, prompt string, filePath string, fileType string, opts CallOptions) (string, error) {
+	systemPrompt, err := renderPrompt(opts.Prompts, prompts.SetEnglish, prompts.File, fileType)
+	if err != nil {
+		return "", err
+	}
+
+	userPrompt := fmt.Sprintf("Please generate content for file %s:\n%s", filePath, prompt)
+
+	return c.chat(ctx, opts, OperationGenerateFile, systemPrompt, userPrompt, 0.7, false)
+}
+
+// ReviseProject changes existing files according to an instruction
+func (c *CompatibleClient) ReviseProject(ctx context.Context, instruction string, files []FileInfo, opts CallOptions) (*GeneratedProject, error) {
+	systemPrompt, err := renderPrompt(opts.Prompts, prompts.SetEnglish, prompts.Revise, "")
+	if err != nil {
+		return nil, err
+	}
+
+	userPrompt := fmt.Sprintf("Instruction:\n%s\n\nExisting files:\n%s", instruction, formatFiles(files))
+
+	content, err := c.chat(ctx, opts, OperationReviseProject, systemPrompt, userPrompt, 0.2, c.cfg.JSONMode)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseProjectContent(content)
+}
+
+// chat sends a system and user prompt and returns the reply. temperature
+// applies unless the provider or request sets its own.
+func (c *CompatibleClient) chat(ctx context.Context, opts CallOptions, operation, systemPrompt, userPrompt string, temperature float32, jsonMode bool) (string, error) {
+	req := openai.ChatCompletionRequest{
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: systemPrompt,
+			},
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: userPrompt,
+			},
+		},
+		Temperature: temperature,
+	}
+	req = applySampling(opts.Params, c.cfg.Name, c.cfg.ModelConfig, req)
+	if jsonMode {
+		req.ResponseFormat = &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject}
+	}
+
+	resp, err := chatCompletion(ctx, opts, c.client, c.cfg.Name, c.keyID, operation, req)
+	if err != nil {
+		return "", fmt.Errorf("failed to call %s API: %w", c.cfg.Name, err)
+	}
+
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("no response from %s API", c.cfg.Name)
+	}
+	if resp.Choices[0].FinishReason == openai.FinishReasonLength {
+		return "", fmt.Errorf("response from %s was truncated at max_tokens, please simplify your prompt or raise the provider's max_tokens", c.cfg.Name)
+	}
+
+	return resp.Choices[0].Message.Content, nil
+}
@@ -0,0 +1,176 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeCompatible serves one canned chat completions reply and keeps the last
+// request it received
+type fakeCompatible struct {
+	content      string
+	finishReason string
+
+	header http.Header
+	body   map[string]any
+}
+
+func (f *fakeCompatible) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.header = r.Header.Clone()
+	data, _ := io.ReadAll(r.Body)
+	f.body = nil
+	json.Unmarshal(data, &f.body)
+
+	if r.URL.Path != "/v1/chat/completions" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"id":     "chatcmpl-test",
+		"object": "chat.completion",
+		"model":  "llama3",
+		"choices": []map[string]any{{
+			"index":         0,
+			"message":       map[string]string{"role": "assistant", "content": f.content},
+			"finish_reason": f.finishReason,
+		}},
+		"usage": map[string]int{"prompt_tokens": 10, "completion_tokens": 5, "total_tokens": 15},
+	})
+}
+
+func newFakeCompatible(t *testing.T, cfg CompatibleConfig, content, finishReason string) (*fakeCompatible, *CompatibleClient) {
+	t.Helper()
+	fake := &fakeCompatible{content: content, finishReason: finishReason}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	cfg.Name = "local"
+	cfg.BaseURL = srv.URL + "/v1"
+	cfg.Model = "llama3"
+	cfg.HTTPClient = srv.Client()
+	return fake, NewCompatibleClient(cfg)
+}
+
+const compatibleProject = `{"name": "hello", "description": "says hello", "files": [{"path": "main.go", "content": "package main\n", "type": "go"}]}`
+
+func TestCompatibleJSONMode(t *testing.T) {
+	tests := []struct {
+		name     string
+		jsonMode bool
+	}{
+		{"enabled", true},
+		{"disabled", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, client := newFakeCompatible(t, CompatibleConfig{JSONMode: tt.jsonMode}, compatibleProject, "stop")
+
+			project, err := client.GenerateProject(context.Background(), "a hello world program", CallOptions{})
+			if err != nil {
+				t.Fatalf("GenerateProject: %v", err)
+			}
+			if project.Name != "hello" || len(project.Files) != 1 {
+				t.Errorf("project = %+v", project)
+			}
+
+			format, ok := fake.body["response_format"].(map[string]any)
+			if tt.jsonMode && (!ok || format["type"] != "json_object") {
+				t.Errorf("response_format = %v, want json_object", fake.body["response_format"])
+			}
+			if !tt.jsonMode && ok {
+				t.Errorf("response_format = %v, want it omitted", format)
+			}
+			if fake.body["model"] != "llama3" {
+				t.Errorf("model = %v", fake.body["model"])
+			}
+		})
+	}
+}
+
+func TestCompatibleJSONModeOnlyForProjects(t *testing.T) {
+	fake, client := newFakeCompatible(t, CompatibleConfig{JSONMode: true}, "package main\n", "stop")
+
+	if _, err := client.GenerateFile(context.Background(), "entry point", "main.go", "go", CallOptions{}); err != nil {
+		t.Fatalf("GenerateFile: %v", err)
+	}
+	if _, ok := fake.body["response_format"]; ok {
+		t.Error("file requests must not ask for JSON")
+	}
+}
+
+func TestCompatibleAuthorization(t *testing.T) {
+	tests := []struct {
+		name   string
+		apiKey string
+		want   string
+	}{
+		{"no key", "", ""},
+		{"key", "local-secret", "Bearer local-secret"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, client := newFakeCompatible(t, CompatibleConfig{APIKey: tt.apiKey}, "package main\n", "stop")
+
+			if _, err := client.GenerateFile(context.Background(), "entry point", "main.go", "go", CallOptions{}); err != nil {
+				t.Fatalf("GenerateFile: %v", err)
+			}
+			if _, ok := fake.header["Authorization"]; ok != (tt.want != "") || fake.header.Get("Authorization") != tt.want {
+				t.Errorf("Authorization = %q, want %q", fake.header.Get("Authorization"), tt.want)
+			}
+		})
+	}
+}
+
+func TestCompatibleTruncationIsAnError(t *testing.T) {
+	_, client := newFakeCompatible(t, CompatibleConfig{}, `{"name": "cut", "files": [`, "length")
+
+	if _, err := client.GenerateProject(context.Background(), "a large project", CallOptions{}); err == nil || !strings.Contains(err.Error(), "truncated") {
+		t.Fatalf("err = %v, want a truncation error", err)
+	}
+}
+
+func TestLoadCompatibleProviders(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{"valid", `[{"name": "ollama", "base_url": "http://localhost:11434/v1", "model": "llama3", "json_mode": true}, {"name": "vllm", "base_url": "http://vllm:8000/v1", "model": "qwen"}]`, ""},
+		{"malformed", `[{"name": "ollama"`, "failed to parse"},
+		{"missing name", `[{"base_url": "http://localhost:11434/v1", "model": "llama3"}]`, "without a name"},
+		{"missing base_url", `[{"name": "ollama", "model": "llama3"}]`, "needs base_url and model"},
+		{"missing model", `[{"name": "ollama", "base_url": "http://localhost:11434/v1"}]`, "needs base_url and model"},
+		{"duplicate", `[{"name": "ollama", "base_url": "http://a/v1", "model": "a"}, {"name": "ollama", "base_url": "http://b/v1", "model": "b"}]`, "duplicate provider ollama"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "providers.json")
+			if err := os.WriteFile(path, []byte(tt.data), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			providers, err := LoadCompatibleProviders(path)
+			if tt.wantErr == "" {
+				if err != nil || len(providers) != 2 || !providers[0].JSONMode || providers[1].Model != "qwen" {
+					t.Errorf("providers = %+v, err = %v", providers, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := LoadCompatibleProviders(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("a missing providers file must be an error")
+	}
+}
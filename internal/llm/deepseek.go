@@ -2,27 +2,38 @@ package llm
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"net/http"
 	"strings"
 
+	"github.com/cosmos-link/gen-code/internal/prompts"
 	openai "github.com/sashabaranov/go-openai"
 )
 
 // DeepSeekClient implements the Client interface for DeepSeek
 type DeepSeekClient struct {
 	client *openai.Client
-	model  string
+	cfg    ModelConfig
+	keyID  string
 }
 
-// NewDeepSeekClient creates a new DeepSeek client
-func NewDeepSeekClient(apiKey, baseURL string) *DeepSeekClient {
+// NewDeepSeekClient creates a new DeepSeek client. httpClient may be nil to
+// use the default client.
+func NewDeepSeekClient(apiKey, baseURL string, cfg ModelConfig, httpClient *http.Client) *DeepSeekClient {
 	config := openai.DefaultConfig(apiKey)
 	config.BaseURL = baseURL
+	if httpClient != nil {
+		config.HTTPClient = httpClient
+	}
 	
+	if cfg.Model == "" {
+		cfg.Model = "deepseek-chat"
+	}
+
 	return &DeepSeekClient{
 		client: openai.NewClientWithConfig(config),
-		model:  "deepseek-chat",
+		cfg:    cfg,
+		keyID:  KeyID(apiKey),
 	}
 }
 
@@ -32,37 +43,19 @@ func (c *DeepSeekClient) GetModelName() string {
 }
 
 // GenerateProject generates a complete project structure and files
-func (c *DeepSeekClient) GenerateProject(ctx context.Context, prompt string) (*GeneratedProject, error) {
+func (c *DeepSeekClient) GenerateProject(ctx context.Context, prompt string, opts CallOptions) (*GeneratedProject, error) {
 	// First, generate the project structure
-	systemPrompt := `你是一个专业的代码生成助手。根据用户的需求，生成完整的项目结构和代码。
-
-请返回JSON格式的响应，格式如下：
-{
-  "name": "项目名称",
-  "description": "项目描述",
-  "files": [
-    {
-      "path": "文件路径",
-      "content": "文件内容（保持简洁）",
-      "type": "文件类型(go/python/js/md等)"
-    }
-  ]
-}
-
-重要注意事项：
-1. 文件数量限制在5个以内
-2. 每个文件的内容保持简洁，生成核心功能代码
-3. README.md要简短清晰
-4. 确保返回完整有效的JSON，不要截断
-5. 文件内容中的字符串要正确转义
-6. 支持的文件类型：go, py, js, ts, md, json, yaml`
+	systemPrompt, err := renderPrompt(opts.Prompts, prompts.SetChinese, prompts.Project, "")
+	if err != nil {
+		return nil, err
+	}
 
 	userPrompt := fmt.Sprintf("请根据以下需求生成项目：\n%s", prompt)
 
-	resp, err := c.client.CreateChatCompletion(
-		ctx,
+	resp, err := c.complete(
+		ctx, opts, OperationGenerateProject,
 		openai.ChatCompletionRequest{
-			Model: c.model,
+			Model: c.cfg.Model,
 			Messages: []openai.ChatCompletionMessage{
 				{
 					Role:    openai.ChatMessageRoleSystem,
@@ -86,40 +79,22 @@ func (c *DeepSeekClient) GenerateProject(ctx context.Context, prompt string) (*G
 		return nil, fmt.Errorf("no response from DeepSeek API")
 	}
 
-	content := resp.Choices[0].Message.Content
-	
-	// Try to extract JSON from markdown code blocks if present
-	content = extractJSON(content)
-
-	// Check if content is truncated
-	if !strings.HasSuffix(strings.TrimSpace(content), "}") && !strings.HasSuffix(strings.TrimSpace(content), "]") {
-		return nil, fmt.Errorf("response appears to be truncated, please simplify your prompt or reduce project complexity")
-	}
-
-	var project GeneratedProject
-	if err := json.Unmarshal([]byte(content), &project); err != nil {
-		// Try to provide helpful error message
-		contentPreview := content
-		if len(content) > 500 {
-			contentPreview = content[:500] + "..." + content[len(content)-100:]
-		}
-		return nil, fmt.Errorf("failed to parse JSON response: %w. Preview: %s", err, contentPreview)
-	}
-
-	return &project, nil
+	return parseProjectContent(resp.Choices[0].Message.Content)
 }
 
 // GenerateFile generates a single file
-func (c *DeepSeekClient) GenerateFile(ctx context.Context, prompt string, filePath string, fileType string) (string, error) {
-	systemPrompt := fmt.Sprintf(`你是一个专业的代码生成助手。根据用户需求生成%s类型的文件内容。
-只返回文件的实际内容，不要包含任何解释或markdown标记。`, fileType)
+func (c *DeepSeekClient) GenerateFile(ctx context.Context, prompt string, filePath string, fileType string, opts CallOptions) (string, error) {
+	systemPrompt, err := renderPrompt(opts.Prompts, prompts.SetChinese, prompts.File, fileType)
+	if err != nil {
+		return "", err
+	}
 
 	userPrompt := fmt.Sprintf("请为文件 %s 生成内容：\n%s", filePath, prompt)
 
-	resp, err := c.client.CreateChatCompletion(
-		ctx,
+	resp, err := c.complete(
+		ctx, opts, OperationGenerateFile,
 		openai.ChatCompletionRequest{
-			Model: c.model,
+			Model: c.cfg.Model,
 			Messages: []openai.ChatCompletionMessage{
 				{
 					Role:    openai.ChatMessageRoleSystem,
@@ -146,6 +121,45 @@ func (c *DeepSeekClient) GenerateFile(ctx context.Context, prompt string, filePa
 	return resp.Choices[0].Message.Content, nil
 }
 
+// ReviseProject changes existing files according to an instruction
+func (c *DeepSeekClient) ReviseProject(ctx context.Context, instruction string, files []FileInfo, opts CallOptions) (*GeneratedProject, error) {
+	systemPrompt, err := renderPrompt(opts.Prompts, prompts.SetChinese, prompts.Revise, "")
+	if err != nil {
+		return nil, err
+	}
+
+	userPrompt := fmt.Sprintf("修改要求：\n%s\n\n现有文件：\n%s", instruction, formatFiles(files))
+
+	resp, err := c.complete(
+		ctx, opts, OperationReviseProject,
+		openai.ChatCompletionRequest{
+			Model: c.cfg.Model,
+			Messages: []openai.ChatCompletionMessage{
+				{
+					Role:    openai.ChatMessageRoleSystem,
+					Content: systemPrompt,
+				},
+				{
+					Role:    openai.ChatMessageRoleUser,
+					Content: userPrompt,
+				},
+			},
+			Temperature: 0.2,
+			MaxTokens:   8000,
+		},
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to call DeepSeek API: %w", err)
+	}
+
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("no response from DeepSeek API")
+	}
+
+	return parseProjectContent(resp.Choices[0].Message.Content)
+}
+
 // extractJSON extracts JSON content from markdown code blocks
 func extractJSON(content string) string {
 	// Remove markdown code blocks if present
@@ -166,3 +180,9 @@ func extractJSON(content string) string {
 	
 	return strings.TrimSpace(content)
 }
+
+// complete calls the chat completions API with the configured and requested
+// model and sampling parameters, reporting usage for operation
+func (c *DeepSeekClient) complete(ctx context.Context, opts CallOptions, operation string, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	return chatCompletion(ctx, opts, c.client, "deepseek", c.keyID, operation, applySampling(opts.Params, "deepseek", c.cfg, req))
+}
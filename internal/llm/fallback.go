@@ -0,0 +1,231 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// ErrAllProvidersUnavailable is returned when every provider's circuit is open
+var ErrAllProvidersUnavailable = errors.New("all LLM providers are unavailable")
+
+// FallbackConfig configures failover between providers
+type FallbackConfig struct {
+	AttemptTimeout   time.Duration // Limit on each provider attempt, 0 for none
+	BreakerThreshold int           // Consecutive failures that open a provider's circuit, 0 disables the breaker
+	BreakerCooldown  time.Duration // How long an open circuit skips its provider
+}
+
+// Provider is a named client in a fallback chain
+type Provider struct {
+	Name   string
+	Client Client
+}
+
+// FailoverObserver is told when a provider fails and the call moves on to
+// the next one
+type FailoverObserver func(provider string, err error)
+
+// FallbackClient tries an ordered list of providers, moving to the next one
+// when a call fails with a retryable error or times out
+type FallbackClient struct {
+	providers []*fallbackProvider
+	cfg       FallbackConfig
+}
+
+// fallbackProvider tracks the circuit breaker state of one provider
+type fallbackProvider struct {
+	Provider
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+}
+
+// NewFallbackClient creates a client that fails over between providers in order
+func NewFallbackClient(providers []Provider, cfg FallbackConfig) *FallbackClient {
+	c := &FallbackClient{cfg: cfg}
+	for _, p := range providers {
+		c.providers = append(c.providers, &fallbackProvider{Provider: p})
+	}
+	return c
+}
+
+// GetModelName returns the provider names in failover order
+func (c *FallbackClient) GetModelName() string {
+	names := make([]string, 0, len(c.providers))
+	for _, p := range c.providers {
+		names = append(names, p.Name)
+	}
+	return strings.Join(names, ",")
+}
+
+// GenerateProject generates a project with the first available provider
+func (c *FallbackClient) GenerateProject(ctx context.Context, prompt string, opts CallOptions) (*GeneratedProject, error) {
+	var project *GeneratedProject
+	err := c.try(ctx, opts, func(ctx context.Context, client Client) error {
+		var err error
+		project, err = client.GenerateProject(ctx, prompt, opts)
+		return err
+	})
+	return project, err
+}
+
+// GenerateFile generates a file with the first available provider
+func (c *FallbackClient) GenerateFile(ctx context.Context, prompt string, filePath string, fileType string, opts CallOptions) (string, error) {
+	var content string
+	err := c.try(ctx, opts, func(ctx context.Context, client Client) error {
+		var err error
+		content, err = client.GenerateFile(ctx, prompt, filePath, fileType, opts)
+		return err
+	})
+	return content, err
+}
+
+// ReviseProject revises a project with the first available provider
+func (c *FallbackClient) ReviseProject(ctx context.Context, instruction string, files []FileInfo, opts CallOptions) (*GeneratedProject, error) {
+	var project *GeneratedProject
+	err := c.try(ctx, opts, func(ctx context.Context, client Client) error {
+		var err error
+		project, err = client.ReviseProject(ctx, instruction, files, opts)
+		return err
+	})
+	return project, err
+}
+
+// try runs call against each provider in turn, starting with the one opts
+// prefers, until one succeeds or fails with an error another provider would
+// not fix. Providers with an open circuit are skipped.
+func (c *FallbackClient) try(ctx context.Context, opts CallOptions, call func(context.Context, Client) error) error {
+	var errs []error
+	attempted := false
+
+	for _, p := range c.ordered(opts.PreferredProvider) {
+		if !p.available() {
+			errs = append(errs, fmt.Errorf("%s: circuit open", p.Name))
+			continue
+		}
+		attempted = true
+
+		attemptCtx, cancel := ctx, context.CancelFunc(func() {})
+		if c.cfg.AttemptTimeout > 0 {
+			attemptCtx, cancel = context.WithTimeout(ctx, c.cfg.AttemptTimeout)
+		}
+		err := call(attemptCtx, p.Client)
+		cancel()
+
+		if err == nil {
+			p.succeeded()
+			return nil
+		}
+		if ctx.Err() != nil || !IsRetryable(err) {
+			return err
+		}
+
+		p.failed(c.cfg)
+		errs = append(errs, fmt.Errorf("%s: %w", p.Name, err))
+		if opts.OnFailover != nil {
+			opts.OnFailover(p.Name, err)
+		}
+	}
+
+	if !attempted {
+		return fmt.Errorf("%w: %w", ErrAllProvidersUnavailable, errors.Join(errs...))
+	}
+	return fmt.Errorf("all LLM providers failed: %w", errors.Join(errs...))
+}
+
+// ordered returns the providers in failover order, moving the preferred
+// provider to the front
+func (c *FallbackClient) ordered(preferred string) []*fallbackProvider {
+	if preferred == "" {
+		return c.providers
+	}
+
+	providers := make([]*fallbackProvider, 0, len(c.providers))
+	for _, p := range c.providers {
+		if p.Name == preferred {
+			providers = append(providers, p)
+		}
+	}
+	for _, p := range c.providers {
+		if p.Name != preferred {
+			providers = append(providers, p)
+		}
+	}
+	return providers
+}
+
+// available reports whether the provider's circuit lets a call through.
+// Once the cooldown has passed calls are let through again; the first
+// success closes the circuit and the first failure reopens it.
+func (p *fallbackProvider) available() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.openUntil.IsZero() || time.Now().After(p.openUntil) {
+		return true
+	}
+	return false
+}
+
+// succeeded closes the provider's circuit
+func (p *fallbackProvider) succeeded() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.failures = 0
+	p.openUntil = time.Time{}
+}
+
+// failed counts a failure, opening the circuit at the threshold
+func (p *fallbackProvider) failed(cfg FallbackConfig) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.failures++
+	if cfg.BreakerThreshold > 0 && p.failures >= cfg.BreakerThreshold {
+		p.openUntil = time.Now().Add(cfg.BreakerCooldown)
+	}
+}
+
+// IsRetryable reports whether a failed call might succeed with another
+// provider: timeouts, network errors, rate limiting and server errors.
+// Malformed output, bad requests and token limits are not retryable.
+func IsRetryable(err error) bool {
+	if errors.Is(err, ErrTokenLimit) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) {
+		return retryableStatus(apiErr.HTTPStatusCode)
+	}
+	var reqErr *openai.RequestError
+	if errors.As(err, &reqErr) {
+		return retryableStatus(reqErr.HTTPStatusCode)
+	}
+	var statusErr interface{ StatusCode() int }
+	if errors.As(err, &statusErr) {
+		return retryableStatus(statusErr.StatusCode())
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// retryableStatus reports whether an HTTP status is worth retrying elsewhere
+func retryableStatus(code int) bool {
+	return code == http.StatusRequestTimeout || code == http.StatusTooManyRequests || code >= 500
+}
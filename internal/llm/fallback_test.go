@@ -0,0 +1,219 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"token limit", fmt.Errorf("task: %w", ErrTokenLimit), false},
+		{"canceled", context.Canceled, false},
+		{"deadline", fmt.Errorf("attempt: %w", context.DeadlineExceeded), true},
+		{"unexpected EOF", io.ErrUnexpectedEOF, true},
+		{"rate limited", &openai.APIError{HTTPStatusCode: http.StatusTooManyRequests}, true},
+		{"server error", &openai.APIError{HTTPStatusCode: http.StatusBadGateway}, true},
+		{"request timeout", &openai.RequestError{HTTPStatusCode: http.StatusRequestTimeout}, true},
+		{"bad request", &openai.APIError{HTTPStatusCode: http.StatusBadRequest}, false},
+		{"unauthorized", &openai.RequestError{HTTPStatusCode: http.StatusUnauthorized}, false},
+		{"anthropic overloaded", &AnthropicError{HTTPStatusCode: 529, Type: "overloaded_error"}, true},
+		{"anthropic invalid", &AnthropicError{HTTPStatusCode: http.StatusBadRequest}, false},
+		{"network", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, true},
+		{"malformed output", errors.New("failed to parse project JSON"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.want {
+				t.Errorf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+// scriptedClient returns the next scripted error on each GenerateFile call,
+// succeeding once the script runs out
+type scriptedClient struct {
+	Client
+	name   string
+	errs   []error
+	calls  int
+	record *[]string
+}
+
+func (c *scriptedClient) GenerateFile(ctx context.Context, prompt, filePath, fileType string, opts CallOptions) (string, error) {
+	c.calls++
+	*c.record = append(*c.record, c.name)
+	if len(c.errs) > 0 {
+		err := c.errs[0]
+		c.errs = c.errs[1:]
+		return "", err
+	}
+	return c.name, nil
+}
+
+func newScripted(record *[]string, name string, errs ...error) *scriptedClient {
+	return &scriptedClient{name: name, errs: errs, record: record}
+}
+
+var errUnavailable = &openai.APIError{HTTPStatusCode: http.StatusServiceUnavailable, Message: "unavailable"}
+
+func TestFallbackFailover(t *testing.T) {
+	tests := []struct {
+		name      string
+		primary   []error
+		secondary []error
+		preferred string
+		want      string
+		wantCalls string
+		wantErr   string
+	}{
+		{"primary succeeds", nil, nil, "", "primary", "primary", ""},
+		{"retryable error fails over", []error{errUnavailable}, nil, "", "secondary", "primary,secondary", ""},
+		{"non-retryable error stops", []error{errors.New("bad JSON")}, nil, "", "", "primary", "bad JSON"},
+		{"all fail", []error{errUnavailable}, []error{errUnavailable}, "", "", "primary,secondary", "all LLM providers failed"},
+		{"preferred provider first", nil, nil, "secondary", "secondary", "secondary", ""},
+		{"unknown preference ignored", nil, nil, "other", "primary", "primary", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			client := NewFallbackClient([]Provider{
+				{Name: "primary", Client: newScripted(&calls, "primary", tt.primary...)},
+				{Name: "secondary", Client: newScripted(&calls, "secondary", tt.secondary...)},
+			}, FallbackConfig{})
+
+			var failovers []string
+			opts := CallOptions{
+				PreferredProvider: tt.preferred,
+				OnFailover: func(provider string, err error) {
+					failovers = append(failovers, provider)
+				},
+			}
+
+			got, err := client.GenerateFile(context.Background(), "p", "f.go", "go", opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("err = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil || got != tt.want {
+				t.Errorf("GenerateFile = %q, %v, want %q", got, err, tt.want)
+			}
+			if strings.Join(calls, ",") != tt.wantCalls {
+				t.Errorf("calls = %v, want %s", calls, tt.wantCalls)
+			}
+			if tt.wantErr == "" && len(failovers) != len(calls)-1 {
+				t.Errorf("failovers = %v for calls %v", failovers, calls)
+			}
+		})
+	}
+}
+
+func TestFallbackAttemptTimeout(t *testing.T) {
+	var calls []string
+	slow := &blockingClient{}
+	client := NewFallbackClient([]Provider{
+		{Name: "slow", Client: slow},
+		{Name: "fast", Client: newScripted(&calls, "fast")},
+	}, FallbackConfig{AttemptTimeout: 10 * time.Millisecond})
+
+	got, err := client.GenerateFile(context.Background(), "p", "f.go", "go", CallOptions{})
+	if err != nil || got != "fast" {
+		t.Errorf("GenerateFile = %q, %v, want the second provider after the first timed out", got, err)
+	}
+}
+
+// blockingClient waits for its context to end
+type blockingClient struct{ Client }
+
+func (blockingClient) GenerateFile(ctx context.Context, prompt, filePath, fileType string, opts CallOptions) (string, error) {
+	<-ctx.Done()
+	return "", ctx.Err()
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	var calls []string
+	primary := newScripted(&calls, "primary", errUnavailable, errUnavailable, errUnavailable)
+	client := NewFallbackClient([]Provider{
+		{Name: "primary", Client: primary},
+		{Name: "secondary", Client: newScripted(&calls, "secondary")},
+	}, FallbackConfig{BreakerThreshold: 2, BreakerCooldown: time.Hour})
+	breaker := client.providers[0]
+
+	generate := func() string {
+		t.Helper()
+		got, err := client.GenerateFile(context.Background(), "p", "f.go", "go", CallOptions{})
+		if err != nil {
+			t.Fatalf("GenerateFile: %v", err)
+		}
+		return got
+	}
+
+	steps := []struct {
+		name         string
+		before       func()
+		wantProvider string
+		wantPrimary  int // Calls made to primary so far
+		wantOpen     bool
+	}{
+		{"first failure stays closed", nil, "secondary", 1, false},
+		{"threshold opens the circuit", nil, "secondary", 2, true},
+		{"open circuit skips primary", nil, "secondary", 2, true},
+		{"failure after cooldown reopens", func() { breaker.openUntil = time.Now().Add(-time.Second) }, "secondary", 3, true},
+		{"success after cooldown closes", func() { breaker.openUntil = time.Now().Add(-time.Second) }, "primary", 4, false},
+	}
+	for _, step := range steps {
+		if step.before != nil {
+			step.before()
+		}
+		if got := generate(); got != step.wantProvider {
+			t.Errorf("%s: served by %s, want %s", step.name, got, step.wantProvider)
+		}
+		if primary.calls != step.wantPrimary {
+			t.Errorf("%s: primary called %d times, want %d", step.name, primary.calls, step.wantPrimary)
+		}
+		if open := !breaker.available(); open != step.wantOpen {
+			t.Errorf("%s: circuit open = %v, want %v", step.name, open, step.wantOpen)
+		}
+	}
+	if breaker.failures != 0 {
+		t.Errorf("failures = %d after a success, want 0", breaker.failures)
+	}
+}
+
+func TestCircuitBreakerAllOpen(t *testing.T) {
+	var calls []string
+	client := NewFallbackClient([]Provider{
+		{Name: "only", Client: newScripted(&calls, "only", errUnavailable)},
+	}, FallbackConfig{BreakerThreshold: 1, BreakerCooldown: time.Hour})
+
+	if _, err := client.GenerateFile(context.Background(), "p", "f.go", "go", CallOptions{}); err == nil {
+		t.Fatal("want the provider's failure")
+	}
+	_, err := client.GenerateFile(context.Background(), "p", "f.go", "go", CallOptions{})
+	if !errors.Is(err, ErrAllProvidersUnavailable) || len(calls) != 1 {
+		t.Errorf("err = %v after %d calls, want ErrAllProvidersUnavailable without calling the provider", err, len(calls))
+	}
+}
+
+func TestCircuitBreakerDisabled(t *testing.T) {
+	p := &fallbackProvider{}
+	for i := 0; i < 10; i++ {
+		p.failed(FallbackConfig{})
+	}
+	if !p.available() {
+		t.Error("a zero threshold must never open the circuit")
+	}
+}
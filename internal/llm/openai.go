@@ -2,29 +2,39 @@ package llm
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"strings"
+	"net/http"
 
+	"github.com/cosmos-link/gen-code/internal/prompts"
 	openai "github.com/sashabaranov/go-openai"
 )
 
 // OpenAIClient implements the Client interface for OpenAI
 type OpenAIClient struct {
 	client *openai.Client
-	model  string
+	cfg    ModelConfig
+	keyID  string
 }
 
-// NewOpenAIClient creates a new OpenAI client
-func NewOpenAIClient(apiKey, baseURL string) *OpenAIClient {
+// NewOpenAIClient creates a new OpenAI client. httpClient may be nil to use
+// the default client.
+func NewOpenAIClient(apiKey, baseURL string, cfg ModelConfig, httpClient *http.Client) *OpenAIClient {
 	config := openai.DefaultConfig(apiKey)
 	if baseURL != "" {
 		config.BaseURL = baseURL
 	}
+	if httpClient != nil {
+		config.HTTPClient = httpClient
+	}
 	
+	if cfg.Model == "" {
+		cfg.Model = "gpt-4-turbo-preview"
+	}
+
 	return &OpenAIClient{
 		client: openai.NewClientWithConfig(config),
-		model:  "gpt-4-turbo-preview",
+		cfg:    cfg,
+		keyID:  KeyID(apiKey),
 	}
 }
 
@@ -34,36 +44,18 @@ func (c *OpenAIClient) GetModelName() string {
 }
 
 // GenerateProject generates a complete project structure and files
-func (c *OpenAIClient) GenerateProject(ctx context.Context, prompt string) (*GeneratedProject, error) {
-	systemPrompt := `You are a professional code generation assistant. Based on user requirements, generate complete project structure and code.
-
-Please return a JSON response in the following format:
-{
-  "name": "project name",
-  "description": "project description",
-  "files": [
-    {
-      "path": "file path",
-      "content": "file content (keep concise)",
-      "type": "file type (go/python/js/md etc.)"
-    }
-  ]
-}
-
-IMPORTANT:
-1. Limit to 5 files maximum
-2. Keep file content concise with core functionality only
-3. README.md should be brief and clear
-4. Ensure complete valid JSON response without truncation
-5. Properly escape strings in file content
-6. Supported file types: go, py, js, ts, md, json, yaml`
+func (c *OpenAIClient) GenerateProject(ctx context.Context, prompt string, opts CallOptions) (*GeneratedProject, error) {
+	systemPrompt, err := renderPrompt(opts.Prompts, prompts.SetEnglish, prompts.Project, "")
+	if err != nil {
+		return nil, err
+	}
 
 	userPrompt := fmt.Sprintf("Please generate a project based on the following requirements:\n%s", prompt)
 
-	resp, err := c.client.CreateChatCompletion(
-		ctx,
+	resp, err := c.complete(
+		ctx, opts, OperationGenerateProject,
 		openai.ChatCompletionRequest{
-			Model: c.model,
+			Model: c.cfg.Model,
 			Messages: []openai.ChatCompletionMessage{
 				{
 					Role:    openai.ChatMessageRoleSystem,
@@ -87,37 +79,22 @@ IMPORTANT:
 		return nil, fmt.Errorf("no response from OpenAI API")
 	}
 
-	content := resp.Choices[0].Message.Content
-	content = extractJSON(content)
-
-	// Check if content is truncated
-	if !strings.HasSuffix(strings.TrimSpace(content), "}") && !strings.HasSuffix(strings.TrimSpace(content), "]") {
-		return nil, fmt.Errorf("response appears to be truncated, please simplify your prompt or reduce project complexity")
-	}
-
-	var project GeneratedProject
-	if err := json.Unmarshal([]byte(content), &project); err != nil {
-		contentPreview := content
-		if len(content) > 500 {
-			contentPreview = content[:500] + "..." + content[len(content)-100:]
-		}
-		return nil, fmt.Errorf("failed to parse JSON response: %w. Preview: %s", err, contentPreview)
-	}
-
-	return &project, nil
+	return parseProjectContent(resp.Choices[0].Message.Content)
 }
 
 // GenerateFile generates a single file
-func (c *OpenAIClient) GenerateFile(ctx context.Context, prompt string, filePath string, fileType string) (string, error) {
-	systemPrompt := fmt.Sprintf(`You are a professional code generation assistant. Generate content for a %s file based on user requirements.
-Only return the actual file content, without any explanations or markdown formatting.`, fileType)
+func (c *OpenAIClient) GenerateFile(ctx context.Context, prompt string, filePath string, fileType string, opts CallOptions) (string, error) {
+	systemPrompt, err := renderPrompt(opts.Prompts, prompts.SetEnglish, prompts.File, fileType)
+	if err != nil {
+		return "", err
+	}
 
 	userPrompt := fmt.Sprintf("Please generate content for file %s:\n%s", filePath, prompt)
 
-	resp, err := c.client.CreateChatCompletion(
-		ctx,
+	resp, err := c.complete(
+		ctx, opts, OperationGenerateFile,
 		openai.ChatCompletionRequest{
-			Model: c.model,
+			Model: c.cfg.Model,
 			Messages: []openai.ChatCompletionMessage{
 				{
 					Role:    openai.ChatMessageRoleSystem,
@@ -143,3 +120,48 @@ Only return the actual file content, without any explanations or markdown format
 
 	return resp.Choices[0].Message.Content, nil
 }
+
+// ReviseProject changes existing files according to an instruction
+func (c *OpenAIClient) ReviseProject(ctx context.Context, instruction string, files []FileInfo, opts CallOptions) (*GeneratedProject, error) {
+	systemPrompt, err := renderPrompt(opts.Prompts, prompts.SetEnglish, prompts.Revise, "")
+	if err != nil {
+		return nil, err
+	}
+
+	userPrompt := fmt.Sprintf("Instruction:\n%s\n\nExisting files:\n%s", instruction, formatFiles(files))
+
+	resp, err := c.complete(
+		ctx, opts, OperationReviseProject,
+		openai.ChatCompletionRequest{
+			Model: c.cfg.Model,
+			Messages: []openai.ChatCompletionMessage{
+				{
+					Role:    openai.ChatMessageRoleSystem,
+					Content: systemPrompt,
+				},
+				{
+					Role:    openai.ChatMessageRoleUser,
+					Content: userPrompt,
+				},
+			},
+			Temperature: 0.2,
+			MaxTokens:   8000,
+		},
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to call OpenAI API: %w", err)
+	}
+
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("no response from OpenAI API")
+	}
+
+	return parseProjectContent(resp.Choices[0].Message.Content)
+}
+
+// complete calls the chat completions API with the configured and requested
+// model and sampling parameters, reporting usage for operation
+func (c *OpenAIClient) complete(ctx context.Context, opts CallOptions, operation string, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	return chatCompletion(ctx, opts, c.client, "openai", c.keyID, operation, applySampling(opts.Params, "openai", c.cfg, req))
+}
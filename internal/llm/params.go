@@ -0,0 +1,81 @@
+package llm
+
+import (
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// ModelConfig is a provider's model and sampling configuration. Zero
+// values keep each operation's defaults: temperature 0.7 for generation
+// and 0.2 for revision, and 8000 or 2000 max tokens.
+type ModelConfig struct {
+	Model       string   `json:"model"`
+	Temperature *float32 `json:"temperature,omitempty"`
+	MaxTokens   int      `json:"max_tokens,omitempty"`
+}
+
+// Params are per-request overrides of a provider's model configuration
+type Params struct {
+	// Provider whose model Model replaces; other providers in a fallback
+	// chain keep their own model
+	Provider string
+	Model    string
+
+	Temperature *float32
+	MaxTokens   int
+}
+
+// CallOptions are the per-request settings of an LLM call. The zero value
+// uses each provider's configuration and the embedded prompts, and neither
+// tracks usage nor caches responses.
+type CallOptions struct {
+	Params  Params        // Model and sampling overrides
+	Prompts PromptOptions // System prompts
+
+	// Usage receives the usage of every call; optional
+	Usage UsageTracker
+
+	// Cache serves repeated requests when set. With RefreshCache, cached
+	// responses are ignored and replaced by fresh ones.
+	Cache        *Cache
+	RefreshCache bool
+
+	// PreferredProvider is tried first by a fallback chain; unknown names
+	// leave the configured order unchanged
+	PreferredProvider string
+
+	// OnFailover is told when a fallback chain moves on to the next
+	// provider; optional
+	OnFailover FailoverObserver
+}
+
+// sampling returns the model, temperature and max tokens for a call by
+// provider: the operation's defaults, overridden by the provider's
+// configuration and then by the request's params
+func sampling(params Params, provider string, cfg ModelConfig, temperature float32, maxTokens int) (string, float32, int) {
+	model := cfg.Model
+	if cfg.Temperature != nil {
+		temperature = *cfg.Temperature
+	}
+	if cfg.MaxTokens > 0 {
+		maxTokens = cfg.MaxTokens
+	}
+
+	if params.Model != "" && params.Provider == provider {
+		model = params.Model
+	}
+	if params.Temperature != nil {
+		temperature = *params.Temperature
+	}
+	if params.MaxTokens > 0 {
+		maxTokens = params.MaxTokens
+	}
+
+	return model, temperature, maxTokens
+}
+
+// applySampling sets the model, temperature and max tokens of a chat
+// completions request, keeping the request's values as the defaults
+func applySampling(params Params, provider string, cfg ModelConfig, req openai.ChatCompletionRequest) openai.ChatCompletionRequest {
+	req.Model, req.Temperature, req.MaxTokens = sampling(params, provider, cfg, req.Temperature, req.MaxTokens)
+	return req
+}
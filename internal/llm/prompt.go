@@ -0,0 +1,35 @@
+package llm
+
+import (
+	"fmt"
+
+	"github.com/cosmos-link/gen-code/internal/prompts"
+)
+
+// PromptOptions selects the system prompts for a request
+type PromptOptions struct {
+	Library *prompts.Library // Defaults to the embedded prompts
+	Set     string           // Prompt set, e.g. en or zh; defaults to the provider's
+	Data    prompts.Data
+}
+
+// renderPrompt renders system prompt name with opts, using defaultSet when
+// opts selects no set
+func renderPrompt(opts PromptOptions, defaultSet, name string, fileType string) (string, error) {
+	library := opts.Library
+	if library == nil {
+		library = prompts.Default()
+	}
+	set := opts.Set
+	if set == "" {
+		set = defaultSet
+	}
+	data := opts.Data
+	data.FileType = fileType
+
+	prompt, err := library.Render(set, name, data)
+	if err != nil {
+		return "", fmt.Errorf("failed to render prompt: %w", err)
+	}
+	return prompt, nil
+}
@@ -0,0 +1,127 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// Operations reported in usage records
+const (
+	OperationGenerateProject = "generate_project"
+	OperationGenerateFile    = "generate_file"
+	OperationReviseProject   = "revise_project"
+)
+
+// ErrTokenLimit is returned when a call would exceed the token limit of
+// the task it is made for
+var ErrTokenLimit = errors.New("token limit exceeded")
+
+// Usage describes the tokens consumed by one LLM call
+type Usage struct {
+	Provider         string
+	Model            string
+	KeyID            string // Masked API key the call was billed to
+	Operation        string
+	Temperature      float32
+	MaxTokens        int
+	PromptTokens     int
+	CompletionTokens int
+	TotalTokens      int
+	Latency          time.Duration
+	Time             time.Time
+	Cached           bool // Served from the response cache; the tokens were spent by an earlier call
+}
+
+// UsageTracker receives the usage of every LLM call made with the
+// CallOptions it is set in
+type UsageTracker interface {
+	// Allow returns an error if no further calls may be made
+	Allow() error
+
+	// Record records a completed call. An error aborts the caller, whose
+	// response is discarded.
+	Record(usage Usage) error
+}
+
+// KeyID masks an API key down to its last four characters so usage can be
+// attributed to it without storing the key
+func KeyID(apiKey string) string {
+	if len(apiKey) < 8 {
+		return "****"
+	}
+	return "****" + apiKey[len(apiKey)-4:]
+}
+
+// trackCall runs call, an LLM request, if tracker allows it and reports the
+// usage call returns. The tracker may reject a completed call; a nil tracker
+// allows every call.
+func trackCall(tracker UsageTracker, call func() (Usage, error)) error {
+	if tracker != nil {
+		if err := tracker.Allow(); err != nil {
+			return err
+		}
+	}
+
+	start := time.Now()
+	usage, err := call()
+	if err != nil || tracker == nil {
+		return err
+	}
+
+	usage.Latency = time.Since(start)
+	usage.Time = start
+	return tracker.Record(usage)
+}
+
+// chatCompletion calls the chat completions API, or serves the response
+// from the cache in opts, and reports the call's usage to the tracker in opts
+func chatCompletion(ctx context.Context, opts CallOptions, client *openai.Client, provider, keyID, operation string, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	// go-openai omits a zero temperature, which servers read as their
+	// default, so send the smallest non-zero value instead
+	sent := req
+	if sent.Temperature == 0 {
+		sent.Temperature = math.SmallestNonzeroFloat32
+	}
+
+	key := req
+	key.Messages = make([]openai.ChatCompletionMessage, len(req.Messages))
+	for i, message := range req.Messages {
+		message.Content = normalizePrompt(message.Content)
+		key.Messages[i] = message
+	}
+
+	// Only complete responses are cached
+	var resp openai.ChatCompletionResponse
+	keep := func() bool {
+		return len(resp.Choices) > 0 && resp.Choices[0].FinishReason != openai.FinishReasonLength
+	}
+
+	err := cachedCall(opts, provider, key, &resp, keep, func() (Usage, error) {
+		var err error
+		resp, err = client.CreateChatCompletion(ctx, sent)
+		if err != nil {
+			return Usage{}, err
+		}
+
+		model := resp.Model
+		if model == "" {
+			model = req.Model
+		}
+		return Usage{
+			Provider:         provider,
+			Model:            model,
+			KeyID:            keyID,
+			Operation:        operation,
+			Temperature:      req.Temperature,
+			MaxTokens:        req.MaxTokens,
+			PromptTokens:     resp.Usage.PromptTokens,
+			CompletionTokens: resp.Usage.CompletionTokens,
+			TotalTokens:      resp.Usage.TotalTokens,
+		}, nil
+	})
+	return resp, err
+}
@@ -0,0 +1,216 @@
+// Package prompts renders the system prompts sent to LLM providers from
+// text/template files. Defaults are embedded in the binary; a directory on
+// disk can override them or add prompt sets.
+package prompts
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// Prompt names; every prompt set provides one template file per name,
+// e.g. en/project.tmpl
+const (
+	Project = "project" // A new project as a JSON document
+	File    = "file"    // The content of a single file
+	Revise  = "revise"  // The files an instruction adds, changes or deletes
+)
+
+// names lists the prompts every set must provide
+var names = []string{Project, File, Revise}
+
+// Built-in prompt sets
+const (
+	SetEnglish = "en"
+	SetChinese = "zh"
+)
+
+// DefaultMaxFiles is the file limit stated in project prompts when none is
+// configured
+const DefaultMaxFiles = 5
+
+// templateExt is the extension of prompt template files
+const templateExt = ".tmpl"
+
+//go:embed defaults
+var defaults embed.FS
+
+// Data holds the variables available to prompt templates
+type Data struct {
+	MaxFiles    int    // Most files a new project may have
+	Language    string // Target programming language, e.g. Go
+	Conventions string // Coding conventions the code must follow
+	FileType    string // Type of the file the file prompt asks for
+}
+
+// Library holds the prompt sets loaded from the embedded defaults and an
+// optional override directory
+type Library struct {
+	dir    string
+	reload bool
+
+	mu   sync.RWMutex
+	sets map[string]map[string]*template.Template
+}
+
+// Load loads the embedded prompt sets and overlays dir, whose
+// subdirectories replace individual templates of a built-in set or add new
+// sets. A missing or empty dir uses the defaults. With reload, dir is read
+// again on every render so prompts can be edited without a restart.
+func Load(dir string, reload bool) (*Library, error) {
+	l := &Library{dir: dir, reload: reload}
+	if err := l.load(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+var (
+	defaultOnce    sync.Once
+	defaultLibrary *Library
+)
+
+// Default returns the library of embedded prompt sets
+func Default() *Library {
+	defaultOnce.Do(func() {
+		l, err := Load("", false)
+		if err != nil {
+			panic(fmt.Sprintf("invalid embedded prompts: %v", err))
+		}
+		defaultLibrary = l
+	})
+	return defaultLibrary
+}
+
+// Render renders prompt name of set with data
+func (l *Library) Render(set, name string, data Data) (string, error) {
+	if l.reload {
+		if err := l.load(); err != nil {
+			return "", err
+		}
+	}
+
+	l.mu.RLock()
+	tmpl, ok := l.sets[set][name]
+	l.mu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("unknown prompt set: %s", set)
+	}
+
+	if data.MaxFiles <= 0 {
+		data.MaxFiles = DefaultMaxFiles
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to render %s/%s prompt: %w", set, name, err)
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+// Has reports whether the library has a prompt set
+func (l *Library) Has(set string) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	_, ok := l.sets[set]
+	return ok
+}
+
+// Sets returns the names of the prompt sets, sorted
+func (l *Library) Sets() []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	sets := make([]string, 0, len(l.sets))
+	for set := range l.sets {
+		sets = append(sets, set)
+	}
+	sort.Strings(sets)
+	return sets
+}
+
+// load parses the embedded sets and the override directory
+func (l *Library) load() error {
+	sets := make(map[string]map[string]*template.Template)
+
+	embedded, err := fs.Sub(defaults, "defaults")
+	if err != nil {
+		return err
+	}
+	if err := parseSets(embedded, sets); err != nil {
+		return err
+	}
+
+	if l.dir != "" {
+		if _, err := os.Stat(l.dir); err == nil {
+			if err := parseSets(os.DirFS(l.dir), sets); err != nil {
+				return err
+			}
+		} else if !os.IsNotExist(err) {
+			return fmt.Errorf("failed to read prompts directory: %w", err)
+		}
+	}
+
+	for set, templates := range sets {
+		for _, name := range names {
+			if _, ok := templates[name]; !ok {
+				return fmt.Errorf("prompt set %s is missing %s%s", set, name, templateExt)
+			}
+		}
+	}
+
+	l.mu.Lock()
+	l.sets = sets
+	l.mu.Unlock()
+	return nil
+}
+
+// parseSets parses the templates of every set directory in fsys into sets
+func parseSets(fsys fs.FS, sets map[string]map[string]*template.Template) error {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return fmt.Errorf("failed to read prompts directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		set := entry.Name()
+
+		files, err := fs.ReadDir(fsys, set)
+		if err != nil {
+			return fmt.Errorf("failed to read prompt set %s: %w", set, err)
+		}
+		for _, file := range files {
+			if file.IsDir() || path.Ext(file.Name()) != templateExt {
+				continue
+			}
+			name := strings.TrimSuffix(file.Name(), templateExt)
+
+			text, err := fs.ReadFile(fsys, path.Join(set, file.Name()))
+			if err != nil {
+				return fmt.Errorf("failed to read prompt %s/%s: %w", set, file.Name(), err)
+			}
+			tmpl, err := template.New(name).Parse(string(text))
+			if err != nil {
+				return fmt.Errorf("failed to parse prompt %s/%s: %w", set, file.Name(), err)
+			}
+
+			if sets[set] == nil {
+				sets[set] = make(map[string]*template.Template)
+			}
+			sets[set][name] = tmpl
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,151 @@
+package prompts
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writePrompts writes prompt files, keyed by set/name.tmpl, under dir
+func writePrompts(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDefault(t *testing.T) {
+	l := Default()
+	if got := strings.Join(l.Sets(), ","); got != "en,zh" {
+		t.Errorf("sets = %s, want en,zh", got)
+	}
+
+	for _, set := range l.Sets() {
+		for _, name := range names {
+			text, err := l.Render(set, name, Data{Language: "Go", Conventions: "Use tabs.", FileType: "go"})
+			if err != nil {
+				t.Errorf("Render(%s, %s): %v", set, name, err)
+				continue
+			}
+			if text == "" || strings.Contains(text, "{{") {
+				t.Errorf("Render(%s, %s) = %q", set, name, text)
+			}
+		}
+	}
+
+	text, _ := l.Render(SetEnglish, Project, Data{})
+	if !strings.Contains(text, "Limit to 5 files maximum") {
+		t.Errorf("project prompt does not state DefaultMaxFiles:\n%s", text)
+	}
+	if strings.Contains(text, "Target language") {
+		t.Error("project prompt names a target language without one")
+	}
+}
+
+func TestOverrides(t *testing.T) {
+	complete := map[string]string{
+		"fr/project.tmpl": "Projet de {{.MaxFiles}} fichiers",
+		"fr/file.tmpl":    "Fichier {{.FileType}}",
+		"fr/revise.tmpl":  "Révision",
+	}
+
+	tests := []struct {
+		name    string
+		files   map[string]string // nil for a missing directory
+		set     string
+		prompt  string
+		want    string // Rendered prompt, or its start when prefix is set
+		prefix  bool
+		wantErr string // Error from Load
+	}{
+		{"missing directory uses the defaults", nil, SetEnglish, File, "You are a professional code generation assistant", true, ""},
+		{"override replaces one template", map[string]string{"en/project.tmpl": "Custom: {{.MaxFiles}} files in {{.Language}}"}, SetEnglish, Project, "Custom: 3 files in Go", false, ""},
+		{"other templates of the set stay built in", map[string]string{"en/project.tmpl": "Custom"}, SetEnglish, Revise, "You are", true, ""},
+		{"other sets stay built in", map[string]string{"en/project.tmpl": "Custom"}, SetChinese, Project, "你", true, ""},
+		{"new set", complete, "fr", Project, "Projet de 3 fichiers", false, ""},
+		{"non-template files are ignored", map[string]string{"en/notes.md": "{{", "README.md": "{{"}, SetEnglish, File, "You are", true, ""},
+		{"incomplete new set", map[string]string{"fr/project.tmpl": "Projet"}, "", "", "", false, "prompt set fr is missing file.tmpl"},
+		{"broken template", map[string]string{"en/file.tmpl": "{{if .FileType}}unclosed"}, "", "", "", false, "failed to parse prompt en/file.tmpl"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "prompts")
+			if tt.files != nil {
+				writePrompts(t, dir, tt.files)
+			}
+
+			l, err := Load(dir, false)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("err = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+
+			got, err := l.Render(tt.set, tt.prompt, Data{MaxFiles: 3, Language: "Go"})
+			if err != nil {
+				t.Fatalf("Render: %v", err)
+			}
+			if tt.prefix && !strings.HasPrefix(got, tt.want) || !tt.prefix && got != tt.want {
+				t.Errorf("Render = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	dir := t.TempDir()
+	writePrompts(t, dir, map[string]string{"en/file.tmpl": "{{.Missing}}"})
+	l, err := Load(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := l.Render("fr", Project, Data{}); err == nil || err.Error() != "unknown prompt set: fr" {
+		t.Errorf("unknown set err = %v", err)
+	}
+	if l.Has("fr") {
+		t.Error("Has(fr) = true")
+	}
+	if _, err := l.Render(SetEnglish, File, Data{}); err == nil || !strings.Contains(err.Error(), "failed to render en/file prompt") {
+		t.Errorf("execution err = %v, want a render error", err)
+	}
+}
+
+func TestReload(t *testing.T) {
+	dir := t.TempDir()
+	writePrompts(t, dir, map[string]string{"en/file.tmpl": "first"})
+
+	static, err := Load(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reloading, err := Load(dir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writePrompts(t, dir, map[string]string{"en/file.tmpl": "second"})
+	if got, _ := static.Render(SetEnglish, File, Data{}); got != "first" {
+		t.Errorf("static Render = %q, want first", got)
+	}
+	if got, _ := reloading.Render(SetEnglish, File, Data{}); got != "second" {
+		t.Errorf("reloading Render = %q, want second", got)
+	}
+
+	// A broken edit fails the render instead of serving a stale prompt
+	writePrompts(t, dir, map[string]string{"en/file.tmpl": "{{end}}"})
+	if _, err := reloading.Render(SetEnglish, File, Data{}); err == nil || !strings.Contains(err.Error(), "failed to parse prompt") {
+		t.Errorf("broken edit err = %v, want a parse error", err)
+	}
+}
@@ -0,0 +1,74 @@
+package sandbox
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// ContainerRunner isolates commands in a container runtime such as docker
+// or podman, with networking disabled and a read-only root filesystem
+type ContainerRunner struct {
+	runtime string
+}
+
+// NewContainerRunner creates a runner using the given runtime binary
+func NewContainerRunner(runtime string) *ContainerRunner {
+	if runtime == "" {
+		runtime = "docker"
+	}
+	return &ContainerRunner{runtime: runtime}
+}
+
+// Name returns the runner name
+func (r *ContainerRunner) Name() string {
+	return "container:" + filepath.Base(r.runtime)
+}
+
+// Run executes spec in a throwaway container with the project mounted at /work
+func (r *ContainerRunner) Run(ctx context.Context, spec Spec) (*Result, error) {
+	runtime, err := exec.LookPath(r.runtime)
+	if err != nil {
+		return nil, fmt.Errorf("%s not found: %w", r.runtime, ErrUnavailable)
+	}
+	if spec.Image == "" {
+		return nil, fmt.Errorf("no container image configured for %v", spec.Command)
+	}
+
+	dir, err := filepath.Abs(spec.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{
+		"run", "--rm",
+		"--network", "none",
+		"--read-only",
+		"--tmpfs", "/tmp:rw,exec",
+		"--pids-limit", "256",
+		"--security-opt", "no-new-privileges",
+		"--user", fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid()),
+		"--volume", dir + ":/work",
+		"--workdir", "/work",
+		"--env", "HOME=/tmp",
+	}
+	if spec.Limits.MemoryMB > 0 {
+		args = append(args, "--memory", fmt.Sprintf("%dm", spec.Limits.MemoryMB))
+	}
+	if spec.Limits.CPUSeconds > 0 {
+		args = append(args, "--ulimit", fmt.Sprintf("cpu=%d", spec.Limits.CPUSeconds))
+	}
+	for _, path := range spec.ReadOnly {
+		args = append(args, "--volume", path+":"+path+":ro")
+	}
+	for _, kv := range spec.Env {
+		args = append(args, "--env", kv)
+	}
+
+	args = append(args, spec.Image)
+	args = append(args, spec.Command...)
+
+	return execute(ctx, spec, runtime, args...)
+}
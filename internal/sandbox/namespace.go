@@ -0,0 +1,151 @@
+package sandbox
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// toolchainDirs are the host paths mounted read-only into the namespace:
+// system binaries and libraries, and the configuration they need. Paths
+// missing on the host are skipped.
+var toolchainDirs = []string{
+	"/usr",
+	"/bin",
+	"/sbin",
+	"/lib",
+	"/lib32",
+	"/lib64",
+	"/libx32",
+	"/etc/alternatives",
+	"/etc/ld.so.cache",
+	"/etc/ssl",
+	"/etc/ca-certificates",
+}
+
+// NamespaceRunner isolates commands with Linux namespaces via bubblewrap.
+// Only the toolchain directories, the command's own installation and the
+// spec's read-only paths are visible from the host; the project directory
+// is the only writable bind mount and the network namespace has no
+// interfaces.
+type NamespaceRunner struct {
+	bwrap string
+	dirs  []string
+}
+
+// NewNamespaceRunner creates a runner using the bwrap binary at path,
+// or from PATH when path is empty. dirs are host paths mounted read-only
+// in addition to toolchainDirs, such as a toolchain under /opt.
+func NewNamespaceRunner(path string, dirs []string) *NamespaceRunner {
+	if path == "" {
+		path = "bwrap"
+	}
+	return &NamespaceRunner{bwrap: path, dirs: dirs}
+}
+
+// Name returns the runner name
+func (r *NamespaceRunner) Name() string {
+	return "namespace"
+}
+
+// Run executes spec inside fresh user, mount, PID, IPC, UTS and network namespaces
+func (r *NamespaceRunner) Run(ctx context.Context, spec Spec) (*Result, error) {
+	bwrap, err := exec.LookPath(r.bwrap)
+	if err != nil {
+		return nil, fmt.Errorf("bwrap not found: %w", ErrUnavailable)
+	}
+
+	dir, err := filepath.Abs(spec.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return execute(ctx, spec, bwrap, r.args(spec, dir)...)
+}
+
+// args returns the bwrap arguments running spec with dir as the project
+// directory
+func (r *NamespaceRunner) args(spec Spec, dir string) []string {
+	args := []string{
+		"--dev", "/dev",
+		"--proc", "/proc",
+		"--tmpfs", "/tmp",
+	}
+	for _, d := range toolchainDirs {
+		args = append(args, "--ro-bind-try", d, d)
+	}
+
+	// Mounted after /tmp so that paths below it are not hidden
+	readOnly := append(append([]string(nil), r.dirs...), spec.ReadOnly...)
+	path := "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin:/usr/local/go/bin"
+	if root, bin, ok := installation(spec.Command); ok {
+		readOnly = append(readOnly, root)
+		path = bin + ":" + path
+	}
+	for _, d := range readOnly {
+		args = append(args, "--ro-bind", d, d)
+	}
+
+	args = append(args,
+		"--bind", dir, dir,
+		"--chdir", dir,
+		"--unshare-all", // Includes the network namespace
+		"--die-with-parent",
+		"--new-session",
+		"--clearenv",
+		"--setenv", "PATH", path,
+		"--setenv", "HOME", "/tmp",
+	)
+	for _, kv := range spec.Env {
+		if key, value, ok := strings.Cut(kv, "="); ok && key != "" {
+			args = append(args, "--setenv", key, value)
+		}
+	}
+
+	args = append(args, "--", "/bin/sh", "-c", ulimitScript(spec.Limits), "sandbox")
+	return append(args, spec.Command...)
+}
+
+// installation returns the installation root and bin directory of the
+// command's program when it is outside toolchainDirs, such as a Go root in
+// /opt/go or /home/ci/sdk/go1.24, so that it and its sibling tools run in
+// the namespace
+func installation(command []string) (root, bin string, ok bool) {
+	if len(command) == 0 {
+		return "", "", false
+	}
+	program, err := exec.LookPath(command[0])
+	if err != nil {
+		return "", "", false
+	}
+	if resolved, err := filepath.EvalSymlinks(program); err == nil {
+		program = resolved
+	}
+	program, err = filepath.Abs(program)
+	if err != nil {
+		return "", "", false
+	}
+
+	for _, d := range toolchainDirs {
+		if strings.HasPrefix(program, d+"/") {
+			return "", "", false
+		}
+	}
+
+	bin = filepath.Dir(program)
+	return filepath.Dir(bin), bin, true
+}
+
+// ulimitScript applies CPU and memory limits before exec'ing the command
+func ulimitScript(limits Limits) string {
+	script := ""
+	if limits.CPUSeconds > 0 {
+		script += fmt.Sprintf("ulimit -t %d; ", limits.CPUSeconds)
+	}
+	if limits.MemoryMB > 0 {
+		script += fmt.Sprintf("ulimit -v %d; ", limits.MemoryMB*1024)
+	}
+	return script + `exec "$@"`
+}
@@ -0,0 +1,114 @@
+package sandbox
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// mounts returns the source paths of the bwrap binds of the given kind, in
+// order, with the position of each
+func mounts(args []string, kind string) map[string]int {
+	found := make(map[string]int)
+	for i := 0; i+2 < len(args); i++ {
+		if args[i] == kind {
+			found[args[i+1]] = i
+		}
+	}
+	return found
+}
+
+func TestNamespaceArgsMountOnlyToolchain(t *testing.T) {
+	r := NewNamespaceRunner("", []string{"/opt/node"})
+	spec := Spec{
+		Command:  []string{"sh", "-c", "true"},
+		Env:      []string{"GOMODCACHE=/tmp/modcache", "GOSUMDB=off"},
+		ReadOnly: []string{"/tmp/modcache"},
+	}
+	args := r.args(spec, "/srv/work/task-1")
+
+	roBinds := mounts(args, "--ro-bind")
+	if _, ok := roBinds["/"]; ok {
+		t.Fatal("the host root is mounted")
+	}
+	for _, d := range []string{"/opt/node", "/tmp/modcache"} {
+		if _, ok := roBinds[d]; !ok {
+			t.Errorf("%s is not mounted read-only: %v", d, args)
+		}
+	}
+	if i, ok := mounts(args, "--tmpfs")["/tmp"]; !ok || roBinds["/tmp/modcache"] < i {
+		t.Errorf("the module cache must be mounted after /tmp: %v", args)
+	}
+	for _, d := range []string{"/usr", "/lib", "/etc/ssl"} {
+		if _, ok := mounts(args, "--ro-bind-try")[d]; !ok {
+			t.Errorf("toolchain dir %s is not mounted", d)
+		}
+	}
+	for _, d := range []string{"/home", "/root", "/etc", "/var"} {
+		if _, ok := roBinds[d]; ok {
+			t.Errorf("host dir %s is mounted", d)
+		}
+	}
+
+	if binds := mounts(args, "--bind"); len(binds) != 1 || binds["/srv/work/task-1"] == 0 {
+		t.Errorf("writable binds = %v, want only the project dir", binds)
+	}
+
+	env := strings.Join(args, " ")
+	for _, kv := range []string{"--clearenv", "--setenv GOMODCACHE /tmp/modcache", "--setenv GOSUMDB off", "--setenv HOME /tmp"} {
+		if !strings.Contains(env, kv) {
+			t.Errorf("args lack %q: %v", kv, args)
+		}
+	}
+	if !slices.Equal(args[len(args)-3:], spec.Command) {
+		t.Errorf("args do not end with the command: %v", args)
+	}
+}
+
+func TestNamespaceArgsMountCommandInstallation(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "go1.24")
+	bin := filepath.Join(root, "bin")
+	if err := os.MkdirAll(bin, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(bin, "go"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	args := NewNamespaceRunner("", nil).args(Spec{Command: []string{"go", "test", "./..."}}, "/srv/work/task-1")
+
+	if _, ok := mounts(args, "--ro-bind")[root]; !ok {
+		t.Errorf("the Go root %s is not mounted: %v", root, args)
+	}
+	if i := slices.Index(args, "PATH"); i < 0 || !strings.HasPrefix(args[i+1], bin+":") {
+		t.Errorf("PATH does not start with %s: %v", bin, args)
+	}
+}
+
+func TestInstallationInsideToolchainDirs(t *testing.T) {
+	if _, _, ok := installation([]string{"sh"}); ok {
+		t.Error("a program under /bin or /usr needs no extra mount")
+	}
+	if _, _, ok := installation([]string{"no-such-program-xyz"}); ok {
+		t.Error("a missing program has no installation")
+	}
+}
+
+func TestUlimitScript(t *testing.T) {
+	tests := []struct {
+		limits Limits
+		want   string
+	}{
+		{Limits{}, `exec "$@"`},
+		{Limits{CPUSeconds: 30}, `ulimit -t 30; exec "$@"`},
+		{Limits{CPUSeconds: 30, MemoryMB: 512}, `ulimit -t 30; ulimit -v 524288; exec "$@"`},
+	}
+	for _, tt := range tests {
+		if got := ulimitScript(tt.limits); got != tt.want {
+			t.Errorf("ulimitScript(%+v) = %q, want %q", tt.limits, got, tt.want)
+		}
+	}
+}
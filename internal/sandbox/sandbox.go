@@ -0,0 +1,114 @@
+package sandbox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+// ErrUnavailable is returned when a runner's isolation mechanism is not
+// installed on this host. Callers must not fall back to running unsandboxed.
+var ErrUnavailable = errors.New("sandbox unavailable")
+
+// Limits bounds the resources a sandboxed command may use
+type Limits struct {
+	CPUSeconds     int           // CPU time limit, 0 for none
+	MemoryMB       int           // Address space / memory limit, 0 for none
+	Timeout        time.Duration // Wall-clock limit
+	MaxOutputBytes int           // Captured output beyond this is truncated
+}
+
+// Spec describes a command to run in a sandbox
+type Spec struct {
+	Dir      string   // Project directory; the only writable host path, so pass a throwaway copy
+	Command  []string // Program and arguments
+	Env      []string // Extra environment variables
+	ReadOnly []string // Extra host paths mounted read-only at the same location, such as a module cache
+	Image    string   // Container image, used only by container runners
+	Limits   Limits
+}
+
+// Result is the outcome of a sandboxed command
+type Result struct {
+	ExitCode  int
+	Output    string
+	Duration  time.Duration
+	TimedOut  bool
+	Truncated bool
+}
+
+// Passed reports whether the command exited successfully in time
+func (r *Result) Passed() bool {
+	return r.ExitCode == 0 && !r.TimedOut
+}
+
+// Runner executes commands in isolation from the host
+type Runner interface {
+	// Name identifies the runner in logs and task results
+	Name() string
+
+	// Run executes spec and returns its result. A non-zero exit code is not
+	// an error; errors mean the sandbox itself could not run the command.
+	Run(ctx context.Context, spec Spec) (*Result, error)
+}
+
+// limitedBuffer keeps at most max bytes of output
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	max       int
+	truncated bool
+}
+
+// Write implements io.Writer, discarding bytes beyond the limit
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.max > 0 && b.buf.Len()+len(p) > b.max {
+		b.buf.Write(p[:b.max-b.buf.Len()])
+		b.truncated = true
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+// execute runs a prepared command with the spec's timeout and output limit
+func execute(ctx context.Context, spec Spec, name string, args ...string) (*Result, error) {
+	timeout := spec.Limits.Timeout
+	if timeout == 0 {
+		timeout = 5 * time.Minute
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	out := &limitedBuffer{max: spec.Limits.MaxOutputBytes}
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = out
+	cmd.Stderr = out
+	cmd.WaitDelay = 5 * time.Second
+
+	start := time.Now()
+	err := cmd.Run()
+	result := &Result{
+		Output:    out.buf.String(),
+		Duration:  time.Since(start),
+		Truncated: out.truncated,
+	}
+
+	if ctx.Err() == context.DeadlineExceeded {
+		result.TimedOut = true
+		result.ExitCode = -1
+		return result, nil
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case errors.As(err, &exitErr):
+		result.ExitCode = exitErr.ExitCode()
+	default:
+		return nil, fmt.Errorf("failed to run %s: %w", name, err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,292 @@
+package scaffold
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/cosmos-link/gen-code/internal/llm"
+)
+
+// assets holds the embedded scaffolding files. Assets are rendered with
+// text/template using [[ ]] delimiters so GitHub Actions ${{ }} expressions
+// can be used verbatim.
+//
+//go:embed assets
+var assets embed.FS
+
+// LicenseNone disables the LICENSE file
+const LicenseNone = "none"
+
+// ecosystem groups languages that share a .gitignore, CI workflow and
+// Dockerfile
+type ecosystem struct {
+	name      string
+	languages []string
+}
+
+// ecosystems in order of preference for the Dockerfile of a mixed project
+var ecosystems = []ecosystem{
+	{name: "go", languages: []string{llm.LanguageGo}},
+	{name: "python", languages: []string{llm.LanguagePython}},
+	{name: "node", languages: []string{llm.LanguageJavaScript, llm.LanguageTypeScript}},
+}
+
+// Config holds scaffolding settings
+type Config struct {
+	License    string // SPDX identifier of an embedded license, or LicenseNone
+	Owner      string // Default copyright owner
+	CI         bool   // Add a GitHub Actions workflow per language
+	Dockerfile bool   // Add a Dockerfile for the primary language
+	Overwrite  bool   // Replace files the LLM already produced
+}
+
+// Request holds per-project scaffolding inputs
+type Request struct {
+	License string // Overrides Config.License when set
+	Owner   string // Overrides Config.Owner when set
+}
+
+// Scaffolder adds standard repository files to generated projects
+type Scaffolder struct {
+	cfg Config
+}
+
+// New creates a new scaffolder
+func New(cfg Config) (*Scaffolder, error) {
+	if cfg.License != "" && !HasLicense(cfg.License) {
+		return nil, fmt.Errorf("unknown license: %s (available: %s)", cfg.License, strings.Join(Licenses(), ", "))
+	}
+	return &Scaffolder{cfg: cfg}, nil
+}
+
+// Licenses returns the identifiers of the embedded licenses
+func Licenses() []string {
+	entries, _ := assets.ReadDir("assets/licenses")
+	licenses := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		licenses = append(licenses, strings.TrimSuffix(entry.Name(), ".txt"))
+	}
+	sort.Strings(licenses)
+	return licenses
+}
+
+// HasLicense reports whether a license identifier is available
+func HasLicense(license string) bool {
+	if license == LicenseNone {
+		return true
+	}
+	for _, available := range Licenses() {
+		if available == license {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply adds scaffolding files to project. Files the LLM produced are kept
+// unless the scaffolder is configured to overwrite them. It returns the
+// paths that were added or replaced.
+func (s *Scaffolder) Apply(project *llm.GeneratedProject, req Request) ([]string, error) {
+	files, err := s.files(project, req)
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]int, len(project.Files))
+	for i, file := range project.Files {
+		index[strings.ToLower(file.Path)] = i
+	}
+
+	var changed []string
+	for _, file := range files {
+		if i, exists := index[strings.ToLower(file.Path)]; exists {
+			if !s.cfg.Overwrite {
+				continue
+			}
+			project.Files[i] = file
+		} else {
+			project.Files = append(project.Files, file)
+		}
+		changed = append(changed, file.Path)
+	}
+
+	return changed, nil
+}
+
+// files renders the scaffolding files that apply to project
+func (s *Scaffolder) files(project *llm.GeneratedProject, req Request) ([]llm.FileInfo, error) {
+	present := detectEcosystems(project)
+	data := templateData{
+		Year:       time.Now().Year(),
+		Owner:      firstNonEmpty(req.Owner, s.cfg.Owner, fmt.Sprintf("The %s Authors", project.Name)),
+		GoMain:     goMainPackage(project),
+		PythonMain: pythonEntrypoint(project),
+	}
+
+	var files []llm.FileInfo
+
+	gitignore, err := s.gitignore(present)
+	if err != nil {
+		return nil, err
+	}
+	files = append(files, llm.FileInfo{Path: ".gitignore", Content: gitignore, Type: "gitignore", Category: llm.CategoryScaffolding})
+
+	if license := firstNonEmpty(req.License, s.cfg.License); license != "" && license != LicenseNone {
+		content, err := render("assets/licenses/"+license+".txt", data)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, llm.FileInfo{Path: "LICENSE", Content: content, Type: "text", Category: llm.CategoryDocs})
+	}
+
+	if s.cfg.CI {
+		for _, eco := range present {
+			content, err := render("assets/workflows/"+eco.name+".yml", data)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, llm.FileInfo{Path: ".github/workflows/" + eco.name + ".yml", Content: content, Type: "yaml", Category: llm.CategoryCI})
+		}
+	}
+
+	if s.cfg.Dockerfile && len(present) > 0 {
+		content, err := render("assets/docker/"+present[0].name+".Dockerfile", data)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, llm.FileInfo{Path: "Dockerfile", Content: content, Type: "dockerfile", Category: llm.CategoryScaffolding})
+	}
+
+	return files, nil
+}
+
+// gitignore combines the common ignore rules with those of each ecosystem
+func (s *Scaffolder) gitignore(present []ecosystem) (string, error) {
+	parts := []string{"common"}
+	for _, eco := range present {
+		parts = append(parts, eco.name)
+	}
+
+	var sections []string
+	for _, part := range parts {
+		content, err := assets.ReadFile("assets/gitignore/" + part + ".gitignore")
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s gitignore: %w", part, err)
+		}
+		sections = append(sections, strings.TrimSpace(string(content)))
+	}
+	return strings.Join(sections, "\n\n") + "\n", nil
+}
+
+// templateData holds the values available to scaffolding assets
+type templateData struct {
+	Year       int
+	Owner      string
+	GoMain     string // Package path of the Go main package, e.g. ./cmd/server
+	PythonMain string // Python entrypoint script
+}
+
+// render executes an embedded asset as a template
+func render(name string, data templateData) (string, error) {
+	content, err := assets.ReadFile(name)
+	if err != nil {
+		return "", fmt.Errorf("failed to read scaffolding asset %s: %w", name, err)
+	}
+
+	tmpl, err := template.New(path.Base(name)).Delims("[[", "]]").Parse(string(content))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse scaffolding asset %s: %w", name, err)
+	}
+
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("failed to render scaffolding asset %s: %w", name, err)
+	}
+	return out.String(), nil
+}
+
+// detectEcosystems returns the ecosystems the project uses, in order of
+// preference
+func detectEcosystems(project *llm.GeneratedProject) []ecosystem {
+	var present []ecosystem
+	for _, eco := range ecosystems {
+		for _, lang := range eco.languages {
+			if project.HasLanguage(lang) {
+				present = append(present, eco)
+				break
+			}
+		}
+	}
+	return present
+}
+
+// goMainPackage returns the directory of the Go main package, preferring
+// the module root, then cmd/
+func goMainPackage(project *llm.GeneratedProject) string {
+	var candidates []string
+	for _, file := range project.Files {
+		if path.Ext(file.Path) != ".go" || strings.HasSuffix(file.Path, "_test.go") {
+			continue
+		}
+		if strings.Contains(file.Content, "package main\n") || strings.HasPrefix(file.Content, "package main") {
+			candidates = append(candidates, path.Dir(file.Path))
+		}
+	}
+	if len(candidates) == 0 {
+		return "."
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return packageRank(candidates[i]) < packageRank(candidates[j]) ||
+			(packageRank(candidates[i]) == packageRank(candidates[j]) && candidates[i] < candidates[j])
+	})
+	if candidates[0] == "." {
+		return "."
+	}
+	return "./" + candidates[0]
+}
+
+// packageRank orders main package candidates
+func packageRank(dir string) int {
+	switch {
+	case dir == ".":
+		return 0
+	case strings.HasPrefix(dir, "cmd/"):
+		return 1
+	default:
+		return 2
+	}
+}
+
+// pythonEntrypoint returns the script the Python Dockerfile runs
+func pythonEntrypoint(project *llm.GeneratedProject) string {
+	for _, name := range []string{"main.py", "app.py", "run.py", "server.py"} {
+		for _, file := range project.Files {
+			if file.Path == name {
+				return name
+			}
+		}
+	}
+	for _, file := range project.Files {
+		if path.Ext(file.Path) == ".py" && strings.Contains(file.Content, `__name__ == "__main__"`) {
+			return file.Path
+		}
+	}
+	return "main.py"
+}
+
+// firstNonEmpty returns the first non-empty value
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,253 @@
+package scaffold
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cosmos-link/gen-code/internal/llm"
+)
+
+// newProject builds a project from path and content pairs
+func newProject(pairs ...string) *llm.GeneratedProject {
+	project := &llm.GeneratedProject{Name: "greet"}
+	for i := 0; i < len(pairs); i += 2 {
+		project.Files = append(project.Files, llm.FileInfo{Path: pairs[i], Content: pairs[i+1]})
+	}
+	return project
+}
+
+// fileContent returns the content of the project file at path
+func fileContent(project *llm.GeneratedProject, path string) (string, bool) {
+	for _, file := range project.Files {
+		if file.Path == path {
+			return file.Content, true
+		}
+	}
+	return "", false
+}
+
+func TestApplyPerLanguage(t *testing.T) {
+	tests := []struct {
+		name          string
+		project       *llm.GeneratedProject
+		wantAdded     []string
+		wantIgnore    []string // Section headers expected in .gitignore
+		wantNotIgnore []string
+		wantDocker    string // Line expected in the Dockerfile
+	}{
+		{
+			"go",
+			newProject("go.mod", "module greet\n", "cmd/greet/main.go", "package main\n"),
+			[]string{".gitignore", "LICENSE", ".github/workflows/go.yml", "Dockerfile"},
+			[]string{"# Editors and OS files", "# Go build output"},
+			[]string{"# Python", "# Node"},
+			"go build -o /out/app ./cmd/greet",
+		},
+		{
+			"python",
+			newProject("app.py", "print('hi')\n", "requirements.txt", "flask\n"),
+			[]string{".gitignore", "LICENSE", ".github/workflows/python.yml", "Dockerfile"},
+			[]string{"# Python"},
+			[]string{"# Go build output", "# Node"},
+			`CMD ["python", "app.py"]`,
+		},
+		{
+			"typescript uses the node files",
+			newProject("src/index.ts", "console.log('hi')\n"),
+			[]string{".gitignore", "LICENSE", ".github/workflows/node.yml", "Dockerfile"},
+			[]string{"# Node"},
+			[]string{"# Go build output", "# Python"},
+			"FROM node",
+		},
+		{
+			"mixed project prefers go for the Dockerfile",
+			newProject("main.go", "package main\n", "scripts/seed.py", "print('seed')\n"),
+			[]string{".gitignore", "LICENSE", ".github/workflows/go.yml", ".github/workflows/python.yml", "Dockerfile"},
+			[]string{"# Go build output", "# Python"},
+			[]string{"# Node"},
+			"go build -o /out/app .",
+		},
+		{
+			"no known language",
+			newProject("README.md", "# greet\n"),
+			[]string{".gitignore", "LICENSE"},
+			[]string{"# Editors and OS files"},
+			[]string{"# Go build output", "# Python", "# Node"},
+			"",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New(Config{License: "MIT", CI: true, Dockerfile: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			added, err := s.Apply(tt.project, Request{})
+			if err != nil {
+				t.Fatalf("Apply: %v", err)
+			}
+			if strings.Join(added, ",") != strings.Join(tt.wantAdded, ",") {
+				t.Errorf("added = %v, want %v", added, tt.wantAdded)
+			}
+
+			gitignore, _ := fileContent(tt.project, ".gitignore")
+			for _, header := range tt.wantIgnore {
+				if !strings.Contains(gitignore, header) {
+					t.Errorf(".gitignore lacks %q", header)
+				}
+			}
+			for _, header := range tt.wantNotIgnore {
+				if strings.Contains(gitignore, header) {
+					t.Errorf(".gitignore contains %q", header)
+				}
+			}
+
+			dockerfile, ok := fileContent(tt.project, "Dockerfile")
+			if tt.wantDocker == "" {
+				if ok {
+					t.Error("Dockerfile added for a project without a known language")
+				}
+			} else if !strings.Contains(dockerfile, tt.wantDocker) {
+				t.Errorf("Dockerfile lacks %q:\n%s", tt.wantDocker, dockerfile)
+			}
+		})
+	}
+}
+
+func TestApplyDisabledFiles(t *testing.T) {
+	s, err := New(Config{License: LicenseNone})
+	if err != nil {
+		t.Fatal(err)
+	}
+	project := newProject("main.go", "package main\n")
+
+	added, err := s.Apply(project, Request{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(added, ",") != ".gitignore" {
+		t.Errorf("added = %v, want only .gitignore without license, CI or Dockerfile", added)
+	}
+}
+
+func TestApplyExistingFiles(t *testing.T) {
+	tests := []struct {
+		name      string
+		overwrite bool
+		wantAdded []string
+		wantKept  bool
+	}{
+		{"kept by default", false, []string{".github/workflows/go.yml"}, true},
+		{"replaced when overwriting", true, []string{".gitignore", "LICENSE", ".github/workflows/go.yml"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New(Config{License: "MIT", CI: true, Overwrite: tt.overwrite})
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Paths match regardless of case
+			project := newProject("main.go", "package main\n", ".gitignore", "/tmp/\n", "License", "Proprietary\n")
+
+			added, err := s.Apply(project, Request{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Join(added, ",") != strings.Join(tt.wantAdded, ",") {
+				t.Errorf("added = %v, want %v", added, tt.wantAdded)
+			}
+			if len(project.Files) != 4 {
+				t.Errorf("project has %d files, want 4", len(project.Files))
+			}
+
+			gitignore, _ := fileContent(project, ".gitignore")
+			if kept := gitignore == "/tmp/\n"; kept != tt.wantKept {
+				t.Errorf(".gitignore = %q, want kept %v", gitignore, tt.wantKept)
+			}
+			_, licenseKept := fileContent(project, "License")
+			if licenseKept != tt.wantKept {
+				t.Errorf("License kept = %v, want %v", licenseKept, tt.wantKept)
+			}
+		})
+	}
+}
+
+func TestLicense(t *testing.T) {
+	year := time.Now().Year()
+	tests := []struct {
+		name      string
+		cfg       Config
+		req       Request
+		wantFirst string // First line of LICENSE, "" for no LICENSE
+		wantOwner string
+	}{
+		{"configured license and default owner", Config{License: "MIT"}, Request{}, "MIT License", fmt.Sprintf("Copyright (c) %d The greet Authors", year)},
+		{"configured owner", Config{License: "MIT", Owner: "Octo Corp"}, Request{}, "MIT License", fmt.Sprintf("Copyright (c) %d Octo Corp", year)},
+		{"request overrides", Config{License: "MIT", Owner: "Octo Corp"}, Request{License: "BSD-3-Clause", Owner: "Alice"}, "BSD 3-Clause License", fmt.Sprintf("Copyright (c) %d, Alice", year)},
+		{"request disables", Config{License: "MIT"}, Request{License: LicenseNone}, "", ""},
+		{"no license configured", Config{}, Request{}, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New(tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			project := newProject("README.md", "# greet\n")
+			if _, err := s.Apply(project, tt.req); err != nil {
+				t.Fatal(err)
+			}
+
+			license, ok := fileContent(project, "LICENSE")
+			if tt.wantFirst == "" {
+				if ok {
+					t.Errorf("LICENSE added:\n%s", license)
+				}
+				return
+			}
+			if !strings.HasPrefix(license, tt.wantFirst+"\n") || !strings.Contains(license, tt.wantOwner) {
+				t.Errorf("LICENSE = %q, want %q by %q", license, tt.wantFirst, tt.wantOwner)
+			}
+		})
+	}
+}
+
+func TestNewRejectsUnknownLicense(t *testing.T) {
+	if _, err := New(Config{License: "WTFPL"}); err == nil || !strings.Contains(err.Error(), "unknown license: WTFPL") {
+		t.Errorf("err = %v, want an unknown license error", err)
+	}
+	for _, license := range Licenses() {
+		if _, err := New(Config{License: license}); err != nil {
+			t.Errorf("New(%s): %v", license, err)
+		}
+	}
+}
+
+func TestEntrypoints(t *testing.T) {
+	tests := []struct {
+		name       string
+		project    *llm.GeneratedProject
+		wantGo     string
+		wantPython string
+	}{
+		{"empty", newProject(), ".", "main.py"},
+		{"root main", newProject("main.go", "package main\n", "cmd/tool/main.go", "package main\n"), ".", "main.py"},
+		{"cmd beats other dirs", newProject("tools/gen.go", "package main\n", "cmd/server/main.go", "package main\n"), "./cmd/server", "main.py"},
+		{"tests are not main packages", newProject("main_test.go", "package main\n", "internal/app/app.go", "package app\n"), ".", "main.py"},
+		{"known python script", newProject("server.py", "", "app.py", ""), ".", "app.py"},
+		{"python __main__ guard", newProject("src/cli.py", "if __name__ == \"__main__\":\n    run()\n"), ".", "src/cli.py"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := goMainPackage(tt.project); got != tt.wantGo {
+				t.Errorf("goMainPackage = %q, want %q", got, tt.wantGo)
+			}
+			if got := pythonEntrypoint(tt.project); got != tt.wantPython {
+				t.Errorf("pythonEntrypoint = %q, want %q", got, tt.wantPython)
+			}
+		})
+	}
+}
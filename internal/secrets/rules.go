@@ -0,0 +1,89 @@
+package secrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+)
+
+// Rule is a pattern that identifies a secret
+type Rule struct {
+	ID          string
+	Description string
+	Pattern     *regexp.Regexp
+}
+
+// builtinRules detect well-known credential formats
+var builtinRules = []Rule{
+	{
+		ID:          "aws-access-key-id",
+		Description: "AWS access key ID",
+		Pattern:     regexp.MustCompile(`\b(?:AKIA|ASIA|AGPA|AIDA|AROA)[0-9A-Z]{16}\b`),
+	},
+	{
+		ID:          "aws-secret-access-key",
+		Description: "AWS secret access key",
+		Pattern:     regexp.MustCompile(`(?i)aws.{0,20}(?:secret|private).{0,20}['"=:\s]([0-9a-zA-Z/+]{40})\b`),
+	},
+	{
+		ID:          "github-token",
+		Description: "GitHub token",
+		Pattern:     regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`),
+	},
+	{
+		ID:          "openai-api-key",
+		Description: "OpenAI-style API key",
+		Pattern:     regexp.MustCompile(`\bsk-(?:proj-|ant-)?[A-Za-z0-9_-]{20,}`),
+	},
+	{
+		ID:          "google-api-key",
+		Description: "Google API key",
+		Pattern:     regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`),
+	},
+	{
+		ID:          "slack-token",
+		Description: "Slack token",
+		Pattern:     regexp.MustCompile(`\bxox[baprs]-[0-9A-Za-z-]{10,}`),
+	},
+	{
+		ID:          "private-key",
+		Description: "PEM private key block",
+		Pattern:     regexp.MustCompile(`(?s)-----BEGIN (?:[A-Z0-9]+ )*PRIVATE KEY-----.*?(?:-----END (?:[A-Z0-9]+ )*PRIVATE KEY-----|\z)`),
+	},
+}
+
+// customRule is the on-disk form of a custom rule
+type customRule struct {
+	ID          string `json:"id"`
+	Description string `json:"description"`
+	Pattern     string `json:"pattern"`
+}
+
+// LoadRules loads custom rules from a JSON file containing a list of
+// {"id", "description", "pattern"} objects
+func LoadRules(path string) ([]Rule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secret scan rules: %w", err)
+	}
+
+	var raw []customRule
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse secret scan rules: %w", err)
+	}
+
+	rules := make([]Rule, 0, len(raw))
+	for _, r := range raw {
+		if r.ID == "" || r.Pattern == "" {
+			return nil, fmt.Errorf("secret scan rule needs an id and a pattern")
+		}
+		pattern, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern for secret scan rule %s: %w", r.ID, err)
+		}
+		rules = append(rules, Rule{ID: r.ID, Description: r.Description, Pattern: pattern})
+	}
+
+	return rules, nil
+}
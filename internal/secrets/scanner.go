@@ -0,0 +1,234 @@
+package secrets
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Mode is what happens to a project when secrets are found
+type Mode string
+
+const (
+	ModeBlock  Mode = "block"  // Fail the task
+	ModeRedact Mode = "redact" // Replace secrets and continue
+	ModeWarn   Mode = "warn"   // Record findings and continue
+)
+
+// Redacted replaces secrets in redact mode
+const Redacted = "REDACTED"
+
+// minLiteralLength is the shortest configured value treated as a secret
+const minLiteralLength = 8
+
+// entropyCandidate matches quoted or assigned strings that may be secrets
+var entropyCandidate = regexp.MustCompile(`[A-Za-z0-9+/=_-]{20,}`)
+
+// lockFiles contain integrity hashes that look random but are not secrets
+var lockFiles = map[string]bool{
+	"go.sum": true, "package-lock.json": true, "yarn.lock": true,
+	"pnpm-lock.yaml": true, "poetry.lock": true, "cargo.lock": true,
+}
+
+// Config holds scanner settings
+type Config struct {
+	CustomRules []Rule
+
+	// Literals are known secret values, such as our own API keys, keyed by name
+	Literals map[string]string
+
+	// EntropyThreshold is the Shannon entropy (bits per character) above
+	// which long random-looking strings are reported; 0 disables the check
+	EntropyThreshold float64
+}
+
+// Finding is a secret found in a file. Match never holds the full secret.
+type Finding struct {
+	RuleID string
+	Path   string
+	Line   int
+	Match  string
+
+	start, end int
+}
+
+// Scanner finds secrets in generated files
+type Scanner struct {
+	rules            []Rule
+	literals         map[string]string
+	entropyThreshold float64
+}
+
+// NewScanner creates a scanner with the built-in rules plus cfg's additions
+func NewScanner(cfg Config) *Scanner {
+	rules := append(append([]Rule(nil), builtinRules...), cfg.CustomRules...)
+
+	literals := make(map[string]string)
+	for name, value := range cfg.Literals {
+		if len(value) >= minLiteralLength {
+			literals[name] = value
+		}
+	}
+
+	return &Scanner{
+		rules:            rules,
+		literals:         literals,
+		entropyThreshold: cfg.EntropyThreshold,
+	}
+}
+
+// WithLiterals returns a scanner that also looks for the given values,
+// such as a task's own credentials
+func (s *Scanner) WithLiterals(literals map[string]string) *Scanner {
+	clone := *s
+	clone.literals = make(map[string]string, len(s.literals)+len(literals))
+	for name, value := range s.literals {
+		clone.literals[name] = value
+	}
+	for name, value := range literals {
+		if len(value) >= minLiteralLength {
+			clone.literals[name] = value
+		}
+	}
+	return &clone
+}
+
+// Scan returns the secrets found in a file, ordered by position
+func (s *Scanner) Scan(filePath, content string) []Finding {
+	var findings []Finding
+	add := func(ruleID string, start, end int) {
+		for _, f := range findings {
+			if start < f.end && f.start < end {
+				return // Already reported by an earlier rule
+			}
+		}
+		findings = append(findings, Finding{
+			RuleID: ruleID,
+			Path:   filePath,
+			Line:   strings.Count(content[:start], "\n") + 1,
+			Match:  mask(content[start:end]),
+			start:  start,
+			end:    end,
+		})
+	}
+
+	for name, value := range s.literals {
+		offset := 0
+		for {
+			idx := strings.Index(content[offset:], value)
+			if idx < 0 {
+				break
+			}
+			start := offset + idx
+			add("config-value:"+name, start, start+len(value))
+			offset = start + len(value)
+		}
+	}
+
+	for _, rule := range s.rules {
+		for _, loc := range rule.Pattern.FindAllStringSubmatchIndex(content, -1) {
+			start, end := loc[0], loc[1]
+			// Report only the captured secret when the rule has a group
+			if len(loc) >= 4 && loc[2] >= 0 {
+				start, end = loc[2], loc[3]
+			}
+			add(rule.ID, start, end)
+		}
+	}
+
+	if s.entropyThreshold > 0 && !lockFiles[strings.ToLower(path.Base(filePath))] {
+		for _, loc := range entropyCandidate.FindAllStringIndex(content, -1) {
+			if looksRandom(content[loc[0]:loc[1]], s.entropyThreshold) {
+				add("high-entropy-string", loc[0], loc[1])
+			}
+		}
+	}
+
+	sort.Slice(findings, func(i, j int) bool { return findings[i].start < findings[j].start })
+	return findings
+}
+
+// Redact replaces every finding in content with Redacted
+func Redact(content string, findings []Finding) string {
+	if len(findings) == 0 {
+		return content
+	}
+
+	sorted := append([]Finding(nil), findings...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].start < sorted[j].start })
+
+	var b strings.Builder
+	last := 0
+	for _, f := range sorted {
+		if f.start < last {
+			continue
+		}
+		b.WriteString(content[last:f.start])
+		b.WriteString(Redacted)
+		last = f.end
+	}
+	b.WriteString(content[last:])
+	return b.String()
+}
+
+// ParseMode parses a scan mode name
+func ParseMode(name string) (Mode, error) {
+	switch mode := Mode(strings.ToLower(name)); mode {
+	case ModeBlock, ModeRedact, ModeWarn:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown secret scan mode: %s", name)
+	}
+}
+
+// looksRandom reports whether s has the character mix and entropy of a
+// generated secret rather than an identifier or placeholder
+func looksRandom(s string, threshold float64) bool {
+	var hasUpper, hasLower, hasDigit bool
+	for _, r := range s {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			hasUpper = true
+		case r >= 'a' && r <= 'z':
+			hasLower = true
+		case r >= '0' && r <= '9':
+			hasDigit = true
+		}
+	}
+	// Identifiers such as SOME_LONG_CONSTANT_NAME or some-long-slug lack the mix
+	if !hasDigit || !(hasUpper || hasLower) {
+		return false
+	}
+	return shannonEntropy(s) >= threshold
+}
+
+// shannonEntropy returns the entropy of s in bits per character
+func shannonEntropy(s string) float64 {
+	counts := make(map[rune]int)
+	for _, r := range s {
+		counts[r]++
+	}
+
+	var entropy float64
+	length := float64(len(s))
+	for _, count := range counts {
+		p := float64(count) / length
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// mask shows only the start of a secret
+func mask(secret string) string {
+	if len(secret) <= 8 {
+		return strings.Repeat("*", len(secret))
+	}
+	first := strings.SplitN(secret, "\n", 2)[0]
+	if len(first) > 4 {
+		first = first[:4]
+	}
+	return first + "..." + fmt.Sprintf("(%d chars)", len(secret))
+}
@@ -6,6 +6,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/cosmos-link/gen-code/internal/llm"
 	"github.com/google/uuid"
 )
 
@@ -15,6 +16,8 @@ type StatusCallback func(task *Task)
 // Manager manages tasks
 type Manager struct {
 	tasks             map[string]*Task
+	projects          map[string]*llm.GeneratedProject
+	approvals         map[string]chan Approval
 	mu                sync.RWMutex
 	statusCallbacks   map[string][]StatusCallback
 	callbackMu        sync.RWMutex
@@ -31,6 +34,8 @@ func NewManager(maxConcurrentTasks int) *Manager {
 	
 	m := &Manager{
 		tasks:             make(map[string]*Task),
+		projects:          make(map[string]*llm.GeneratedProject),
+		approvals:         make(map[string]chan Approval),
 		statusCallbacks:   make(map[string][]StatusCallback),
 		maxConcurrentTasks: maxConcurrentTasks,
 		taskQueue:         make(chan *Task, 100),
@@ -46,14 +51,75 @@ func NewManager(maxConcurrentTasks int) *Manager {
 	return m
 }
 
+// Options holds optional per-request settings for a new task
+type Options struct {
+	Private         bool
+	DryRun          bool
+	RequireApproval bool
+	GitHub          *GitHubCredentials
+	Caller          string
+
+	ModelVariant string
+	Temperature  *float32
+	MaxTokens    int
+
+	PromptSet   string
+	Language    string
+	Conventions string
+
+	Template     string
+	TemplateVars map[string]string
+	License      string
+
+	ParentID   string
+	RefineMode string
+	TokenLimit int
+	NoCache    bool
+
+	CommitAuthor      *CommitIdentity
+	CommitCommitter   *CommitIdentity
+	CoAuthors         []CommitIdentity
+	CoAuthorRequester bool
+}
+
 // CreateTask creates a new task
-func (m *Manager) CreateTask(prompt, repoName, model, githubOrg string) *Task {
+func (m *Manager) CreateTask(prompt, repoName, model, githubOrg string, opts Options) *Task {
 	task := &Task{
 		ID:        uuid.New().String(),
 		Prompt:    prompt,
 		RepoName:  repoName,
 		Model:     model,
 		GitHubOrg: githubOrg,
+		Private:   opts.Private,
+		DryRun:    opts.DryRun,
+
+		RequireApproval: opts.RequireApproval,
+
+		GitHub:    opts.GitHub,
+		Caller:    opts.Caller,
+
+		ModelVariant: opts.ModelVariant,
+		Temperature:  opts.Temperature,
+		MaxTokens:    opts.MaxTokens,
+
+		PromptSet:   opts.PromptSet,
+		Language:    opts.Language,
+		Conventions: opts.Conventions,
+
+		Template:     opts.Template,
+		TemplateVars: opts.TemplateVars,
+		License:      opts.License,
+
+		ParentID:   opts.ParentID,
+		RefineMode: opts.RefineMode,
+		TokenLimit: opts.TokenLimit,
+		NoCache:    opts.NoCache,
+
+		CommitAuthor:      opts.CommitAuthor,
+		CommitCommitter:   opts.CommitCommitter,
+		CoAuthors:         opts.CoAuthors,
+		CoAuthorRequester: opts.CoAuthorRequester,
+
 		Status:    StatusPending,
 		Message:   "Task created",
 		CreatedAt: time.Now(),
@@ -62,6 +128,10 @@ func (m *Manager) CreateTask(prompt, repoName, model, githubOrg string) *Task {
 
 	m.mu.Lock()
 	m.tasks[task.ID] = task
+	if parent, ok := m.tasks[opts.ParentID]; ok {
+		parent.RefinementIDs = append(parent.RefinementIDs, task.ID)
+		parent.UpdatedAt = time.Now()
+	}
 	m.mu.Unlock()
 
 	return task
@@ -132,6 +202,308 @@ func (m *Manager) SetTaskRepoURL(id string, repoURL string) error {
 	return nil
 }
 
+// SetTaskBranch records the branch a task pushed to and its pull request
+func (m *Manager) SetTaskBranch(id, branch, pullRequestURL string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	task, ok := m.tasks[id]
+	if !ok {
+		return fmt.Errorf("task not found: %s", id)
+	}
+
+	task.Branch = branch
+	task.PullRequestURL = pullRequestURL
+	task.UpdatedAt = time.Now()
+
+	return nil
+}
+
+// SetTaskFileChanges replaces the files a refinement touched
+func (m *Manager) SetTaskFileChanges(id string, changes []FileChange) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	task, ok := m.tasks[id]
+	if !ok {
+		return fmt.Errorf("task not found: %s", id)
+	}
+
+	task.FileChanges = changes
+	task.UpdatedAt = time.Now()
+
+	return nil
+}
+
+// AddTaskUsage records an LLM call made for a task, notes the provider that
+// served it and returns the task's total tokens used so far
+func (m *Manager) AddTaskUsage(id string, record UsageRecord) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	task, ok := m.tasks[id]
+	if !ok {
+		return 0, fmt.Errorf("task not found: %s", id)
+	}
+
+	task.Usage = append(task.Usage, record)
+	task.Provider = record.Provider
+	if record.Cached {
+		task.CacheHits++
+	} else {
+		task.TokensUsed += record.TotalTokens
+		task.Cost += record.Cost
+	}
+	task.UpdatedAt = time.Now()
+
+	return task.TokensUsed, nil
+}
+
+// AddTaskRejections records generated files that were rejected for a task
+func (m *Manager) AddTaskRejections(id string, rejections []FileRejection) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	task, ok := m.tasks[id]
+	if !ok {
+		return fmt.Errorf("task not found: %s", id)
+	}
+
+	task.Rejections = append(task.Rejections, rejections...)
+	task.UpdatedAt = time.Now()
+
+	return nil
+}
+
+// AddTaskDependencyChanges appends dependency manifest changes to a task
+func (m *Manager) AddTaskDependencyChanges(id string, changes []DependencyChange) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	task, ok := m.tasks[id]
+	if !ok {
+		return fmt.Errorf("task not found: %s", id)
+	}
+
+	task.DependencyChanges = append(task.DependencyChanges, changes...)
+	task.UpdatedAt = time.Now()
+
+	return nil
+}
+
+// SetTaskDiagnostics replaces the validation diagnostics of a task
+func (m *Manager) SetTaskDiagnostics(id string, diagnostics map[string][]Diagnostic) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	task, ok := m.tasks[id]
+	if !ok {
+		return fmt.Errorf("task not found: %s", id)
+	}
+
+	task.Diagnostics = diagnostics
+	task.UpdatedAt = time.Now()
+
+	return nil
+}
+
+// SetTaskTestResults replaces the sandboxed test results of a task
+func (m *Manager) SetTaskTestResults(id string, results []TestResult) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	task, ok := m.tasks[id]
+	if !ok {
+		return fmt.Errorf("task not found: %s", id)
+	}
+
+	task.TestResults = results
+	task.UpdatedAt = time.Now()
+
+	return nil
+}
+
+// AddTaskSecretFindings records secrets detected in a task's generated files
+func (m *Manager) AddTaskSecretFindings(id string, findings []SecretFinding) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	task, ok := m.tasks[id]
+	if !ok {
+		return fmt.Errorf("task not found: %s", id)
+	}
+
+	task.SecretFindings = append(task.SecretFindings, findings...)
+	task.UpdatedAt = time.Now()
+
+	return nil
+}
+
+// AddTaskEvent appends an event to a task's event log and notifies subscribers
+func (m *Manager) AddTaskEvent(id string, event Event) error {
+	m.mu.Lock()
+	task, ok := m.tasks[id]
+	if !ok {
+		m.mu.Unlock()
+		return fmt.Errorf("task not found: %s", id)
+	}
+
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	task.Events = append(task.Events, task.redactEvent(event))
+	task.UpdatedAt = time.Now()
+	m.mu.Unlock()
+
+	// Notify callbacks
+	m.notifyCallbacks(task)
+
+	return nil
+}
+
+// AddTaskLabel adds a label to a task if it is not already present
+func (m *Manager) AddTaskLabel(id string, label string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	task, ok := m.tasks[id]
+	if !ok {
+		return fmt.Errorf("task not found: %s", id)
+	}
+
+	for _, existing := range task.Labels {
+		if existing == label {
+			return nil
+		}
+	}
+	task.Labels = append(task.Labels, label)
+	task.UpdatedAt = time.Now()
+
+	return nil
+}
+
+// SetTaskProject stores a snapshot of the project generated for a task
+func (m *Manager) SetTaskProject(id string, project *llm.GeneratedProject) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	task, ok := m.tasks[id]
+	if !ok {
+		return fmt.Errorf("task not found: %s", id)
+	}
+
+	snapshot := *project
+	snapshot.Files = append([]llm.FileInfo(nil), project.Files...)
+	m.projects[id] = &snapshot
+	task.FileCount = len(snapshot.Files)
+	task.UpdatedAt = time.Now()
+
+	return nil
+}
+
+// ClearTaskProject drops the project stored for a task, such as one that
+// failed its secret scan
+func (m *Manager) ClearTaskProject(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	task, ok := m.tasks[id]
+	if !ok {
+		return fmt.Errorf("task not found: %s", id)
+	}
+
+	delete(m.projects, id)
+	task.FileCount = 0
+	task.UpdatedAt = time.Now()
+
+	return nil
+}
+
+// GetTaskProject returns the project stored for a task
+func (m *Manager) GetTaskProject(id string) (*llm.GeneratedProject, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if _, ok := m.tasks[id]; !ok {
+		return nil, fmt.Errorf("task not found: %s", id)
+	}
+
+	project, ok := m.projects[id]
+	if !ok {
+		return nil, ErrNoProject
+	}
+	return project, nil
+}
+
+// AwaitApproval pauses a task until it is approved or rejected, or the
+// timeout elapses, which counts as a rejection
+func (m *Manager) AwaitApproval(ctx context.Context, id string, timeout time.Duration) (Approval, error) {
+	decision := make(chan Approval, 1)
+	deadline := time.Now().Add(timeout)
+
+	m.mu.Lock()
+	task, ok := m.tasks[id]
+	if !ok {
+		m.mu.Unlock()
+		return Approval{}, fmt.Errorf("task not found: %s", id)
+	}
+	m.approvals[id] = decision
+	task.ApprovalDeadline = &deadline
+	task.UpdateStatus(StatusAwaitingApproval, "Waiting for approval before pushing")
+	m.mu.Unlock()
+
+	m.notifyCallbacks(task)
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	var approval Approval
+	select {
+	case approval = <-decision:
+	case <-timer.C:
+		approval = Approval{Reason: fmt.Sprintf("approval timed out after %s", timeout)}
+	case <-ctx.Done():
+		approval = Approval{Reason: "approval cancelled"}
+	}
+
+	m.mu.Lock()
+	delete(m.approvals, id)
+	task.ApprovalDeadline = nil
+	record := &ApprovalRecord{
+		Approved: approval.Approved,
+		Reviewer: approval.Reviewer,
+		Reason:   approval.Reason,
+		Time:     time.Now(),
+	}
+	for _, file := range approval.Files {
+		record.EditedFiles = append(record.EditedFiles, file.Path)
+	}
+	task.Approval = record
+	task.UpdatedAt = time.Now()
+	m.mu.Unlock()
+
+	return approval, nil
+}
+
+// ResolveApproval delivers a decision to a task awaiting approval
+func (m *Manager) ResolveApproval(id string, approval Approval) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.tasks[id]; !ok {
+		return fmt.Errorf("task not found: %s", id)
+	}
+
+	decision, ok := m.approvals[id]
+	if !ok {
+		return ErrNotAwaitingApproval
+	}
+	delete(m.approvals, id)
+	decision <- approval
+
+	return nil
+}
+
 // SubscribeToTask subscribes to task status updates
 func (m *Manager) SubscribeToTask(taskID string, callback StatusCallback) error {
 	m.callbackMu.Lock()
@@ -1,51 +1,353 @@
 package task
 
-import "time"
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/cosmos-link/gen-code/internal/llm"
+)
 
 // Status represents the status of a task
 type Status string
 
 const (
-	StatusPending      Status = "pending"
-	StatusGenerating   Status = "generating"
-	StatusMergingFiles Status = "merging_files"
-	StatusCreatingRepo Status = "creating_repo"
-	StatusPushing      Status = "pushing"
-	StatusCompleted    Status = "completed"
-	StatusFailed       Status = "failed"
+	StatusPending          Status = "pending"
+	StatusGenerating       Status = "generating"
+	StatusMergingFiles     Status = "merging_files"
+	StatusValidating       Status = "validating"
+	StatusTesting          Status = "testing"
+	StatusScanning         Status = "scanning"
+	StatusAwaitingApproval Status = "awaiting_approval"
+	StatusCreatingRepo     Status = "creating_repo"
+	StatusPushing          Status = "pushing"
+	StatusCompleted        Status = "completed"
+	StatusFailed           Status = "failed"
+	StatusRejected         Status = "rejected"
+)
+
+// ErrNoProject is returned when a task has no generated files yet
+var ErrNoProject = errors.New("task has no generated project")
+
+// ErrNotAwaitingApproval is returned when approving or rejecting a task
+// that is not paused for approval
+var ErrNotAwaitingApproval = errors.New("task is not awaiting approval")
+
+// Refinement push modes
+const (
+	RefineModeCommit      = "commit"       // Commit to the parent's branch
+	RefineModePullRequest = "pull_request" // Open a pull request against it
+)
+
+// File change actions
+const (
+	FileAdded    = "added"
+	FileModified = "modified"
+	FileDeleted  = "deleted"
 )
 
+// LabelNeedsAttention marks tasks pushed despite unresolved problems
+const LabelNeedsAttention = "needs-attention"
+
 // Task represents a code generation task
 type Task struct {
-	ID          string    `json:"task_id"`
-	Prompt      string    `json:"prompt"`
-	RepoName    string    `json:"repo_name"`
-	Model       string    `json:"model"`
-	GitHubOrg   string    `json:"github_org,omitempty"`
-	Status      Status    `json:"status"`
-	Message     string    `json:"message"`
-	RepoURL     string    `json:"repo_url,omitempty"`
-	Error       string    `json:"error,omitempty"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID        string `json:"task_id"`
+	Prompt    string `json:"prompt"`
+	RepoName  string `json:"repo_name"`
+	Model     string `json:"model"`
+	GitHubOrg string `json:"github_org,omitempty"`
+	Private   bool   `json:"private"`
+	DryRun    bool   `json:"dry_run,omitempty"`
+
+	// API client that created the task; only it may read or act on it
+	Caller string `json:"caller,omitempty"`
+
+	// Requested model variant and sampling overrides; the values each call
+	// actually used are in Usage
+	ModelVariant string   `json:"model_variant,omitempty"`
+	Temperature  *float32 `json:"temperature,omitempty"`
+	MaxTokens    int      `json:"max_tokens,omitempty"`
+
+	// Requested LLM prompt set, target language and coding conventions;
+	// empty values fall back to the template's and the server's
+	PromptSet   string `json:"prompt_set,omitempty"`
+	Language    string `json:"language,omitempty"`
+	Conventions string `json:"conventions,omitempty"`
+
+	// Human approval gate before the repository is created
+	RequireApproval  bool               `json:"require_approval,omitempty"`
+	ApprovalDeadline *time.Time         `json:"approval_deadline,omitempty"`
+	Approval         *ApprovalRecord    `json:"approval,omitempty"`
+	GitHub           *GitHubCredentials `json:"github_auth,omitempty"`
+
+	// Starter template the project is generated from
+	Template     string            `json:"template,omitempty"`
+	TemplateVars map[string]string `json:"template_vars,omitempty"`
+
+	// License added by the scaffolding stage, overriding the server default
+	License string `json:"license,omitempty"`
+
+	// Refinement links: a refinement is a child task that changes its
+	// parent's project according to a follow-up prompt
+	ParentID      string   `json:"parent_task_id,omitempty"`
+	RefinementIDs []string `json:"refinement_task_ids,omitempty"`
+	RefineMode    string   `json:"refine_mode,omitempty"`
+
+	// Branch the task's commit was pushed to, and the pull request opened
+	// for it, if any
+	Branch         string `json:"branch,omitempty"`
+	PullRequestURL string `json:"pull_request_url,omitempty"`
+
+	// Commit identity overrides
+	CommitAuthor      *CommitIdentity  `json:"commit_author,omitempty"`
+	CommitCommitter   *CommitIdentity  `json:"commit_committer,omitempty"`
+	CoAuthors         []CommitIdentity `json:"co_authors,omitempty"`
+	CoAuthorRequester bool             `json:"co_author_requester,omitempty"`
+
+	Status  Status `json:"status"`
+	Message string `json:"message"`
+	RepoURL string `json:"repo_url,omitempty"`
+	Error   string `json:"error,omitempty"`
+
+	// Number of generated files, available through the files endpoints
+	FileCount int `json:"file_count,omitempty"`
+
+	// LLM provider that served the task's most recent call, which differs
+	// from Model when the call failed over to another provider
+	Provider string `json:"provider,omitempty"`
+
+	// LLM usage: one record per call, running totals, the token limit
+	// that aborts the task when exceeded (0 for none) and the number of
+	// calls served from the response cache
+	Usage      []UsageRecord `json:"usage,omitempty"`
+	TokensUsed int           `json:"tokens_used,omitempty"`
+	Cost       float64       `json:"cost_usd,omitempty"`
+	TokenLimit int           `json:"token_limit,omitempty"`
+	CacheHits  int           `json:"cache_hits,omitempty"`
+
+	// Skip cached LLM responses; fresh responses still replace them
+	NoCache bool `json:"no_cache,omitempty"`
+
+	// Files a refinement added, modified or deleted
+	FileChanges []FileChange `json:"file_changes,omitempty"`
+
+	// Generated files dropped by the file policy
+	Rejections []FileRejection `json:"rejections,omitempty"`
+
+	// Dependency manifest entries added, removed or flagged
+	DependencyChanges []DependencyChange `json:"dependency_changes,omitempty"`
+
+	// Validation diagnostics keyed by file path ("." for project-level)
+	Diagnostics map[string][]Diagnostic `json:"diagnostics,omitempty"`
+
+	// Results of running the generated tests in a sandbox
+	TestResults []TestResult `json:"test_results,omitempty"`
+
+	// Secrets detected in generated files
+	SecretFindings []SecretFinding `json:"secret_findings,omitempty"`
+
+	// Labels such as LabelNeedsAttention, applied to the pushed repository
+	Labels []string `json:"labels,omitempty"`
+
+	// Chronological log of pipeline events such as fix-up rounds
+	Events []Event `json:"events,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// GitHubCredentials holds the per-task GitHub identity. The raw token is
+// never serialized.
+type GitHubCredentials struct {
+	Token          string `json:"-"`
+	CredentialRef  string `json:"credential_ref,omitempty"`
+	InstallationID int64  `json:"installation_id,omitempty"`
+	Caller         string `json:"caller,omitempty"` // API client that supplied the credentials
+}
+
+// CommitIdentity is a git name/email pair
+type CommitIdentity struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// FileRejection records a generated file that was not written and why
+type FileRejection struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// FileChange records a file a refinement touched. The patch is served by
+// the diff endpoint rather than with the task.
+type FileChange struct {
+	Path    string `json:"path"`
+	Action  string `json:"action"` // added, modified or deleted
+	Added   int    `json:"added"`
+	Removed int    `json:"removed"`
+	Patch   string `json:"-"`
+}
+
+// UsageRecord records the tokens, latency and cost of one LLM call
+type UsageRecord struct {
+	Provider         string    `json:"provider"`
+	Model            string    `json:"model"`
+	KeyID            string    `json:"key_id"`
+	Operation        string    `json:"operation"`
+	Temperature      float32   `json:"temperature"`
+	MaxTokens        int       `json:"max_tokens"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	TotalTokens      int       `json:"total_tokens"`
+	LatencyMs        int64     `json:"latency_ms"`
+	Cost             float64   `json:"cost_usd"`
+	Priced           bool      `json:"priced"`           // False when the model is missing from the price table
+	Cached           bool      `json:"cached,omitempty"` // Served from the response cache; not counted in the task's tokens or cost
+	Time             time.Time `json:"time"`
+}
+
+// DependencyChange records a reconciliation of a dependency manifest
+type DependencyChange struct {
+	Manifest string `json:"manifest"`
+	Package  string `json:"package"`
+	Version  string `json:"version,omitempty"`
+	Action   string `json:"action"` // added, removed or flagged
+	Reason   string `json:"reason"`
+}
+
+// Diagnostic is a problem reported by a validation tool
+type Diagnostic struct {
+	Tool     string `json:"tool"`
+	Severity string `json:"severity"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+	Message  string `json:"message"`
+}
+
+// TestResult is the outcome of one sandboxed test command
+type TestResult struct {
+	Language   string `json:"language"`
+	Runner     string `json:"runner"`
+	Command    string `json:"command"`
+	ExitCode   int    `json:"exit_code"`
+	Passed     bool   `json:"passed"`
+	TimedOut   bool   `json:"timed_out,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+	Output     string `json:"output"`
+	Truncated  bool   `json:"truncated,omitempty"`
+}
+
+// SecretFinding records a secret detected in a generated file. Match is
+// masked and never contains the full secret.
+type SecretFinding struct {
+	Rule   string `json:"rule"`
+	Path   string `json:"path"`
+	Line   int    `json:"line"`
+	Match  string `json:"match"`
+	Action string `json:"action"` // blocked, redacted or warned
+}
+
+// Approval is a reviewer's decision on a task awaiting approval
+type Approval struct {
+	Approved bool
+	Reviewer string
+	Reason   string
+	Files    []llm.FileInfo // Edited files to apply before pushing
+}
+
+// ApprovalRecord records the decision on a task awaiting approval
+type ApprovalRecord struct {
+	Approved    bool      `json:"approved"`
+	Reviewer    string    `json:"reviewer,omitempty"`
+	Reason      string    `json:"reason,omitempty"`
+	EditedFiles []string  `json:"edited_files,omitempty"`
+	Time        time.Time `json:"time"`
+}
+
+// Event is an entry in a task's event log
+type Event struct {
+	Time        time.Time               `json:"time"`
+	Type        string                  `json:"type"`
+	Message     string                  `json:"message"`
+	Round       int                     `json:"round,omitempty"`
+	Files       []string                `json:"files,omitempty"`
+	Diagnostics map[string][]Diagnostic `json:"diagnostics,omitempty"`
+	Remaining   map[string][]Diagnostic `json:"remaining,omitempty"` // Fix-up rounds: diagnostics left after revalidation
 }
 
 // UpdateStatus updates the task status and message
 func (t *Task) UpdateStatus(status Status, message string) {
 	t.Status = status
-	t.Message = message
+	t.Message = t.redact(message)
 	t.UpdatedAt = time.Now()
+
+	if t.IsTerminal() {
+		t.clearSecrets()
+	}
 }
 
 // SetError sets the task error and status to failed
 func (t *Task) SetError(err error) {
 	t.Status = StatusFailed
-	t.Error = err.Error()
+	t.Error = t.redact(err.Error())
 	t.Message = "Task failed"
 	t.UpdatedAt = time.Now()
+	t.clearSecrets()
+}
+
+// HasLabel reports whether the task carries the given label
+func (t *Task) HasLabel(label string) bool {
+	for _, existing := range t.Labels {
+		if existing == label {
+			return true
+		}
+	}
+	return false
 }
 
 // IsTerminal returns true if the task is in a terminal state
 func (t *Task) IsTerminal() bool {
-	return t.Status == StatusCompleted || t.Status == StatusFailed
+	return t.Status == StatusCompleted || t.Status == StatusFailed || t.Status == StatusRejected
+}
+
+// redact removes the task's GitHub token from a message
+func (t *Task) redact(message string) string {
+	if t.GitHub == nil || t.GitHub.Token == "" {
+		return message
+	}
+	return strings.ReplaceAll(message, t.GitHub.Token, "[REDACTED]")
+}
+
+// redactEvent removes the task's GitHub token from an event's message and
+// diagnostics, which often quote raw API or tool errors
+func (t *Task) redactEvent(event Event) Event {
+	event.Message = t.redact(event.Message)
+	event.Diagnostics = t.redactDiagnostics(event.Diagnostics)
+	event.Remaining = t.redactDiagnostics(event.Remaining)
+	return event
+}
+
+// redactDiagnostics returns a copy of diagnostics with the task's GitHub
+// token removed from their messages
+func (t *Task) redactDiagnostics(diagnostics map[string][]Diagnostic) map[string][]Diagnostic {
+	if len(diagnostics) == 0 {
+		return diagnostics
+	}
+
+	redacted := make(map[string][]Diagnostic, len(diagnostics))
+	for path, diags := range diagnostics {
+		copied := make([]Diagnostic, len(diags))
+		for i, d := range diags {
+			d.Message = t.redact(d.Message)
+			copied[i] = d
+		}
+		redacted[path] = copied
+	}
+	return redacted
+}
+
+// clearSecrets drops credentials that are no longer needed
+func (t *Task) clearSecrets() {
+	if t.GitHub != nil {
+		t.GitHub.Token = ""
+	}
 }
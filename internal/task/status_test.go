@@ -0,0 +1,46 @@
+package task
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestTaskMessagesAreRedacted(t *testing.T) {
+	const token = "user-supplied-token-0123456789"
+	m := NewManager(1)
+	tk := m.CreateTask("prompt", "repo", "", "", Options{GitHub: &GitHubCredentials{Token: token}})
+
+	diagnostics := map[string][]Diagnostic{
+		".": {{Tool: "go test", Severity: "error", Message: "401 for token " + token}},
+	}
+	if err := m.AddTaskEvent(tk.ID, Event{
+		Type:        "issue_failed",
+		Message:     "POST https://x-access-token:" + token + "@github.com/acme/repo/issues: 401 Bad credentials",
+		Diagnostics: diagnostics,
+	}); err != nil {
+		t.Fatalf("AddTaskEvent: %v", err)
+	}
+	if err := m.UpdateTask(tk.ID, StatusPushing, "pushing with "+token); err != nil {
+		t.Fatalf("UpdateTask: %v", err)
+	}
+
+	event := tk.Events[len(tk.Events)-1]
+	for name, message := range map[string]string{
+		"event message":    event.Message,
+		"event diagnostic": event.Diagnostics["."][0].Message,
+		"status message":   tk.Message,
+	} {
+		if strings.Contains(message, token) || !strings.Contains(message, "[REDACTED]") {
+			t.Errorf("%s = %q, want the token redacted", name, message)
+		}
+	}
+	if diagnostics["."][0].Message != "401 for token "+token {
+		t.Error("AddTaskEvent modified the caller's diagnostics")
+	}
+
+	tk.SetError(errors.New("push failed: " + token))
+	if strings.Contains(tk.Error, token) {
+		t.Errorf("error = %q, want the token redacted", tk.Error)
+	}
+}
@@ -0,0 +1,257 @@
+package templates
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/cosmos-link/gen-code/internal/llm"
+)
+
+// ManifestFile is the name of the manifest at the root of each template
+const ManifestFile = "template.json"
+
+// templateSuffix is stripped from file names so template sources such as
+// main.go.tmpl are not picked up by tooling that scans the template tree
+const templateSuffix = ".tmpl"
+
+// Variable is a value substituted into template paths and contents as
+// {{name}}
+type Variable struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Default     string `json:"default,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// Placeholder is a template file the LLM is expected to fill in
+type Placeholder struct {
+	Path        string `json:"path"`
+	Description string `json:"description,omitempty"`
+}
+
+// Manifest describes a template
+type Manifest struct {
+	Name         string        `json:"name"`
+	Description  string        `json:"description"`
+	Language     string        `json:"language,omitempty"`
+	Variables    []Variable    `json:"variables,omitempty"`
+	Placeholders []Placeholder `json:"placeholders,omitempty"`
+	Instructions string        `json:"instructions,omitempty"` // Extra guidance for the LLM
+	PromptSet    string        `json:"prompt_set,omitempty"`   // LLM prompt set, e.g. en or zh
+	Conventions  string        `json:"conventions,omitempty"`  // Coding conventions stated in the LLM prompts
+}
+
+// Template is a starter project on disk
+type Template struct {
+	Manifest
+	dir string
+}
+
+// Registry holds the templates found under a directory
+type Registry struct {
+	templates map[string]*Template
+}
+
+// variablePattern matches {{name}} references. Go template actions such as
+// {{.Title}} do not match, so templates can ship html/template files.
+var variablePattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// LoadRegistry loads every subdirectory of dir that contains a manifest.
+// A missing directory yields an empty registry.
+func LoadRegistry(dir string) (*Registry, error) {
+	r := &Registry{templates: make(map[string]*Template)}
+
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read templates directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		templateDir := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(filepath.Join(templateDir, ManifestFile))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read manifest for template %s: %w", entry.Name(), err)
+		}
+
+		var manifest Manifest
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			return nil, fmt.Errorf("failed to parse manifest for template %s: %w", entry.Name(), err)
+		}
+		if manifest.Name == "" {
+			manifest.Name = entry.Name()
+		}
+		if _, exists := r.templates[manifest.Name]; exists {
+			return nil, fmt.Errorf("duplicate template name: %s", manifest.Name)
+		}
+
+		r.templates[manifest.Name] = &Template{Manifest: manifest, dir: templateDir}
+	}
+
+	return r, nil
+}
+
+// Get returns the named template
+func (r *Registry) Get(name string) (*Template, bool) {
+	t, ok := r.templates[name]
+	return t, ok
+}
+
+// List returns the manifests of all templates, sorted by name
+func (r *Registry) List() []Manifest {
+	manifests := make([]Manifest, 0, len(r.templates))
+	for _, t := range r.templates {
+		manifests = append(manifests, t.Manifest)
+	}
+	sort.Slice(manifests, func(i, j int) bool { return manifests[i].Name < manifests[j].Name })
+	return manifests
+}
+
+// ResolveVars applies defaults to vars and checks that every required
+// variable is set and no unknown variable is passed
+func (t *Template) ResolveVars(vars map[string]string) (map[string]string, error) {
+	known := make(map[string]bool, len(t.Variables))
+	resolved := make(map[string]string, len(t.Variables))
+
+	for _, v := range t.Variables {
+		known[v.Name] = true
+		value, ok := vars[v.Name]
+		if !ok || value == "" {
+			if v.Required {
+				return nil, fmt.Errorf("template %s requires variable %q", t.Name, v.Name)
+			}
+			value = v.Default
+		}
+		resolved[v.Name] = value
+	}
+
+	for name := range vars {
+		if !known[name] {
+			return nil, fmt.Errorf("template %s has no variable %q", t.Name, name)
+		}
+	}
+
+	return resolved, nil
+}
+
+// Render returns the template files with variables substituted in their
+// paths and contents. vars must come from ResolveVars.
+func (t *Template) Render(vars map[string]string) ([]llm.FileInfo, error) {
+	var files []llm.FileInfo
+
+	err := filepath.WalkDir(t.dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return fmt.Errorf("template %s contains a non-regular file: %s", t.Name, p)
+		}
+
+		rel, err := filepath.Rel(t.dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == ManifestFile {
+			return nil
+		}
+
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+
+		outPath := substitute(strings.TrimSuffix(rel, templateSuffix), vars)
+		files = append(files, llm.FileInfo{
+			Path:    outPath,
+			Content: substitute(string(content), vars),
+			Type:    strings.TrimPrefix(path.Ext(outPath), "."),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to render template %s: %w", t.Name, err)
+	}
+
+	return files, nil
+}
+
+// IsPlaceholder reports whether the rendered file at p may be replaced by
+// the LLM
+func (t *Template) IsPlaceholder(p string, vars map[string]string) bool {
+	for _, placeholder := range t.Placeholders {
+		if substitute(placeholder.Path, vars) == p {
+			return true
+		}
+	}
+	return false
+}
+
+// Instruction builds the LLM instruction for generating a project from the
+// template: the user's prompt plus the rules for what may change
+func (t *Template) Instruction(prompt string, vars map[string]string) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s\n\n", prompt)
+	fmt.Fprintf(&b, "The project starts from the %q template (%s). The existing files are provided below.\n", t.Name, t.Description)
+	b.WriteString("Rules:\n")
+	b.WriteString("- Do not modify existing files unless they are listed as placeholders below\n")
+	b.WriteString("- Fill in every placeholder file with its complete content\n")
+	b.WriteString("- Add new files as needed, following the existing layout and conventions\n")
+
+	if len(t.Placeholders) > 0 {
+		b.WriteString("\nPlaceholders to fill:\n")
+		for _, placeholder := range t.Placeholders {
+			fmt.Fprintf(&b, "- %s: %s\n", substitute(placeholder.Path, vars), placeholder.Description)
+		}
+	}
+
+	if len(vars) > 0 {
+		b.WriteString("\nTemplate variables:\n")
+		names := make([]string, 0, len(vars))
+		for name := range vars {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(&b, "- %s = %s\n", name, vars[name])
+		}
+	}
+
+	if t.Instructions != "" {
+		fmt.Fprintf(&b, "\n%s\n", substitute(t.Instructions, vars))
+	}
+
+	return b.String()
+}
+
+// substitute replaces {{name}} references to known variables, leaving
+// anything else untouched
+func substitute(s string, vars map[string]string) string {
+	return variablePattern.ReplaceAllStringFunc(s, func(match string) string {
+		name := variablePattern.FindStringSubmatch(match)[1]
+		if value, ok := vars[name]; ok {
+			return value
+		}
+		return match
+	})
+}
@@ -0,0 +1,235 @@
+package templates
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// writeTree writes files, keyed by slash-separated path, under dir
+func writeTree(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+const serviceManifest = `{
+  "name": "service",
+  "description": "HTTP service",
+  "variables": [
+    {"name": "module_path", "required": true},
+    {"name": "port", "default": "8080"}
+  ],
+  "placeholders": [{"path": "cmd/{{module_path}}/handler.go", "description": "Handlers"}],
+  "instructions": "Listen on {{port}}."
+}`
+
+func TestLoadRegistry(t *testing.T) {
+	tests := []struct {
+		name      string
+		files     map[string]string // nil for a missing directory
+		wantNames []string
+		wantErr   string
+	}{
+		{"missing directory", nil, []string{}, ""},
+		{
+			"templates sorted by name",
+			map[string]string{
+				"b/template.json": `{"name": "zeta", "description": "Z"}`,
+				"a/template.json": `{"description": "A"}`,
+				"notes/README.md": "not a template",
+				"loose.json":      `{"name": "ignored"}`,
+			},
+			[]string{"a", "zeta"},
+			"",
+		},
+		{"malformed manifest", map[string]string{"a/template.json": `{"name": `}, nil, "failed to parse manifest for template a"},
+		{
+			"duplicate name",
+			map[string]string{"a/template.json": `{"name": "web"}`, "b/template.json": `{"name": "web"}`},
+			nil,
+			"duplicate template name: web",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "templates")
+			if tt.files != nil {
+				writeTree(t, dir, tt.files)
+			}
+
+			r, err := LoadRegistry(dir)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("err = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadRegistry: %v", err)
+			}
+
+			names := []string{}
+			for _, m := range r.List() {
+				names = append(names, m.Name)
+			}
+			if strings.Join(names, ",") != strings.Join(tt.wantNames, ",") {
+				t.Errorf("templates = %v, want %v", names, tt.wantNames)
+			}
+			if _, ok := r.Get("unknown"); ok {
+				t.Error("Get(unknown) found a template")
+			}
+		})
+	}
+}
+
+func TestResolveVars(t *testing.T) {
+	tmpl := &Template{Manifest: Manifest{Name: "service", Variables: []Variable{
+		{Name: "module_path", Required: true},
+		{Name: "port", Default: "8080"},
+		{Name: "owner"},
+	}}}
+
+	tests := []struct {
+		name    string
+		vars    map[string]string
+		want    map[string]string
+		wantErr string
+	}{
+		{"defaults", map[string]string{"module_path": "example.com/svc"}, map[string]string{"module_path": "example.com/svc", "port": "8080", "owner": ""}, ""},
+		{"overrides", map[string]string{"module_path": "example.com/svc", "port": "9090"}, map[string]string{"module_path": "example.com/svc", "port": "9090", "owner": ""}, ""},
+		{"empty value uses the default", map[string]string{"module_path": "example.com/svc", "port": ""}, map[string]string{"module_path": "example.com/svc", "port": "8080", "owner": ""}, ""},
+		{"missing required", map[string]string{"port": "9090"}, nil, `template service requires variable "module_path"`},
+		{"empty required", map[string]string{"module_path": ""}, nil, `template service requires variable "module_path"`},
+		{"unknown variable", map[string]string{"module_path": "example.com/svc", "name": "svc"}, nil, `template service has no variable "name"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tmpl.ResolveVars(tt.vars)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveVars: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("vars = %v, want %v", got, tt.want)
+			}
+			for name, value := range tt.want {
+				if got[name] != value {
+					t.Errorf("vars[%s] = %q, want %q", name, got[name], value)
+				}
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"service/template.json":                  serviceManifest,
+		"service/go.mod.tmpl":                    "module {{module_path}}\n",
+		"service/cmd/{{module_path}}/main.go":    "// Listens on {{ port }}\n",
+		"service/web/index.html":                 "<h1>{{.Title}}</h1> {{unknown}}\n",
+		"service/cmd/{{module_path}}/handler.go": "package main\n",
+	})
+	r, err := LoadRegistry(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl, ok := r.Get("service")
+	if !ok {
+		t.Fatal("template service not found")
+	}
+	vars, err := tmpl.ResolveVars(map[string]string{"module_path": "greet"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := tmpl.Render(vars)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+
+	want := []struct{ path, content, fileType string }{
+		{"cmd/greet/handler.go", "package main\n", "go"},
+		{"cmd/greet/main.go", "// Listens on 8080\n", "go"},
+		{"go.mod", "module greet\n", "mod"},
+		// Go template actions and unknown names are left alone
+		{"web/index.html", "<h1>{{.Title}}</h1> {{unknown}}\n", "html"},
+	}
+	if len(files) != len(want) {
+		t.Fatalf("files = %+v, want %d files", files, len(want))
+	}
+	for i, w := range want {
+		if files[i].Path != w.path || files[i].Content != w.content || files[i].Type != w.fileType {
+			t.Errorf("file %d = %q %q %q, want %q %q %q", i, files[i].Path, files[i].Content, files[i].Type, w.path, w.content, w.fileType)
+		}
+	}
+
+	if !tmpl.IsPlaceholder("cmd/greet/handler.go", vars) || tmpl.IsPlaceholder("cmd/greet/main.go", vars) {
+		t.Error("IsPlaceholder does not match the substituted placeholder path alone")
+	}
+	instruction := tmpl.Instruction("Add a greeting endpoint", vars)
+	for _, line := range []string{"- cmd/greet/handler.go: Handlers\n", "- port = 8080\n", "Listen on 8080.\n"} {
+		if !strings.Contains(instruction, line) {
+			t.Errorf("instruction lacks %q:\n%s", line, instruction)
+		}
+	}
+}
+
+func TestRenderRejectsSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"service/template.json": `{"name": "service"}`})
+	if err := os.Symlink("/etc/passwd", filepath.Join(dir, "service", "passwd")); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+	r, err := LoadRegistry(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl, _ := r.Get("service")
+	if _, err := tmpl.Render(nil); err == nil || !strings.Contains(err.Error(), "non-regular file") {
+		t.Errorf("err = %v, want a non-regular file error", err)
+	}
+}
+
+func TestShippedTemplates(t *testing.T) {
+	r, err := LoadRegistry(filepath.Join("..", "..", "templates"))
+	if err != nil {
+		t.Fatalf("LoadRegistry: %v", err)
+	}
+	tmpl, ok := r.Get("go-gin-service")
+	if !ok {
+		t.Fatal("go-gin-service template not found")
+	}
+	if _, err := tmpl.ResolveVars(nil); err == nil {
+		t.Error("ResolveVars accepted a missing module_path")
+	}
+	vars, err := tmpl.ResolveVars(map[string]string{"module_path": "github.com/octo/api"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := tmpl.Render(vars)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	for _, file := range files {
+		if strings.HasSuffix(file.Path, templateSuffix) || strings.Contains(file.Content, "{{module_path}}") {
+			t.Errorf("%s was not fully rendered", file.Path)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+// Package usage prices LLM token usage and aggregates it across tasks
+package usage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Price is the cost of a model's tokens in USD per million tokens
+type Price struct {
+	Prompt     float64 `json:"prompt"`
+	Completion float64 `json:"completion"`
+}
+
+// Prices maps model names to their price
+type Prices map[string]Price
+
+// DefaultPrices returns list prices for the models the service ships with.
+// Override or extend them with LoadPrices.
+func DefaultPrices() Prices {
+	return Prices{
+		"deepseek-chat":       {Prompt: 0.27, Completion: 1.10},
+		"deepseek-reasoner":   {Prompt: 0.55, Completion: 2.19},
+		"gpt-4-turbo-preview": {Prompt: 10, Completion: 30},
+		"gpt-4-turbo":         {Prompt: 10, Completion: 30},
+		"gpt-4o":              {Prompt: 2.5, Completion: 10},
+		"gpt-4o-mini":         {Prompt: 0.15, Completion: 0.60},
+		"claude-opus-4":       {Prompt: 15, Completion: 75},
+		"claude-sonnet-4":     {Prompt: 3, Completion: 15},
+		"claude-haiku-4":      {Prompt: 1, Completion: 5},
+		"claude-3-5-haiku":    {Prompt: 0.80, Completion: 4},
+	}
+}
+
+// LoadPrices reads a JSON price table of the form
+// {"model": {"prompt": 0.27, "completion": 1.10}} over the default prices.
+// An empty path returns the defaults.
+func LoadPrices(path string) (Prices, error) {
+	prices := DefaultPrices()
+	if path == "" {
+		return prices, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read price table: %w", err)
+	}
+
+	var overrides Prices
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return nil, fmt.Errorf("failed to parse price table: %w", err)
+	}
+	for model, price := range overrides {
+		prices[model] = price
+	}
+
+	return prices, nil
+}
+
+// Cost returns the cost of a call in USD. Models missing from the table
+// are looked up by their longest listed prefix, so dated snapshots such as
+// gpt-4o-2024-08-06 use the gpt-4o price; unknown models cost nothing and
+// report false.
+func (p Prices) Cost(model string, promptTokens, completionTokens int) (float64, bool) {
+	price, ok := p[model]
+	if !ok {
+		best := ""
+		for name := range p {
+			if strings.HasPrefix(model, name) && len(name) > len(best) {
+				best = name
+			}
+		}
+		if best == "" {
+			return 0, false
+		}
+		price = p[best]
+	}
+
+	return (float64(promptTokens)*price.Prompt + float64(completionTokens)*price.Completion) / 1e6, true
+}
+
+// Record is the usage of one LLM call
+type Record struct {
+	TaskID           string
+	Provider         string
+	Model            string
+	KeyID            string
+	Operation        string
+	PromptTokens     int
+	CompletionTokens int
+	TotalTokens      int
+	Cost             float64
+	Time             time.Time
+}
+
+// Summary aggregates the usage of a group of calls
+type Summary struct {
+	Key              string  `json:"key"`
+	Calls            int     `json:"calls"`
+	Tasks            int     `json:"tasks"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	TotalTokens      int     `json:"total_tokens"`
+	Cost             float64 `json:"cost_usd"`
+}
+
+// Ledger keeps the usage records of every task for aggregation
+type Ledger struct {
+	prices  Prices
+	mu      sync.RWMutex
+	records []Record
+}
+
+// NewLedger creates a ledger that prices calls with prices
+func NewLedger(prices Prices) *Ledger {
+	return &Ledger{prices: prices}
+}
+
+// Price returns the cost of a call and whether its model has a price
+func (l *Ledger) Price(model string, promptTokens, completionTokens int) (float64, bool) {
+	return l.prices.Cost(model, promptTokens, completionTokens)
+}
+
+// Add appends a record to the ledger
+func (l *Ledger) Add(record Record) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.records = append(l.records, record)
+}
+
+// ByKey aggregates the records in [from, to) by provider and API key.
+// A zero from or to leaves that end of the range open.
+func (l *Ledger) ByKey(from, to time.Time) []Summary {
+	return l.aggregate(from, to, func(r Record) string {
+		return r.Provider + "/" + r.KeyID
+	})
+}
+
+// ByDay aggregates the records in [from, to) by UTC day
+func (l *Ledger) ByDay(from, to time.Time) []Summary {
+	return l.aggregate(from, to, func(r Record) string {
+		return r.Time.UTC().Format(time.DateOnly)
+	})
+}
+
+// aggregate sums the records in [from, to) grouped by key, sorted by key
+func (l *Ledger) aggregate(from, to time.Time, key func(Record) string) []Summary {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	groups := make(map[string]*Summary)
+	tasks := make(map[string]map[string]bool)
+	for _, r := range l.records {
+		if (!from.IsZero() && r.Time.Before(from)) || (!to.IsZero() && !r.Time.Before(to)) {
+			continue
+		}
+
+		k := key(r)
+		s, ok := groups[k]
+		if !ok {
+			s = &Summary{Key: k}
+			groups[k] = s
+			tasks[k] = make(map[string]bool)
+		}
+		s.Calls++
+		s.PromptTokens += r.PromptTokens
+		s.CompletionTokens += r.CompletionTokens
+		s.TotalTokens += r.TotalTokens
+		s.Cost += r.Cost
+		tasks[k][r.TaskID] = true
+	}
+
+	summaries := make([]Summary, 0, len(groups))
+	for k, s := range groups {
+		s.Tasks = len(tasks[k])
+		summaries = append(summaries, *s)
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Key < summaries[j].Key })
+	return summaries
+}
@@ -0,0 +1,159 @@
+package usage
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCost(t *testing.T) {
+	prices := DefaultPrices()
+	tests := []struct {
+		name       string
+		model      string
+		prompt     int
+		completion int
+		want       float64
+		wantPriced bool
+	}{
+		{"exact match", "gpt-4o", 1_000_000, 1_000_000, 12.5, true},
+		{"dated snapshot", "gpt-4o-2024-08-06", 1_000_000, 0, 2.5, true},
+		{"longest prefix wins", "gpt-4o-mini-2024-07-18", 1_000_000, 1_000_000, 0.75, true},
+		{"exact beats a shorter prefix", "gpt-4o-mini", 0, 1_000_000, 0.60, true},
+		{"claude snapshot", "claude-sonnet-4-20250514", 2000, 500, (2000*3 + 500*15) / 1e6, true},
+		{"zero tokens", "deepseek-chat", 0, 0, 0, true},
+		{"unknown model", "llama-3-70b", 1000, 1000, 0, false},
+		{"prefix of a listed model", "gpt-4", 1000, 1000, 0, false},
+		{"empty model", "", 1000, 1000, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, priced := prices.Cost(tt.model, tt.prompt, tt.completion)
+			if priced != tt.wantPriced || math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Cost(%q, %d, %d) = %v, %v, want %v, %v", tt.model, tt.prompt, tt.completion, got, priced, tt.want, tt.wantPriced)
+			}
+		})
+	}
+}
+
+func TestLoadPrices(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string // File content, "" for no file
+		model   string
+		want    Price
+		wantErr string
+	}{
+		{"defaults", "", "deepseek-chat", Price{Prompt: 0.27, Completion: 1.10}, ""},
+		{"override", `{"deepseek-chat": {"prompt": 0.14, "completion": 0.28}}`, "deepseek-chat", Price{Prompt: 0.14, Completion: 0.28}, ""},
+		{"defaults kept", `{"deepseek-chat": {"prompt": 0.14, "completion": 0.28}}`, "gpt-4o", Price{Prompt: 2.5, Completion: 10}, ""},
+		{"new model", `{"llama-3": {"prompt": 0.5, "completion": 0.7}}`, "llama-3", Price{Prompt: 0.5, Completion: 0.7}, ""},
+		{"malformed", `{"llama-3": `, "", Price{}, "failed to parse price table"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := ""
+			if tt.data != "" {
+				path = filepath.Join(t.TempDir(), "prices.json")
+				if err := os.WriteFile(path, []byte(tt.data), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			prices, err := LoadPrices(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("err = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadPrices: %v", err)
+			}
+			if got := prices[tt.model]; got != tt.want {
+				t.Errorf("prices[%q] = %+v, want %+v", tt.model, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := LoadPrices(filepath.Join(t.TempDir(), "missing.json")); err == nil || !strings.Contains(err.Error(), "failed to read price table") {
+		t.Errorf("missing file err = %v", err)
+	}
+}
+
+func TestLedgerAggregation(t *testing.T) {
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	ledger := NewLedger(DefaultPrices())
+	for _, r := range []Record{
+		{TaskID: "t1", Provider: "openai", KeyID: "sk-a", TotalTokens: 100, Cost: 1, Time: day.Add(time.Hour)},
+		{TaskID: "t1", Provider: "openai", KeyID: "sk-a", TotalTokens: 50, Cost: 0.5, Time: day.Add(2 * time.Hour)},
+		{TaskID: "t2", Provider: "openai", KeyID: "sk-a", TotalTokens: 10, Cost: 0.1, Time: day.Add(25 * time.Hour)},
+		{TaskID: "t2", Provider: "deepseek", KeyID: "sk-b", TotalTokens: 20, Cost: 0.2, Time: day.Add(26 * time.Hour)},
+		// Local times are grouped by their UTC day
+		{TaskID: "t3", Provider: "deepseek", KeyID: "sk-b", TotalTokens: 5, Cost: 0.05, Time: day.Add(-time.Hour).In(time.FixedZone("UTC+2", 2*3600))},
+	} {
+		ledger.Add(r)
+	}
+
+	type group struct {
+		key    string
+		calls  int
+		tasks  int
+		tokens int
+	}
+	tests := []struct {
+		name   string
+		byDay  bool
+		from   time.Time
+		to     time.Time
+		groups []group
+	}{
+		{"by key, open range", false, time.Time{}, time.Time{}, []group{
+			{"deepseek/sk-b", 2, 2, 25},
+			{"openai/sk-a", 3, 2, 160},
+		}},
+		{"by day, open range", true, time.Time{}, time.Time{}, []group{
+			{"2026-02-28", 1, 1, 5},
+			{"2026-03-01", 2, 1, 150},
+			{"2026-03-02", 2, 1, 30},
+		}},
+		{"from is inclusive", false, day.Add(2 * time.Hour), time.Time{}, []group{
+			{"deepseek/sk-b", 1, 1, 20},
+			{"openai/sk-a", 2, 2, 60},
+		}},
+		{"to is exclusive", true, day, day.Add(25 * time.Hour), []group{
+			{"2026-03-01", 2, 1, 150},
+		}},
+		{"empty range", false, day.Add(48 * time.Hour), time.Time{}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var summaries []Summary
+			if tt.byDay {
+				summaries = ledger.ByDay(tt.from, tt.to)
+			} else {
+				summaries = ledger.ByKey(tt.from, tt.to)
+			}
+			if summaries == nil {
+				t.Fatal("summaries = nil, want an empty list")
+			}
+			if len(summaries) != len(tt.groups) {
+				t.Fatalf("summaries = %+v, want %d groups", summaries, len(tt.groups))
+			}
+			for i, want := range tt.groups {
+				s := summaries[i]
+				if s.Key != want.key || s.Calls != want.calls || s.Tasks != want.tasks || s.TotalTokens != want.tokens {
+					t.Errorf("summary %d = %+v, want %+v", i, s, want)
+				}
+			}
+		})
+	}
+
+	all := ledger.ByKey(time.Time{}, time.Time{})
+	if cost := all[1].Cost; math.Abs(cost-1.6) > 1e-9 {
+		t.Errorf("openai/sk-a cost = %v, want 1.6", cost)
+	}
+}
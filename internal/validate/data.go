@@ -0,0 +1,127 @@
+package validate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/parser"
+)
+
+// jsoncFiles are JSON files whose tooling accepts comments and trailing
+// commas, so a strict parse would report false errors
+var jsoncFiles = []string{"tsconfig", "jsconfig", ".eslintrc", "devcontainer"}
+
+// JSONValidator parses JSON files in-process
+type JSONValidator struct{}
+
+// NewJSONValidator creates a new JSON validator
+func NewJSONValidator() *JSONValidator {
+	return &JSONValidator{}
+}
+
+// Name returns the validator name
+func (v *JSONValidator) Name() string {
+	return "json"
+}
+
+// Validate reports files that are not well-formed JSON
+func (v *JSONValidator) Validate(ctx context.Context, dir string, files []File) ([]Diagnostic, error) {
+	var diags []Diagnostic
+
+	for _, file := range files {
+		if isJSONC(file.Path) {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(file.Path)))
+		if err != nil {
+			return nil, err
+		}
+
+		var value any
+		err = json.Unmarshal(data, &value)
+		if err == nil {
+			continue
+		}
+
+		diag := Diagnostic{Tool: "json", Severity: SeverityError, Path: file.Path, Message: err.Error()}
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			diag.Line, diag.Column = offsetPosition(data, syntaxErr.Offset)
+		}
+		diags = append(diags, diag)
+	}
+
+	return diags, nil
+}
+
+// isJSONC reports whether a JSON file is conventionally allowed comments
+func isJSONC(p string) bool {
+	name := strings.ToLower(path.Base(p))
+	if strings.HasPrefix(p, ".vscode/") {
+		return true
+	}
+	for _, prefix := range jsoncFiles {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// offsetPosition converts a byte offset to a 1-based line and column
+func offsetPosition(data []byte, offset int64) (int, int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	prefix := data[:offset]
+	line := 1 + strings.Count(string(prefix), "\n")
+	col := int(offset) - strings.LastIndex(string(prefix), "\n")
+	return line, col
+}
+
+// YAMLValidator parses YAML files, including multi-document streams,
+// in-process
+type YAMLValidator struct{}
+
+// NewYAMLValidator creates a new YAML validator
+func NewYAMLValidator() *YAMLValidator {
+	return &YAMLValidator{}
+}
+
+// Name returns the validator name
+func (v *YAMLValidator) Name() string {
+	return "yaml"
+}
+
+// Validate reports files that are not well-formed YAML
+func (v *YAMLValidator) Validate(ctx context.Context, dir string, files []File) ([]Diagnostic, error) {
+	var diags []Diagnostic
+
+	for _, file := range files {
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(file.Path)))
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := parser.ParseBytes(data, 0); err != nil {
+			diag := Diagnostic{Tool: "yaml", Severity: SeverityError, Path: file.Path, Message: err.Error()}
+			var yamlErr yaml.Error
+			if errors.As(err, &yamlErr) {
+				diag.Message = yamlErr.GetMessage()
+				if tk := yamlErr.GetToken(); tk != nil && tk.Position != nil {
+					diag.Line, diag.Column = tk.Position.Line, tk.Position.Column
+				}
+			}
+			diags = append(diags, diag)
+		}
+	}
+
+	return diags, nil
+}
@@ -0,0 +1,115 @@
+package validate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// GoConfig holds settings for validating Go projects
+type GoConfig struct {
+	GoBinary string        // Defaults to "go" on PATH
+	Offline  bool          // Set GOPROXY=off so validation never hits the network
+	ModCache string        // Optional GOMODCACHE to resolve dependencies from
+	Timeout  time.Duration // Per-command timeout
+}
+
+// GoValidator runs gofmt, go vet and go build on a project directory
+type GoValidator struct {
+	cfg GoConfig
+}
+
+// ErrToolMissing is returned when a required toolchain is not installed
+var ErrToolMissing = errors.New("toolchain not found")
+
+// NewGoValidator creates a new Go validator
+func NewGoValidator(cfg GoConfig) *GoValidator {
+	if cfg.GoBinary == "" {
+		cfg.GoBinary = "go"
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 2 * time.Minute
+	}
+	return &GoValidator{cfg: cfg}
+}
+
+// Name returns the validator name
+func (v *GoValidator) Name() string {
+	return "go"
+}
+
+// Validate checks the Go project in dir and returns its diagnostics. The
+// toolchain works on packages, so the whole module is checked regardless
+// of which Go files are passed.
+func (v *GoValidator) Validate(ctx context.Context, dir string, files []File) ([]Diagnostic, error) {
+	goBin, err := exec.LookPath(v.cfg.GoBinary)
+	if err != nil {
+		return nil, fmt.Errorf("go: %w", ErrToolMissing)
+	}
+
+	var diags []Diagnostic
+
+	if _, err := os.Stat(filepath.Join(dir, "go.mod")); os.IsNotExist(err) {
+		diags = append(diags, Diagnostic{Tool: "go", Severity: SeverityError, Path: "go.mod", Message: "project has Go files but no go.mod"})
+		return diags, nil
+	}
+
+	// gofmt -l lists files whose formatting differs, or reports syntax errors
+	if fmtOut, err := v.run(ctx, dir, gofmtBinary(goBin), "-l", "."); err != nil {
+		diags = append(diags, parseOutput("gofmt", SeverityError, dir, fmtOut)...)
+	} else {
+		for _, file := range strings.Fields(fmtOut) {
+			diags = append(diags, Diagnostic{Tool: "gofmt", Severity: SeverityWarning, Path: relativePath(dir, file), Message: "file is not gofmt-formatted"})
+		}
+	}
+
+	// go build first: vet output is noise when the code does not compile
+	if buildOut, err := v.run(ctx, dir, goBin, "build", "./..."); err != nil {
+		return append(diags, parseOutput("go build", SeverityError, dir, buildOut)...), nil
+	}
+
+	if vetOut, err := v.run(ctx, dir, goBin, "vet", "./..."); err != nil {
+		diags = append(diags, parseOutput("go vet", SeverityWarning, dir, vetOut)...)
+	}
+
+	return diags, nil
+}
+
+// run executes a toolchain command in dir and returns its combined output
+func (v *GoValidator) run(ctx context.Context, dir, name string, args ...string) (string, error) {
+	return runTool(ctx, dir, v.cfg.Timeout, v.env(), name, args...)
+}
+
+// goEnv is the host environment the Go toolchain needs in addition to
+// toolEnv: where its caches live and how it downloads modules
+var goEnv = []string{"GOPATH", "GOCACHE", "GOMODCACHE", "GOPROXY", "GONOSUMDB", "GOPRIVATE"}
+
+// env returns the environment for toolchain commands
+func (v *GoValidator) env() []string {
+	extra := []string{
+		"GOFLAGS=" + strings.TrimSpace(os.Getenv("GOFLAGS")+" -mod=mod"),
+		"GOWORK=off",
+		"GOTOOLCHAIN=local",
+	}
+	if v.cfg.Offline {
+		extra = append(extra, "GOPROXY=off", "GOSUMDB=off")
+	}
+	if v.cfg.ModCache != "" {
+		extra = append(extra, "GOMODCACHE="+v.cfg.ModCache)
+	}
+	return hostEnv(goEnv, extra...)
+}
+
+// gofmtBinary returns the gofmt that ships next to the go binary
+func gofmtBinary(goBin string) string {
+	candidate := strings.TrimSuffix(goBin, "go") + "gofmt"
+	if _, err := os.Stat(candidate); err == nil {
+		return candidate
+	}
+	return "gofmt"
+}
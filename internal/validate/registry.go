@@ -0,0 +1,140 @@
+package validate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// File types understood by the registry
+const (
+	TypeGo         = "go"
+	TypePython     = "python"
+	TypeJavaScript = "javascript"
+	TypeTypeScript = "typescript"
+	TypeJSON       = "json"
+	TypeYAML       = "yaml"
+)
+
+// typeAliases maps the type names the LLM reports to canonical file types
+var typeAliases = map[string]string{
+	"go":         TypeGo,
+	"golang":     TypeGo,
+	"py":         TypePython,
+	"python":     TypePython,
+	"js":         TypeJavaScript,
+	"jsx":        TypeJavaScript,
+	"javascript": TypeJavaScript,
+	"ts":         TypeTypeScript,
+	"tsx":        TypeTypeScript,
+	"typescript": TypeTypeScript,
+	"json":       TypeJSON,
+	"yaml":       TypeYAML,
+	"yml":        TypeYAML,
+}
+
+// typeByExt maps file extensions to file types
+var typeByExt = map[string]string{
+	".go":   TypeGo,
+	".py":   TypePython,
+	".js":   TypeJavaScript,
+	".mjs":  TypeJavaScript,
+	".cjs":  TypeJavaScript,
+	".jsx":  TypeJavaScript,
+	".ts":   TypeTypeScript,
+	".mts":  TypeTypeScript,
+	".cts":  TypeTypeScript,
+	".tsx":  TypeTypeScript,
+	".json": TypeJSON,
+	".yaml": TypeYAML,
+	".yml":  TypeYAML,
+}
+
+// File is a generated file to validate
+type File struct {
+	Path string // Relative to the project root
+	Type string // As reported by the LLM; may be empty
+}
+
+// FileType returns the canonical type of a file. The extension wins over
+// the declared type, since the LLM is not always consistent about it.
+func FileType(file File) string {
+	if t, ok := typeByExt[strings.ToLower(path.Ext(file.Path))]; ok {
+		return t
+	}
+	return typeAliases[strings.ToLower(strings.TrimSpace(file.Type))]
+}
+
+// Validator checks files of the types it is registered for
+type Validator interface {
+	// Name identifies the validator in logs and skip notices
+	Name() string
+	// Validate checks files written under dir. It returns an error
+	// wrapping ErrToolMissing when its toolchain is not installed.
+	Validate(ctx context.Context, dir string, files []File) ([]Diagnostic, error)
+}
+
+// registration is a validator and the file types it handles
+type registration struct {
+	validator Validator
+	types     map[string]bool
+}
+
+// Registry dispatches files to validators by file type
+type Registry struct {
+	registrations []registration
+}
+
+// NewRegistry creates an empty validator registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds a validator for the given file types. Validators run in
+// registration order.
+func (r *Registry) Register(v Validator, fileTypes ...string) {
+	types := make(map[string]bool, len(fileTypes))
+	for _, t := range fileTypes {
+		types[t] = true
+	}
+	r.registrations = append(r.registrations, registration{validator: v, types: types})
+}
+
+// Len returns the number of registered validators
+func (r *Registry) Len() int {
+	return len(r.registrations)
+}
+
+// Validate runs every validator that has matching files and merges their
+// diagnostics into a single report. Validators whose toolchain is missing
+// are skipped and returned by name.
+func (r *Registry) Validate(ctx context.Context, dir string, files []File) (Report, []string, error) {
+	report := make(Report)
+	var skipped []string
+
+	for _, reg := range r.registrations {
+		var matched []File
+		for _, file := range files {
+			if reg.types[FileType(file)] {
+				matched = append(matched, file)
+			}
+		}
+		if len(matched) == 0 {
+			continue
+		}
+
+		diags, err := reg.validator.Validate(ctx, dir, matched)
+		if errors.Is(err, ErrToolMissing) {
+			skipped = append(skipped, reg.validator.Name())
+			continue
+		}
+		if err != nil {
+			return nil, skipped, fmt.Errorf("%s validator: %w", reg.validator.Name(), err)
+		}
+		report.Add(diags...)
+	}
+
+	return report, skipped, nil
+}
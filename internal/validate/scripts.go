@@ -0,0 +1,227 @@
+package validate
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ScriptConfig holds settings for the Python and JavaScript/TypeScript
+// syntax checkers
+type ScriptConfig struct {
+	PythonBinary string        // Defaults to "python3"
+	NodeBinary   string        // Defaults to "node"
+	TSCBinary    string        // Defaults to "tsc"
+	Timeout      time.Duration // Per-command timeout
+}
+
+// withDefaults fills unset fields
+func (c ScriptConfig) withDefaults() ScriptConfig {
+	if c.PythonBinary == "" {
+		c.PythonBinary = "python3"
+	}
+	if c.NodeBinary == "" {
+		c.NodeBinary = "node"
+	}
+	if c.TSCBinary == "" {
+		c.TSCBinary = "tsc"
+	}
+	if c.Timeout == 0 {
+		c.Timeout = time.Minute
+	}
+	return c
+}
+
+// PythonValidator checks Python syntax with py_compile
+type PythonValidator struct {
+	cfg ScriptConfig
+}
+
+// NewPythonValidator creates a new Python validator
+func NewPythonValidator(cfg ScriptConfig) *PythonValidator {
+	return &PythonValidator{cfg: cfg.withDefaults()}
+}
+
+// Name returns the validator name
+func (v *PythonValidator) Name() string {
+	return "python"
+}
+
+// pythonLocation matches the 'File "x.py", line N' header of a syntax error
+var pythonLocation = regexp.MustCompile(`File "(.+?)", line (\d+)`)
+
+// Validate compiles each file. py_compile stops at the first failure, so
+// files are checked one at a time.
+func (v *PythonValidator) Validate(ctx context.Context, dir string, files []File) ([]Diagnostic, error) {
+	python, err := exec.LookPath(v.cfg.PythonBinary)
+	if err != nil {
+		return nil, fmt.Errorf("python: %w", ErrToolMissing)
+	}
+
+	// Keep bytecode out of the project directory
+	cacheDir, err := os.MkdirTemp("", "gen-code-pycache-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(cacheDir)
+	env := hostEnv(nil, "PYTHONPYCACHEPREFIX="+cacheDir)
+
+	var diags []Diagnostic
+	for _, file := range files {
+		out, err := runTool(ctx, dir, v.cfg.Timeout, env, python, "-m", "py_compile", argPath(file.Path))
+		if err == nil {
+			continue
+		}
+
+		diag := Diagnostic{Tool: "py_compile", Severity: SeverityError, Path: file.Path, Message: lastLine(out)}
+		if m := pythonLocation.FindStringSubmatch(out); m != nil {
+			diag.Line, _ = strconv.Atoi(m[2])
+		}
+		diags = append(diags, diag)
+	}
+
+	return diags, nil
+}
+
+// JavaScriptValidator checks JavaScript syntax with node --check
+type JavaScriptValidator struct {
+	cfg ScriptConfig
+}
+
+// NewJavaScriptValidator creates a new JavaScript validator
+func NewJavaScriptValidator(cfg ScriptConfig) *JavaScriptValidator {
+	return &JavaScriptValidator{cfg: cfg.withDefaults()}
+}
+
+// Name returns the validator name
+func (v *JavaScriptValidator) Name() string {
+	return "javascript"
+}
+
+// nodeLocation matches the "file.js:N" header of a node syntax error
+var nodeLocation = regexp.MustCompile(`^(.+):(\d+)$`)
+
+// Validate checks each file. JSX is skipped because node cannot parse it.
+func (v *JavaScriptValidator) Validate(ctx context.Context, dir string, files []File) ([]Diagnostic, error) {
+	node, err := exec.LookPath(v.cfg.NodeBinary)
+	if err != nil {
+		return nil, fmt.Errorf("node: %w", ErrToolMissing)
+	}
+
+	var diags []Diagnostic
+	for _, file := range files {
+		if strings.ToLower(path.Ext(file.Path)) == ".jsx" {
+			continue
+		}
+
+		out, err := runTool(ctx, dir, v.cfg.Timeout, hostEnv(nil), node, "--check", argPath(file.Path))
+		if err == nil {
+			continue
+		}
+		diags = append(diags, parseNodeError(file.Path, out))
+	}
+
+	return diags, nil
+}
+
+// parseNodeError extracts the position and message from node --check
+// output, which prints the offending line with a caret under the column
+func parseNodeError(p, output string) Diagnostic {
+	diag := Diagnostic{Tool: "node --check", Severity: SeverityError, Path: p, Message: strings.TrimSpace(output)}
+
+	lines := strings.Split(output, "\n")
+	for i, line := range lines {
+		if m := nodeLocation.FindStringSubmatch(strings.TrimSpace(line)); m != nil && diag.Line == 0 {
+			diag.Line, _ = strconv.Atoi(m[2])
+			if i+2 < len(lines) {
+				if caret := strings.Index(lines[i+2], "^"); caret >= 0 {
+					diag.Column = caret + 1
+				}
+			}
+		}
+		if strings.Contains(line, "Error: ") && !strings.HasPrefix(strings.TrimSpace(line), "at ") {
+			diag.Message = strings.TrimSpace(line)
+			break
+		}
+	}
+	return diag
+}
+
+// TypeScriptValidator checks TypeScript syntax with tsc
+type TypeScriptValidator struct {
+	cfg ScriptConfig
+}
+
+// NewTypeScriptValidator creates a new TypeScript validator
+func NewTypeScriptValidator(cfg ScriptConfig) *TypeScriptValidator {
+	return &TypeScriptValidator{cfg: cfg.withDefaults()}
+}
+
+// Name returns the validator name
+func (v *TypeScriptValidator) Name() string {
+	return "typescript"
+}
+
+// tscLine matches "file.ts(line,col): error TSnnnn: message"
+var tscLine = regexp.MustCompile(`^(.+?)\((\d+),(\d+)\): error TS(\d+): (.+)$`)
+
+// Validate type-checks the files without resolving imports and keeps only
+// syntax errors (TS1xxx): dependencies are not installed, so semantic
+// errors would mostly be unresolved modules.
+func (v *TypeScriptValidator) Validate(ctx context.Context, dir string, files []File) ([]Diagnostic, error) {
+	tsc, err := exec.LookPath(v.cfg.TSCBinary)
+	if err != nil {
+		return nil, fmt.Errorf("tsc: %w", ErrToolMissing)
+	}
+
+	args := []string{"--noEmit", "--pretty", "false", "--noResolve", "--skipLibCheck", "--jsx", "preserve"}
+	for _, file := range files {
+		args = append(args, argPath(file.Path))
+	}
+	out, err := runTool(ctx, dir, v.cfg.Timeout, hostEnv(nil), tsc, args...)
+	if err == nil {
+		return nil, nil
+	}
+
+	var diags []Diagnostic
+	for _, line := range strings.Split(out, "\n") {
+		m := tscLine.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		code, _ := strconv.Atoi(m[4])
+		if code >= 2000 {
+			continue
+		}
+		lineNo, _ := strconv.Atoi(m[2])
+		col, _ := strconv.Atoi(m[3])
+		diags = append(diags, Diagnostic{
+			Tool:     "tsc",
+			Severity: SeverityError,
+			Path:     relativePath(dir, m[1]),
+			Line:     lineNo,
+			Column:   col,
+			Message:  fmt.Sprintf("TS%s: %s", m[4], m[5]),
+		})
+	}
+	return diags, nil
+}
+
+// argPath prefixes a project-relative path with ./ so that a generated
+// file named like an option, such as --require=x.js, reaches the tool as
+// a file name
+func argPath(p string) string {
+	return "./" + p
+}
+
+// lastLine returns the last non-empty line of output
+func lastLine(output string) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
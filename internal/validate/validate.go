@@ -0,0 +1,162 @@
+package validate
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Diagnostic severities
+const (
+	SeverityError   = "error"   // The project does not build or run
+	SeverityWarning = "warning" // Style or likely-bug findings
+)
+
+// Diagnostic is a problem reported by a validation tool
+type Diagnostic struct {
+	Tool     string
+	Severity string
+	Path     string // Relative to the project root; "." for project-level problems
+	Line     int
+	Column   int
+	Message  string
+}
+
+// Report holds diagnostics grouped by file path
+type Report map[string][]Diagnostic
+
+// Add adds diagnostics to the report
+func (r Report) Add(diags ...Diagnostic) {
+	for _, d := range diags {
+		if d.Path == "" {
+			d.Path = "."
+		}
+		r[d.Path] = append(r[d.Path], d)
+	}
+}
+
+// Count returns the total number of diagnostics
+func (r Report) Count() int {
+	count := 0
+	for _, diags := range r {
+		count += len(diags)
+	}
+	return count
+}
+
+// Errors returns the number of error-severity diagnostics
+func (r Report) Errors() int {
+	count := 0
+	for _, diags := range r {
+		for _, d := range diags {
+			if d.Severity == SeverityError {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// Paths returns the files that have diagnostics, sorted
+func (r Report) Paths() []string {
+	paths := make([]string, 0, len(r))
+	for p := range r {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// positionLine matches compiler-style "file:line[:col]: message" output
+var positionLine = regexp.MustCompile(`^(.+?):(\d+)(?::(\d+))?: (.+)$`)
+
+// parseOutput turns compiler-style tool output into diagnostics. Lines
+// without a position become project-level diagnostics; package headers
+// such as "# example.com/pkg" are skipped.
+func parseOutput(tool, severity, dir, output string) []Diagnostic {
+	var diags []Diagnostic
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		m := positionLine.FindStringSubmatch(line)
+		if m == nil {
+			diags = append(diags, Diagnostic{Tool: tool, Severity: severity, Path: ".", Message: line})
+			continue
+		}
+
+		lineNo, _ := strconv.Atoi(m[2])
+		col, _ := strconv.Atoi(m[3])
+		diags = append(diags, Diagnostic{
+			Tool:     tool,
+			Severity: severity,
+			Path:     relativePath(dir, strings.TrimPrefix(m[1], "vet: ")),
+			Line:     lineNo,
+			Column:   col,
+			Message:  m[4],
+		})
+	}
+
+	return diags
+}
+
+// relativePath converts a tool-reported path to a slash path under dir
+func relativePath(dir, p string) string {
+	if filepath.IsAbs(p) {
+		if rel, err := filepath.Rel(dir, p); err == nil {
+			p = rel
+		}
+	}
+	return filepath.ToSlash(filepath.Clean(p))
+}
+
+// toolEnv is the host environment passed to every toolchain command. The
+// server's own environment holds API keys and tokens, which must not reach
+// the toolchains checking untrusted generated code.
+var toolEnv = []string{"PATH", "HOME", "TMPDIR"}
+
+// hostEnv returns the host's values of toolEnv and of the given variables,
+// skipping unset ones, followed by extra
+func hostEnv(names []string, extra ...string) []string {
+	var env []string
+	for _, name := range append(append([]string(nil), toolEnv...), names...) {
+		if value, ok := os.LookupEnv(name); ok {
+			env = append(env, name+"="+value)
+		}
+	}
+	return append(env, extra...)
+}
+
+// runTool executes a command in dir with a timeout and returns its combined
+// output
+func runTool(ctx context.Context, dir string, timeout time.Duration, env []string, name string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	cmd.Env = env
+
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return out.String(), fmt.Errorf("%s timed out after %s", strings.Join(append([]string{name}, args...), " "), timeout)
+	}
+	return out.String(), err
+}
@@ -0,0 +1,121 @@
+package validate
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// lookup returns the last value of name in env, as exec does
+func lookup(env []string, name string) (string, bool) {
+	value, found := "", false
+	for _, kv := range env {
+		if k, v, ok := strings.Cut(kv, "="); ok && k == name {
+			value, found = v, true
+		}
+	}
+	return value, found
+}
+
+func TestGoValidatorEnv(t *testing.T) {
+	t.Setenv("PATH", "/usr/bin:/bin")
+	t.Setenv("HOME", "/home/gen")
+	t.Setenv("GOCACHE", "/var/cache/go-build")
+	t.Setenv("GOFLAGS", "-trimpath")
+	t.Setenv("GITHUB_TOKEN", "ghp_secret")
+	t.Setenv("OPENAI_API_KEY", "sk-secret")
+
+	tests := []struct {
+		name string
+		cfg  GoConfig
+		want map[string]string
+	}{
+		{"online", GoConfig{}, map[string]string{
+			"PATH":    "/usr/bin:/bin",
+			"HOME":    "/home/gen",
+			"GOCACHE": "/var/cache/go-build",
+			"GOFLAGS": "-trimpath -mod=mod",
+			"GOWORK":  "off",
+		}},
+		{"offline with module cache", GoConfig{Offline: true, ModCache: "/srv/gomod"}, map[string]string{
+			"GOPROXY":    "off",
+			"GOSUMDB":    "off",
+			"GOMODCACHE": "/srv/gomod",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := NewGoValidator(tt.cfg).env()
+
+			for name, want := range tt.want {
+				if got, _ := lookup(env, name); got != want {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+			for _, name := range []string{"GITHUB_TOKEN", "OPENAI_API_KEY"} {
+				if _, ok := lookup(env, name); ok {
+					t.Errorf("%s leaked into the toolchain environment", name)
+				}
+			}
+		})
+	}
+}
+
+func TestHostEnv(t *testing.T) {
+	t.Setenv("PATH", "/usr/bin")
+	t.Setenv("HOME", "/home/gen")
+	t.Setenv("TMPDIR", "")
+	t.Setenv("ANTHROPIC_API_KEY", "sk-ant-secret")
+
+	env := hostEnv(nil, "PYTHONPYCACHEPREFIX=/tmp/pycache")
+	want := []string{"PATH=/usr/bin", "HOME=/home/gen", "TMPDIR=", "PYTHONPYCACHEPREFIX=/tmp/pycache"}
+	if !slices.Equal(env, want) {
+		t.Errorf("hostEnv = %v, want %v", env, want)
+	}
+}
+
+func TestScriptValidatorsPassPathsAsFiles(t *testing.T) {
+	// A stand-in tool that records its arguments, one per line
+	bin := t.TempDir()
+	argsFile := filepath.Join(bin, "args")
+	tool := filepath.Join(bin, "tool")
+	script := "#!/bin/sh\nprintf '%s\\n' \"$@\" > " + argsFile + "\n"
+	if err := os.WriteFile(tool, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	cfg := ScriptConfig{PythonBinary: tool, NodeBinary: tool, TSCBinary: tool}
+
+	tests := []struct {
+		name      string
+		validator Validator
+		file      string
+		want      string // Last argument
+	}{
+		{"python", NewPythonValidator(cfg), "--version.py", "./--version.py"},
+		{"node", NewJavaScriptValidator(cfg), "--require=x.js", "./--require=x.js"},
+		{"tsc", NewTypeScriptValidator(cfg), "src/--outDir=x.ts", "./src/--outDir=x.ts"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.validator.Validate(context.Background(), t.TempDir(), []File{{Path: tt.file}}); err != nil {
+				t.Fatalf("Validate: %v", err)
+			}
+			data, err := os.ReadFile(argsFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			args := strings.Split(strings.TrimSpace(string(data)), "\n")
+			if got := args[len(args)-1]; got != tt.want {
+				t.Errorf("args = %q, want the file passed as %q", args, tt.want)
+			}
+			for _, arg := range args {
+				if arg == tt.file {
+					t.Errorf("args = %q pass the bare path, which the tool may parse as an option", args)
+				}
+			}
+		})
+	}
+}